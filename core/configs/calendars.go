@@ -0,0 +1,220 @@
+package configs
+
+import (
+	"fmt"
+	"time"
+)
+
+// Calendar is a named set of windows that defines when the tasks that reference it are allowed to be activated.
+type Calendar struct {
+	Name string `json:"name"`
+	// Include are the windows in which the activations are allowed. If empty, any moment not excluded is allowed.
+	Include []Window `json:"include"`
+	// Exclude are the windows in which the activations are suppressed. They take precedence over `Include`.
+	Exclude []Window `json:"exclude"`
+}
+
+// Window is a period of time, expressed in the timezone of the host. It's made by a range of hours (From and To, with
+// the format HH:mm) repeated on some days, which can be limited to certain days of the week and/or to specific
+// dates. If To is previous to From, the range ends on the next day.
+type Window struct {
+	// From is the start of the range, included. If empty, the start of the day.
+	From string `json:"from,omitempty"`
+	// To is the end of the range, excluded. If empty, the end of the day.
+	To string `json:"to,omitempty"`
+	// Weekdays limits the days of the week on which the range starts (0 = Sunday). If empty, every day.
+	Weekdays []time.Weekday `json:"weekdays,omitempty"`
+	// Dates limits the dates (format YYYY-MM-DD) on which the range starts. If empty, every date.
+	Dates []string `json:"dates,omitempty"`
+}
+
+// Allows reports whether an activation at the moment `t` is allowed by the calendar. The calendar must be valid (see
+// `Calendar.Validate`).
+func (c *Calendar) Allows(t time.Time) bool {
+	for i := range c.Exclude {
+		if c.Exclude[i].Contains(t) {
+			return false
+		}
+	}
+
+	if len(c.Include) == 0 {
+		return true
+	}
+
+	for i := range c.Include {
+		if c.Include[i].Contains(t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Validate checks the name of the calendar and the format of its windows.
+func (c *Calendar) Validate() error {
+	if c.Name == "" {
+		return fmt.Errorf("the name of the calendar is empty")
+	}
+
+	for i := range c.Include {
+		err := c.Include[i].Validate()
+		if err != nil {
+			return fmt.Errorf("include %d: %s", i, err.Error())
+		}
+	}
+
+	for i := range c.Exclude {
+		err := c.Exclude[i].Validate()
+		if err != nil {
+			return fmt.Errorf("exclude %d: %s", i, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// Contains reports whether the moment `t` is inside the window. An invalid window contains nothing.
+func (w *Window) Contains(t time.Time) bool {
+	from, to, err := w.parseRange()
+	if err != nil {
+		return false
+	}
+
+	m := t.Hour()*60 + t.Minute()
+
+	if from < to {
+		return m >= from && m < to && w.startsOn(t)
+	}
+
+	// The range ends on the next day.
+	switch {
+	case m >= from:
+		return w.startsOn(t)
+	case m < to:
+		return w.startsOn(t.AddDate(0, 0, -1))
+	default:
+		return false
+	}
+}
+
+// Validate checks the format of the window.
+func (w *Window) Validate() error {
+	_, _, err := w.parseRange()
+	if err != nil {
+		return err
+	}
+
+	for _, d := range w.Weekdays {
+		if d < time.Sunday || d > time.Saturday {
+			return fmt.Errorf("invalid day of the week %d", d)
+		}
+	}
+
+	for _, d := range w.Dates {
+		if _, err := time.Parse("2006-01-02", d); err != nil {
+			return fmt.Errorf("invalid date '%s', the format must be YYYY-MM-DD", d)
+		}
+	}
+
+	return nil
+}
+
+// startsOn reports whether the range of the window starts on the day of `t`.
+func (w *Window) startsOn(t time.Time) bool {
+	if len(w.Weekdays) > 0 && !containsWeekday(w.Weekdays, t.Weekday()) {
+		return false
+	}
+
+	if len(w.Dates) > 0 && !containsDate(w.Dates, t.Format("2006-01-02")) {
+		return false
+	}
+
+	return true
+}
+
+func containsWeekday(weekdays []time.Weekday, weekday time.Weekday) bool {
+	for _, d := range weekdays {
+		if d == weekday {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsDate(dates []string, date string) bool {
+	for _, d := range dates {
+		if d == date {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseRange returns the range of the window as minutes since the start of the day.
+func (w *Window) parseRange() (from, to int, err error) {
+	from, to = 0, 24*60
+
+	if w.From != "" {
+		from, err = parseHour(w.From)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	if w.To != "" {
+		to, err = parseHour(w.To)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	if from == to {
+		return 0, 0, fmt.Errorf("the window from '%s' to '%s' is empty", w.From, w.To)
+	}
+
+	return from, to, nil
+}
+
+func parseHour(hour string) (int, error) {
+	h, err := time.Parse("15:04", hour)
+	if err != nil {
+		return 0, fmt.Errorf("invalid hour '%s', the format must be HH:mm", hour)
+	}
+
+	return h.Hour()*60 + h.Minute(), nil
+}
+
+// Calendar returns the calendar with the given name.
+func (c *Configs) Calendar(name string) (calendar Calendar, found bool) {
+	c.RLock()
+	defer c.RUnlock()
+
+	for _, calendar := range c.Calendars {
+		if calendar.Name == name {
+			return calendar, true
+		}
+	}
+
+	return Calendar{}, false
+}
+
+// validateCalendars checks the format of all the calendars and that their names are unique.
+func (c *Configs) validateCalendars() error {
+	names := make(map[string]bool)
+
+	for i := range c.Calendars {
+		err := c.Calendars[i].Validate()
+		if err != nil {
+			return fmt.Errorf("calendar %d: %s", i, err.Error())
+		}
+
+		if names[c.Calendars[i].Name] {
+			return fmt.Errorf("the name of the calendar '%s' is duplicated", c.Calendars[i].Name)
+		}
+		names[c.Calendars[i].Name] = true
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package configs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalendar(t *testing.T) {
+	assert := assert.New(t)
+
+	// Allowed at nights (from 22:00 to 06:00), except the weekends and the holidays.
+	calendar := Calendar{
+		Name:    "nights",
+		Include: []Window{{From: "22:00", To: "06:00"}},
+		Exclude: []Window{
+			{Weekdays: []time.Weekday{time.Saturday, time.Sunday}},
+			{Dates: []string{"2021-03-24"}},
+		},
+	}
+	assert.NoError(calendar.Validate(), "the calendar should be valid")
+
+	moments := []struct {
+		t       time.Time
+		allowed bool
+	}{
+		// Friday.
+		{time.Date(2021, 3, 5, 23, 0, 0, 0, time.Local), true},
+		{time.Date(2021, 3, 5, 12, 0, 0, 0, time.Local), false},
+		{time.Date(2021, 3, 5, 22, 0, 0, 0, time.Local), true},
+		{time.Date(2021, 3, 5, 21, 59, 0, 0, time.Local), false},
+		// Thursday night, the range ends on Friday.
+		{time.Date(2021, 3, 5, 5, 59, 0, 0, time.Local), true},
+		{time.Date(2021, 3, 5, 6, 0, 0, 0, time.Local), false},
+		// Saturday.
+		{time.Date(2021, 3, 6, 23, 0, 0, 0, time.Local), false},
+		// Holiday (Wednesday).
+		{time.Date(2021, 3, 24, 23, 0, 0, 0, time.Local), false},
+		{time.Date(2021, 3, 25, 23, 0, 0, 0, time.Local), true},
+	}
+
+	for i, m := range moments {
+		assert.Equalf(m.allowed, calendar.Allows(m.t), "[moment %d] %s", i, m.t.Format(time.RFC1123))
+	}
+
+	// A calendar without windows to include only suppresses the excluded ones.
+	weekdays := Calendar{
+		Name:    "weekdays",
+		Exclude: []Window{{Weekdays: []time.Weekday{time.Saturday, time.Sunday}}},
+	}
+	assert.True(weekdays.Allows(time.Date(2021, 3, 5, 12, 0, 0, 0, time.Local)))
+	assert.False(weekdays.Allows(time.Date(2021, 3, 7, 12, 0, 0, 0, time.Local)))
+
+	invalid := []Calendar{
+		{Name: ""},
+		{Name: "a", Include: []Window{{From: "25:00"}}},
+		{Name: "a", Include: []Window{{From: "10:00", To: "10:00"}}},
+		{Name: "a", Exclude: []Window{{Weekdays: []time.Weekday{7}}}},
+		{Name: "a", Exclude: []Window{{Dates: []string{"24/03/2021"}}}},
+	}
+
+	for i, c := range invalid {
+		assert.Errorf(c.Validate(), "[calendar %d] the validation must fail", i)
+	}
+
+	cfg := Configs{Calendars: []Calendar{calendar, weekdays}}
+	assert.NoError(cfg.validateCalendars())
+
+	found, ok := cfg.Calendar("weekdays")
+	assert.True(ok, "the calendar must be found by its name")
+	assert.Equal(weekdays, found)
+
+	_, ok = cfg.Calendar("weekends")
+	assert.False(ok)
+
+	cfg.Calendars = append(cfg.Calendars, weekdays)
+	assert.Error(cfg.validateCalendars(), "the names of the calendars must be unique")
+}
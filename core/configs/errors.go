@@ -22,3 +22,9 @@ var ErrUserNotFound = errors.New("user not found")
 var ErrUsernameExists = errors.New(
 	"the username is already in use",
 )
+
+// ErrInvalidCalendar is the error used when one of the calendars (`Configs.Calendars`) is incorrectly defined.
+var ErrInvalidCalendar = errors.New("invalid calendar")
+
+// ErrInvalidMQTTBroker is the error used when one of the MQTT brokers (`Configs.MQTTBrokers`) is incorrectly defined.
+var ErrInvalidMQTTBroker = errors.New("invalid MQTT broker")
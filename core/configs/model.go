@@ -6,13 +6,16 @@ import (
 
 // Configs is the struct used to store all PiWorker configurations.
 type Configs struct {
-	Behavior   Behavior   `json:"behavior"`
-	Security   Security   `json:"security"`
-	Backups    Backups    `json:"backups"`
-	APIConfigs APIConfigs `json:"api-configs"`
-	Updates    Updates    `json:"updates"`
-	WebUI      WebUI      `json:"webui"`
-	Users      []User     `json:"users"`
+	Behavior    Behavior     `json:"behavior"`
+	Security    Security     `json:"security"`
+	Backups     Backups      `json:"backups"`
+	APIConfigs  APIConfigs   `json:"api-configs"`
+	Updates     Updates      `json:"updates"`
+	WebUI       WebUI        `json:"webui"`
+	Users       []User       `json:"users"`
+	Calendars   []Calendar   `json:"calendars"`
+	Plugins     Plugins      `json:"plugins"`
+	MQTTBrokers []MQTTBroker `json:"mqtt-brokers"`
 
 	path         string
 	sync.RWMutex `json:"-"`
@@ -21,6 +24,15 @@ type Configs struct {
 // Behavior is the struct used to store Behavior configs of PiWorker.
 type Behavior struct {
 	LoopSleep int64 `json:"loop-sleep(ms)"`
+	// MaxConcurrentTasks limits the executions of tasks in progress at the same time, the rest wait for their turn.
+	// Zero means no limit.
+	MaxConcurrentTasks int `json:"max-concurrent-tasks"`
+	// ActionWorkers is the number of actions executed at the same time by the queue of actions. Zero means one per
+	// CPU.
+	ActionWorkers int `json:"action-workers"`
+	// MaxExecutionsPerTask limits the executions stored on the history of each task, the oldest ones are removed.
+	// Zero means no limit.
+	MaxExecutionsPerTask int `json:"max-executions-per-task"`
 }
 
 // Security is the struct used to store configs related with the security of PiWorker.
@@ -48,6 +60,10 @@ type APIConfigs struct {
 	StatisticsAPI  bool `json:"statistics-api"`
 	LogsAPI        bool `json:"logs-api"`
 	TypesCompatAPI bool `json:"types-compat-api"`
+	ExecutionsAPI  bool `json:"executions-api"`
+	RunTaskAPI     bool `json:"run-task-api"`
+	DryRunAPI      bool `json:"dry-run-api"`
+	WebhooksAPI    bool `json:"webhooks-api"`
 
 	// Authentication
 	RequireToken  bool   `json:"require-token"`
@@ -68,6 +84,15 @@ type WebUI struct {
 	ListeningPort string `json:"listening-port"`
 }
 
+// Plugins is the struct used to store configs related with the plugins, the triggers and actions implemented by
+// external executables.
+type Plugins struct {
+	// Directory is where the executables of the plugins are searched. If empty, the plugins are disabled.
+	Directory string `json:"directory"`
+	// Timeout limits the duration of each execution of a plugin. Zero means the default (10 seconds).
+	Timeout int64 `json:"timeout(ms)"`
+}
+
 // User is used to store each user's credentials.
 type User struct {
 	Username     string `json:"username"`
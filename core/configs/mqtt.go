@@ -0,0 +1,62 @@
+package configs
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// MQTTBroker is a broker whose messages can activate the tasks, referenced by its name on the MQTT trigger.
+type MQTTBroker struct {
+	Name string `json:"name"`
+	// URL is the address of the broker, with the format scheme://host:port where the scheme is 'tcp', 'ssl', 'ws' or
+	// 'wss'. Example: tcp://localhost:1883
+	URL string `json:"url"`
+	// ClientID identifies PiWorker on the broker. If empty, a random one is used.
+	ClientID string `json:"client-id"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// Validate checks the format of the broker.
+func (b *MQTTBroker) Validate() error {
+	if b.Name == "" {
+		return errors.New("the name is empty")
+	}
+
+	u, err := url.Parse(b.URL)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %s", err.Error())
+	}
+
+	switch u.Scheme {
+	case "tcp", "ssl", "ws", "wss":
+	default:
+		return fmt.Errorf("unsupported scheme '%s' on the URL '%s'", u.Scheme, b.URL)
+	}
+
+	if u.Host == "" {
+		return fmt.Errorf("the URL '%s' doesn't have host", b.URL)
+	}
+
+	return nil
+}
+
+// validateMQTTBrokers checks the format of all the MQTT brokers and that their names are unique.
+func (c *Configs) validateMQTTBrokers() error {
+	names := make(map[string]bool)
+
+	for i := range c.MQTTBrokers {
+		err := c.MQTTBrokers[i].Validate()
+		if err != nil {
+			return fmt.Errorf("MQTT broker %d: %s", i, err.Error())
+		}
+
+		if names[c.MQTTBrokers[i].Name] {
+			return fmt.Errorf("the name of the MQTT broker '%s' is duplicated", c.MQTTBrokers[i].Name)
+		}
+		names[c.MQTTBrokers[i].Name] = true
+	}
+
+	return nil
+}
@@ -0,0 +1,36 @@
+package configs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMQTTBroker(t *testing.T) {
+	assert := assert.New(t)
+
+	valid := []MQTTBroker{
+		{Name: "home", URL: "tcp://localhost:1883"},
+		{Name: "cloud", URL: "ssl://broker.example.com:8883", Username: "pi", Password: "secret"},
+		{Name: "web", URL: "wss://broker.example.com/mqtt"},
+	}
+	for i, b := range valid {
+		assert.NoErrorf(b.Validate(), "[broker %d] the broker should be valid", i)
+	}
+
+	invalid := []MQTTBroker{
+		{URL: "tcp://localhost:1883"},
+		{Name: "scheme", URL: "http://localhost:1883"},
+		{Name: "host", URL: "tcp://"},
+		{Name: "url", URL: "localhost:1883"},
+	}
+	for i, b := range invalid {
+		assert.Errorf(b.Validate(), "[broker %d] the broker should be invalid", i)
+	}
+
+	cfg := Configs{MQTTBrokers: valid}
+	assert.NoError(cfg.validateMQTTBrokers())
+
+	cfg.MQTTBrokers = append(cfg.MQTTBrokers, valid[0])
+	assert.Error(cfg.validateMQTTBrokers(), "the names of the brokers must be unique")
+}
@@ -2,6 +2,7 @@ package configs
 
 import (
 	"encoding/json"
+	"fmt"
 	"github.com/rs/zerolog/log"
 	"io/ioutil"
 	"os"
@@ -37,5 +38,15 @@ func readFromFile(path string) (*Configs, error) {
 		return &cfg, ErrConfigFileCorrupted
 	}
 
+	err = cfg.validateCalendars()
+	if err != nil {
+		return &cfg, fmt.Errorf("%w: %s", ErrInvalidCalendar, err.Error())
+	}
+
+	err = cfg.validateMQTTBrokers()
+	if err != nil {
+		return &cfg, fmt.Errorf("%w: %s", ErrInvalidMQTTBroker, err.Error())
+	}
+
 	return &cfg, nil
 }
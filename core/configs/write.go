@@ -16,7 +16,10 @@ func NewConfig(path, filename string) (*Configs, error) {
 	if _, err := os.Stat(file); os.IsNotExist(err) {
 		defaultConfigs := Configs{
 			Behavior: Behavior{
-				LoopSleep: 500, // Milliseconds
+				LoopSleep:            500, // Milliseconds
+				MaxConcurrentTasks:   0,   // No limit
+				ActionWorkers:        0,   // One per CPU
+				MaxExecutionsPerTask: 100,
 			},
 			Security: Security{
 				DeniedIPs:          []string{},
@@ -37,6 +40,10 @@ func NewConfig(path, filename string) (*Configs, error) {
 				StatisticsAPI:  true,
 				LogsAPI:        true,
 				TypesCompatAPI: true,
+				ExecutionsAPI:  true,
+				RunTaskAPI:     true,
+				DryRunAPI:      true,
+				WebhooksAPI:    true,
 				RequireToken:   true,
 				SigningKey:     "",
 				TokenDuration:  168, // 7 days
@@ -50,7 +57,13 @@ func NewConfig(path, filename string) (*Configs, error) {
 				Enabled:       true,
 				ListeningPort: "8080",
 			},
-			Users: []User{},
+			Users:     []User{},
+			Calendars: []Calendar{},
+			Plugins: Plugins{
+				Directory: "./plugins",
+				Timeout:   10000, // Milliseconds
+			},
+			MQTTBrokers: []MQTTBroker{},
 		}
 
 		err = writeToFile(file, &defaultConfigs, true)
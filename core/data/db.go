@@ -29,6 +29,12 @@ func NewDB(path, filename string) (*DatabaseInstance, error) {
 		return nil, err
 	}
 
+	// Same with the table used to store the history of executions.
+	err = createExecutionsTable(db)
+	if err != nil {
+		return nil, err
+	}
+
 	d := DatabaseInstance{
 		Path:     filepath.Join(path, filename),
 		EventBus: make(chan Event),
@@ -77,8 +83,107 @@ func createTable(db *sql.DB) error {
 		Trigger TEXT NOT NULL,
 		Actions TEXT NOT NULL,
 		Created DATETIME,
-		LastTimeModified DATETIME
+		LastTimeModified DATETIME,
+		Settings TEXT NOT NULL DEFAULT '{}',
+		OnFailure TEXT NOT NULL DEFAULT '[]',
+		FailureCount INTEGER NOT NULL DEFAULT 0,
+		LastFailure DATETIME,
+		LastFired DATETIME
+	);
+	`
+
+	_, err := db.Exec(sqlStatement)
+	if err != nil {
+		return err
+	}
+
+	// The databases created by previous versions don't have the columns of the settings and the actions
+	// executed on failure.
+	err = addColumnIfNotExists(db, "Tasks", "Settings", "TEXT NOT NULL DEFAULT '{}'")
+	if err != nil {
+		return err
+	}
+
+	err = addColumnIfNotExists(db, "Tasks", "OnFailure", "TEXT NOT NULL DEFAULT '[]'")
+	if err != nil {
+		return err
+	}
+
+	// Same with the columns used to keep track of the failures.
+	err = addColumnIfNotExists(db, "Tasks", "FailureCount", "INTEGER NOT NULL DEFAULT 0")
+	if err != nil {
+		return err
+	}
+
+	err = addColumnIfNotExists(db, "Tasks", "LastFailure", "DATETIME")
+	if err != nil {
+		return err
+	}
+
+	// And the one used to recover the activations of the schedule triggers missed while PiWorker was stopped.
+	err = addColumnIfNotExists(db, "Tasks", "LastFired", "DATETIME")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// addColumnIfNotExists is the function used to add a column to an existing table, only if the table doesn't
+// have it yet.
+func addColumnIfNotExists(db *sql.DB, table, column, definition string) error {
+	row, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s);", table))
+	if err != nil {
+		return err
+	}
+
+	exists := false
+	for row.Next() {
+		var cid, notNull, pk int
+		var name, columnType string
+		var defaultValue sql.NullString
+
+		err = row.Scan(&cid, &name, &columnType, &notNull, &defaultValue, &pk)
+		if err != nil {
+			row.Close()
+			return err
+		}
+
+		if name == column {
+			exists = true
+		}
+	}
+
+	err = row.Close()
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		return nil
+	}
+
+	_, err = db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s;", table, column, definition))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// createExecutionsTable is the function that creates the table 'Executions' into the SQLite3 database.
+func createExecutionsTable(db *sql.DB) error {
+	sqlStatement := `
+	CREATE TABLE IF NOT EXISTS Executions(
+		ID TEXT NOT NULL,
+		TaskID TEXT NOT NULL,
+		TriggeredAt DATETIME,
+		Finished DATETIME,
+		Actions TEXT NOT NULL,
+		Error TEXT NOT NULL,
+		Outcome TEXT NOT NULL
 	);
+	CREATE INDEX IF NOT EXISTS ExecutionsByTask ON Executions(TaskID, TriggeredAt);
 	`
 
 	_, err := db.Exec(sqlStatement)
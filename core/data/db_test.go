@@ -1,6 +1,7 @@
 package data
 
 import (
+	"database/sql"
 	"encoding/json"
 	"os"
 	"testing"
@@ -91,6 +92,7 @@ func (s *DBTestSuite) TestCreateTable() {
 				Order:                 0,
 			},
 		},
+		OnFailure:        []UserAction{}, // Default value of the column, not given on the insertion.
 		Created:          time.Now(),
 		LastTimeModified: time.Now(),
 		ID:               "hello1234",
@@ -139,6 +141,10 @@ func (s *DBTestSuite) TestCreateTable() {
 	var out UserTask
 	var outTrigger string
 	var outActions string
+	var outSettings string
+	var outOnFailure string
+	var outLastFailure sql.NullTime
+	var outLastFired sql.NullTime
 
 	err = r.Scan(
 		&out.ID,
@@ -148,8 +154,15 @@ func (s *DBTestSuite) TestCreateTable() {
 		&outActions,
 		&out.Created,
 		&out.LastTimeModified,
+		&outSettings,
+		&outOnFailure,
+		&out.FailureCount,
+		&outLastFailure,
+		&outLastFired,
 	)
 	assert.NoError(err, "The row must be scanned without problems")
+	assert.False(outLastFailure.Valid, "A task that has never failed must not have the time of its last failure")
+	assert.False(outLastFired.Valid, "A new task must not have the time of the last activation of its trigger")
 
 	err = json.Unmarshal([]byte(outTrigger), &out.Trigger)
 	if err != nil {
@@ -161,6 +174,16 @@ func (s *DBTestSuite) TestCreateTable() {
 		panic(err)
 	}
 
+	err = json.Unmarshal([]byte(outSettings), &out.Settings)
+	if err != nil {
+		panic(err)
+	}
+
+	err = json.Unmarshal([]byte(outOnFailure), &out.OnFailure)
+	if err != nil {
+		panic(err)
+	}
+
 	// Compare the fields of type `time.Time` individually. Doing it with `assert.Equal` will cause a false positive
 	// due to the absence of metadata on the task that contains the values obtained from the database.
 	if !assert.True(row.Created.Equal(out.Created), "The row that contains the time when the task has been"+
@@ -180,6 +203,49 @@ func (s *DBTestSuite) TestCreateTable() {
 	assert.Equal(row, out, "The row introduced into the database must be the same that the obtained")
 }
 
+func (s *DBTestSuite) TestMigrateTable() {
+	assert := assert2.New(s.T())
+
+	db, err := initDB(s.TestDir + "/migrate_table_" + s.TestFilename)
+	if err != nil {
+		panic(err)
+	}
+	defer db.Close()
+
+	// Table created by a previous version, without the settings of the tasks.
+	_, err = db.Exec(`
+	CREATE TABLE Tasks(
+		ID TEXT NOT NULL,
+		Name TEXT NOT NULL,
+		State TEXT NOT NULL,
+		Trigger TEXT NOT NULL,
+		Actions TEXT NOT NULL,
+		Created DATETIME,
+		LastTimeModified DATETIME
+	);
+	INSERT INTO Tasks VALUES ('hello1234', 'My task', 'active', '{}', '[]', NULL, NULL);
+	`)
+	if err != nil {
+		panic(err)
+	}
+
+	err = createTable(db)
+	assert.NoError(err, "The table should be migrated without problems")
+
+	// Running it again must not try to add the column twice.
+	err = createTable(db)
+	assert.NoError(err, "The migration of an updated table should not cause an error")
+
+	var settings, onFailure string
+	var failureCount int
+	err = db.QueryRow("SELECT Settings, OnFailure, FailureCount FROM Tasks WHERE ID = ?;", "hello1234").
+		Scan(&settings, &onFailure, &failureCount)
+	assert.NoError(err, "The new columns must exist")
+	assert.Equal("{}", settings, "The existing tasks must receive the default settings")
+	assert.Equal("[]", onFailure, "The existing tasks must receive an empty list of actions executed on failure")
+	assert.Equal(0, failureCount, "The existing tasks must not have failures")
+}
+
 func (s *DBTestSuite) TearDownTest() {
 	err := os.RemoveAll(s.TestDir)
 	if err != nil {
@@ -25,6 +25,12 @@ func (db *DatabaseInstance) DeleteTask(ID string) error {
 		return fmt.Errorf("the task with the ID '%s' does not exist", ID)
 	}
 
+	// The history of executions is meaningless without the task itself.
+	err = db.DeleteExecutions(ID)
+	if err != nil {
+		return err
+	}
+
 	event := Event{
 		Type:   Deleted,
 		TaskID: ID,
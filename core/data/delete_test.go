@@ -1,6 +1,7 @@
 package data
 
 import (
+	"database/sql"
 	"encoding/json"
 	assert2 "github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
@@ -187,6 +188,10 @@ func getTask(db *DatabaseInstance, name string) (*UserTask, error) {
 	var task UserTask
 	var trigger string
 	var actions string
+	var settings string
+	var onFailure string
+	var lastFailure sql.NullTime
+	var lastFired sql.NullTime
 
 	row, err := i.Query(sqlStatement, name)
 	if err != nil {
@@ -211,10 +216,17 @@ func getTask(db *DatabaseInstance, name string) (*UserTask, error) {
 		&actions,
 		&task.Created,
 		&task.LastTimeModified,
+		&settings,
+		&onFailure,
+		&task.FailureCount,
+		&lastFailure,
+		&lastFired,
 	)
 	if err != nil {
 		return &task, err
 	}
+	task.LastFailure = lastFailure.Time
+	task.LastFired = lastFired.Time
 
 	// Parse the Trigger string into the proper struct.
 	err = json.Unmarshal([]byte(trigger), &task.Trigger)
@@ -228,5 +240,17 @@ func getTask(db *DatabaseInstance, name string) (*UserTask, error) {
 		return &task, err
 	}
 
+	// Parse the Settings string into the proper struct.
+	err = json.Unmarshal([]byte(settings), &task.Settings)
+	if err != nil {
+		return &task, err
+	}
+
+	// Parse the OnFailure string into the proper struct.
+	err = json.Unmarshal([]byte(onFailure), &task.OnFailure)
+	if err != nil {
+		return &task, err
+	}
+
 	return &task, nil
 }
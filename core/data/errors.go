@@ -14,6 +14,15 @@ import (
 var ErrBadTaskID = errors.New("invalid task ID: the task ID provided not exists " +
 	"in the user database")
 
+// ErrBadExecutionID is an error used when an execution with specific ID is not found
+// in the database.
+var ErrBadExecutionID = errors.New("invalid execution ID: the execution ID provided not exists " +
+	"in the user database")
+
+// ErrTaskOnExecution is an error used when a task is required to run while its actions are
+// already being executed.
+var ErrTaskOnExecution = errors.New("the task is already on execution")
+
 // ErrNoFilenameAssigned is an error used when the name of the json data file was not setted.
 //var ErrNoFilenameAssigned = errors.New("no Filename: the filename of the data file was" +
 //	" not assigned")
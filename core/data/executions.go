@@ -0,0 +1,254 @@
+package data
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// NewExecution is a method used to add a new execution of a task to the table 'Executions'. The ID of the
+// execution is generated here, so any previous value of the field will be overwritten.
+func (db *DatabaseInstance) NewExecution(execution *TaskExecution) error {
+	if execution.TaskID == "" {
+		return ErrIntegrity
+	}
+
+	sqlStatement := `
+	INSERT INTO Executions(
+		ID,
+		TaskID,
+		TriggeredAt,
+		Finished,
+		Actions,
+		Error,
+		Outcome
+	) values (?,?,?,?,?,?,?)
+	`
+
+	execution.ID = uuid.New().String()
+
+	if execution.Outcome == "" {
+		execution.Outcome = OutcomeRunning
+	}
+
+	actions, err := json.Marshal(execution.Actions)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.instance.Exec(sqlStatement,
+		execution.ID,
+		execution.TaskID,
+		execution.TriggeredAt,
+		execution.Finished,
+		string(actions),
+		execution.Error,
+		execution.Outcome,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpdateExecution is a method used to overwrite the stored data of an existing execution.
+func (db *DatabaseInstance) UpdateExecution(execution *TaskExecution) error {
+	sqlStatement := `
+		UPDATE Executions
+		SET Finished = ?, Actions = ?, Error = ?, Outcome = ?
+		WHERE ID = ?;
+	`
+
+	actions, err := json.Marshal(execution.Actions)
+	if err != nil {
+		return err
+	}
+
+	r, err := db.instance.Exec(sqlStatement,
+		execution.Finished,
+		string(actions),
+		execution.Error,
+		execution.Outcome,
+		execution.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := r.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("the execution with the ID '%s' does not exist", execution.ID)
+	}
+
+	return nil
+}
+
+// GetExecutions is a method that returns the executions of a specific task, sorted from the most recent to the
+// oldest one. `limit` and `offset` can be used to paginate the results.
+func (db *DatabaseInstance) GetExecutions(taskID string, limit, offset int) (*[]TaskExecution, error) {
+	sqlStatement := `
+		SELECT * FROM Executions
+		WHERE TaskID = ?
+		ORDER BY TriggeredAt DESC
+		LIMIT ? OFFSET ?;
+	`
+
+	row, err := db.instance.Query(sqlStatement, taskID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		err := row.Close()
+		if err != nil {
+			log.Error().Err(err).Caller(zerolog.CallerSkipFrameCount).Msg("Error when closing rows")
+		}
+	}()
+
+	executions := []TaskExecution{}
+
+	for row.Next() {
+		execution, err := scanExecution(row)
+		if err != nil {
+			return &executions, err
+		}
+
+		executions = append(executions, *execution)
+	}
+
+	return &executions, nil
+}
+
+// GetExecutionByID is a method that returns a specific execution, searching it by its ID.
+func (db *DatabaseInstance) GetExecutionByID(ID string) (*TaskExecution, error) {
+	sqlStatement := `
+		SELECT * FROM Executions
+		WHERE ID = ?;
+	`
+
+	row, err := db.instance.Query(sqlStatement, ID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		err := row.Close()
+		if err != nil {
+			log.Error().Err(err).Caller(zerolog.CallerSkipFrameCount).Msg("Error when closing rows")
+		}
+	}()
+
+	if !row.Next() {
+		return nil, ErrBadExecutionID
+	}
+
+	return scanExecution(row)
+}
+
+// RequestExecution is a method used to ask for the execution of the actions of a task, regardless its trigger.
+// A task which actions are running at the moment can't be requested again.
+func (db *DatabaseInstance) RequestExecution(taskID string) error {
+	task, err := db.GetTaskByID(taskID)
+	if err != nil {
+		return err
+	}
+
+	if task.State == StateTaskOnExecution {
+		return ErrTaskOnExecution
+	}
+
+	event := Event{
+		Type:   RunRequested,
+		TaskID: taskID,
+	}
+	db.EventBus <- event
+
+	return nil
+}
+
+// CountExecutions is a method that returns the amount of executions stored of a specific task.
+func (db *DatabaseInstance) CountExecutions(taskID string) (int, error) {
+	sqlStatement := `
+		SELECT COUNT(*) FROM Executions
+		WHERE TaskID = ?;
+	`
+
+	var n int
+	err := db.instance.QueryRow(sqlStatement, taskID).Scan(&n)
+	if err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
+
+// PruneExecutions is a method used to remove the oldest executions of a specific task, keeping only the `keep` most
+// recent ones. The executions in progress are never removed.
+func (db *DatabaseInstance) PruneExecutions(taskID string, keep int) error {
+	sqlStatement := `
+		DELETE FROM Executions
+		WHERE TaskID = ? AND Outcome != ? AND ID NOT IN (
+			SELECT ID FROM Executions
+			WHERE TaskID = ?
+			ORDER BY TriggeredAt DESC
+			LIMIT ?
+		);
+	`
+
+	_, err := db.instance.Exec(sqlStatement, taskID, OutcomeRunning, taskID, keep)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteExecutions is a method used to remove all the executions of a specific task.
+func (db *DatabaseInstance) DeleteExecutions(taskID string) error {
+	sqlStatement := `
+		DELETE FROM Executions
+		WHERE TaskID = ?;
+	`
+
+	_, err := db.instance.Exec(sqlStatement, taskID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func scanExecution(row *sql.Rows) (*TaskExecution, error) {
+	var execution TaskExecution
+	var actions string
+
+	err := row.Scan(
+		&execution.ID,
+		&execution.TaskID,
+		&execution.TriggeredAt,
+		&execution.Finished,
+		&actions,
+		&execution.Error,
+		&execution.Outcome,
+	)
+	if err != nil {
+		return &execution, err
+	}
+
+	// Parse the Actions string into the proper struct.
+	err = json.Unmarshal([]byte(actions), &execution.Actions)
+	if err != nil {
+		return &execution, err
+	}
+
+	return &execution, nil
+}
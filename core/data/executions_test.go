@@ -0,0 +1,267 @@
+package data
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Pegasus8/piworker/core/types"
+
+	assert2 "github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type ExecutionsTestSuite struct {
+	TestDir        string
+	TestFilename   string
+	TestDB         *DatabaseInstance
+	TestExecutions []TaskExecution
+
+	suite.Suite
+}
+
+func (s *ExecutionsTestSuite) SetupTest() {
+	s.TestDir = "./test_executions"
+	s.TestFilename = "executions.db"
+
+	err := os.Mkdir(s.TestDir, 0755)
+	if err != nil {
+		panic(err)
+	}
+
+	now := time.Now()
+
+	s.TestExecutions = []TaskExecution{
+		{
+			TaskID:      "task-1",
+			TriggeredAt: now.Add(-2 * time.Minute),
+			Actions: []ActionExecution{
+				{
+					ActionID:   "A1",
+					Order:      0,
+					Started:    now.Add(-2 * time.Minute),
+					Finished:   now.Add(-2*time.Minute + time.Second),
+					Result:     "hello world",
+					ResultType: types.Text,
+					Successful: true,
+				},
+			},
+		},
+		{
+			TaskID:      "task-1",
+			TriggeredAt: now.Add(-time.Minute),
+		},
+		{
+			TaskID:      "task-1",
+			TriggeredAt: now,
+		},
+		{
+			TaskID:      "task-2",
+			TriggeredAt: now,
+		},
+	}
+}
+
+func (s *ExecutionsTestSuite) BeforeTest(_, _ string) {
+	db, err := NewDB(s.TestDir, s.TestFilename)
+	if err != nil {
+		panic(err)
+	}
+
+	s.TestDB = db
+}
+
+func (s *ExecutionsTestSuite) TestNewExecution() {
+	assert := assert2.New(s.T())
+
+	for i := range s.TestExecutions {
+		err := s.TestDB.NewExecution(&s.TestExecutions[i])
+		assert.NoError(err, "The execution should be added without problems")
+		assert.NotEmpty(s.TestExecutions[i].ID, "An ID must be assigned to the execution")
+		assert.Equal(OutcomeRunning, s.TestExecutions[i].Outcome, "A new execution without outcome must be "+
+			"considered as running")
+	}
+
+	// An execution without the ID of its task must not be added.
+	err := s.TestDB.NewExecution(&TaskExecution{})
+	assert.EqualError(err, ErrIntegrity.Error(), "The returned error is not which should be")
+}
+
+func (s *ExecutionsTestSuite) TestUpdateExecution() {
+	assert := assert2.New(s.T())
+
+	e := s.TestExecutions[1]
+	err := s.TestDB.NewExecution(&e)
+	if err != nil {
+		panic(err)
+	}
+
+	e.Actions = append(e.Actions, ActionExecution{
+		ActionID:   "A3",
+		Started:    e.TriggeredAt,
+		Finished:   e.TriggeredAt.Add(time.Second),
+		Error:      "exit status 1",
+		Successful: false,
+	})
+	e.Finished = e.TriggeredAt.Add(time.Second)
+	e.Outcome = OutcomeFailure
+	e.Error = "exit status 1"
+
+	err = s.TestDB.UpdateExecution(&e)
+	assert.NoError(err, "The execution should be updated without problems")
+
+	stored, err := s.TestDB.GetExecutionByID(e.ID)
+	assert.NoError(err, "The execution should be obtained without problems")
+	if assert.NotNil(stored) {
+		assert.Equal(OutcomeFailure, stored.Outcome, "The outcome must be updated")
+		assert.Equal(e.Error, stored.Error, "The error must be updated")
+		assert.True(e.Finished.Equal(stored.Finished), "The time of finalization must be updated")
+		if assert.Len(stored.Actions, 1, "The actions executed must be updated") {
+			assert.Equal("A3", stored.Actions[0].ActionID)
+			assert.Equal(e.Error, stored.Actions[0].Error)
+		}
+	}
+
+	// Try to update a non-existent execution must return an error.
+	err = s.TestDB.UpdateExecution(&TaskExecution{ID: "non-existent-id"})
+	assert.Error(err, "Try to update a non-existent execution should return an error")
+}
+
+func (s *ExecutionsTestSuite) TestGetExecutions() {
+	assert := assert2.New(s.T())
+
+	for i := range s.TestExecutions {
+		err := s.TestDB.NewExecution(&s.TestExecutions[i])
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	n, err := s.TestDB.CountExecutions("task-1")
+	assert.NoError(err, "The executions should be counted without problems")
+	assert.Equal(3, n, "Only the executions of the given task must be counted")
+
+	executions, err := s.TestDB.GetExecutions("task-1", 2, 0)
+	assert.NoError(err, "The executions should be obtained without problems")
+	if assert.Len(*executions, 2, "The amount of executions must be limited") {
+		// The most recent execution first.
+		assert.Equal(s.TestExecutions[2].ID, (*executions)[0].ID)
+		assert.Equal(s.TestExecutions[1].ID, (*executions)[1].ID)
+	}
+
+	executions, err = s.TestDB.GetExecutions("task-1", 2, 2)
+	assert.NoError(err, "The executions should be obtained without problems")
+	if assert.Len(*executions, 1, "The offset must be applied") {
+		assert.Equal(s.TestExecutions[0].ID, (*executions)[0].ID)
+		assert.Equal(s.TestExecutions[0].Actions[0].Result, (*executions)[0].Actions[0].Result)
+	}
+
+	executions, err = s.TestDB.GetExecutions("non-existent-task", 10, 0)
+	assert.NoError(err, "Get the executions of a task without them should not return an error")
+	assert.Empty(*executions)
+
+	_, err = s.TestDB.GetExecutionByID("non-existent-id")
+	assert.EqualError(err, ErrBadExecutionID.Error(), "The returned error is not which should be")
+}
+
+func (s *ExecutionsTestSuite) TestDeleteExecutions() {
+	assert := assert2.New(s.T())
+
+	for i := range s.TestExecutions {
+		err := s.TestDB.NewExecution(&s.TestExecutions[i])
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	err := s.TestDB.DeleteExecutions("task-1")
+	assert.NoError(err, "The executions should be deleted without problems")
+
+	n, err := s.TestDB.CountExecutions("task-1")
+	assert.NoError(err)
+	assert.Equal(0, n, "All the executions of the task must be deleted")
+
+	n, err = s.TestDB.CountExecutions("task-2")
+	assert.NoError(err)
+	assert.Equal(1, n, "The executions of other tasks must not be affected")
+}
+
+func (s *ExecutionsTestSuite) TestPruneExecutions() {
+	assert := assert2.New(s.T())
+
+	for i := range s.TestExecutions {
+		if i > 0 {
+			s.TestExecutions[i].Outcome = OutcomeSuccess
+		}
+
+		err := s.TestDB.NewExecution(&s.TestExecutions[i])
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	err := s.TestDB.PruneExecutions("task-1", 1)
+	assert.NoError(err, "The executions should be pruned without problems")
+
+	executions, err := s.TestDB.GetExecutions("task-1", 10, 0)
+	assert.NoError(err)
+	if assert.Len(*executions, 2, "Only the most recent execution and the one in progress must be kept") {
+		assert.Equal(s.TestExecutions[2].ID, (*executions)[0].ID)
+		assert.Equal(s.TestExecutions[0].ID, (*executions)[1].ID)
+	}
+
+	n, err := s.TestDB.CountExecutions("task-2")
+	assert.NoError(err)
+	assert.Equal(1, n, "The executions of other tasks must not be affected")
+}
+
+func (s *ExecutionsTestSuite) TestRequestExecution() {
+	assert := assert2.New(s.T())
+
+	task := UserTask{
+		Name:    "Task to run",
+		State:   StateTaskInactive,
+		Trigger: UserTrigger{ID: "T1"},
+		Actions: []UserAction{{ID: "A1"}},
+	}
+
+	go func() {
+		<-s.TestDB.EventBus
+	}()
+	err := s.TestDB.NewTask(&task)
+	if err != nil {
+		panic(err)
+	}
+
+	events := make(chan Event, 1)
+	go func() {
+		events <- <-s.TestDB.EventBus
+	}()
+
+	err = s.TestDB.RequestExecution(task.ID)
+	assert.NoError(err, "The execution should be requested without problems")
+	event := <-events
+	assert.Equal(RunRequested, event.Type, "The emitted event must be of type `RunRequested`")
+	assert.Equal(task.ID, event.TaskID, "The emitted event must contain the ID of the task")
+
+	err = s.TestDB.RequestExecution("non-existent-id")
+	assert.EqualError(err, ErrBadTaskID.Error(), "The returned error is not which should be")
+
+	err = s.TestDB.UpdateTaskState(task.ID, StateTaskOnExecution)
+	if err != nil {
+		panic(err)
+	}
+	err = s.TestDB.RequestExecution(task.ID)
+	assert.EqualError(err, ErrTaskOnExecution.Error(), "A task on execution must not be requested again")
+}
+
+func (s *ExecutionsTestSuite) TearDownTest() {
+	err := os.RemoveAll(s.TestDir)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func TestExecutionsSuite(t *testing.T) {
+	suite.Run(t, new(ExecutionsTestSuite))
+}
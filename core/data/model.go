@@ -3,6 +3,8 @@ package data
 import (
 	"database/sql"
 	"time"
+
+	"github.com/Pegasus8/piworker/core/types"
 )
 
 type DatabaseInstance struct {
@@ -15,13 +17,96 @@ type DatabaseInstance struct {
 
 // UserTask is the struct that represents the data of a task created by the user.
 type UserTask struct {
-	Name             string       `json:"name"`
-	State            TaskState    `json:"state"`
-	Trigger          UserTrigger  `json:"trigger"`
-	Actions          []UserAction `json:"actions"`
+	Name    string       `json:"name"`
+	State   TaskState    `json:"state"`
+	Trigger UserTrigger  `json:"trigger"`
+	Actions []UserAction `json:"actions"`
+	// OnFailure are the actions executed when the execution of `Actions` fails. Used to clean up or to notify
+	// about the failure.
+	OnFailure        []UserAction `json:"onFailure"`
+	Settings         TaskSettings `json:"settings"`
 	Created          time.Time    `json:"created"`
 	LastTimeModified time.Time    `json:"lastTimeModified"`
 	ID               string       `json:"ID"`
+	// FailureCount is the number of consecutive failures of the task, reset after a successful execution. Managed
+	// by the engine, it's not modified by `UpdateTask`.
+	FailureCount uint16 `json:"failureCount"`
+	// LastFailure is the moment of the last failure of the task. Zero if the task has never failed.
+	LastFailure time.Time `json:"lastFailure"`
+	// LastFired is the moment of the last activation of the trigger of the task, only kept for schedule triggers.
+	// Managed by the engine, it's not modified by `UpdateTask`.
+	LastFired time.Time `json:"lastFired"`
+}
+
+// TaskSettings is the struct that groups the options used by the engine to execute a specific task.
+type TaskSettings struct {
+	// Timeout limits (in milliseconds) the duration of each execution of the actions of the task. Zero means
+	// no limit.
+	Timeout int64 `json:"timeout,omitempty"`
+	// Restart is the policy applied by the engine when the task fails. If nil the task is never restarted.
+	Restart *RestartPolicy `json:"restart,omitempty"`
+	// Overlap is the policy applied when the trigger of the task is activated while the task is still running. If
+	// empty, `OverlapSkip` is used.
+	Overlap OverlapPolicy `json:"overlap,omitempty"`
+	// MaxConcurrentRuns is the maximum number of simultaneous executions of the task with `OverlapParallel`.
+	MaxConcurrentRuns uint8 `json:"maxConcurrentRuns,omitempty"`
+	// Priority is used by the queue of actions to choose which task runs first when there are more actions waiting
+	// than workers available. The highest value runs first, the tasks with the same priority take turns.
+	Priority uint8 `json:"priority,omitempty"`
+	// Misfire is the policy applied on the start of the engine to the activations of the schedule trigger of the task
+	// missed while PiWorker was stopped. If empty, `MisfireSkip` is used.
+	Misfire MisfirePolicy `json:"misfire,omitempty"`
+	// Calendar is the name of the calendar (defined on the configs) that limits when the trigger of the task can be
+	// activated. If empty, the task can be activated at any moment.
+	Calendar string `json:"calendar,omitempty"`
+}
+
+// MisfirePolicy represents what to do with the activations of a schedule trigger missed while PiWorker was stopped.
+type MisfirePolicy string
+
+const (
+	// MisfireSkip ignores the missed activations.
+	MisfireSkip MisfirePolicy = "skip"
+	// MisfireFireOnce runs the task once if at least one activation was missed.
+	MisfireFireOnce MisfirePolicy = "fire-once"
+	// MisfireFireAll runs the task once for each missed activation, one after the other.
+	MisfireFireAll MisfirePolicy = "fire-all"
+)
+
+// OverlapPolicy represents what to do with an activation of the trigger of a task that is still running.
+type OverlapPolicy string
+
+const (
+	// OverlapSkip ignores the activation.
+	OverlapSkip OverlapPolicy = "skip"
+	// OverlapQueue keeps the activation (only the last one) and runs the task again once the current execution
+	// finishes.
+	OverlapQueue OverlapPolicy = "queue"
+	// OverlapParallel runs the task again while there are less than `TaskSettings.MaxConcurrentRuns` executions
+	// in progress, otherwise the activation is ignored.
+	OverlapParallel OverlapPolicy = "parallel"
+)
+
+// RestartMode represents when a failed task is restarted by the engine.
+type RestartMode string
+
+const (
+	// RestartNever leaves the task failed until the user changes it.
+	RestartNever RestartMode = "never"
+	// RestartOnFailure restarts the task until it fails more than `RestartPolicy.MaxRestarts` consecutive times.
+	RestartOnFailure RestartMode = "on-failure"
+	// RestartAlways restarts the task after every failure, without limit.
+	RestartAlways RestartMode = "always"
+)
+
+// RestartPolicy is the struct that represents the way in which a failed task is restarted.
+type RestartPolicy struct {
+	Mode RestartMode `json:"mode"`
+	// MaxRestarts is the maximum number of consecutive restarts used by `RestartOnFailure`. Must be greater than zero
+	// on that mode.
+	MaxRestarts uint16 `json:"maxRestarts,omitempty"`
+	// Cooldown is the time (in milliseconds) waited before restarting the task.
+	Cooldown int64 `json:"cooldown,omitempty"`
 }
 
 // UserTrigger is the struct that represents a trigger created by the user to use on a specific task.
@@ -29,6 +114,8 @@ type UserTrigger struct {
 	ID        string    `json:"ID"`
 	Args      []UserArg `json:"args"`
 	Timestamp string    `json:"timestamp"`
+	// Children contains the triggers combined by a composite trigger. Empty on any other trigger.
+	Children []UserTrigger `json:"children,omitempty"`
 }
 
 // UserAction is the struct that represents an action created by the user to use on a specific task.
@@ -39,6 +126,109 @@ type UserAction struct {
 	Chained               bool      `json:"chained"`
 	ArgumentToReplaceByCR string    `json:"argumentToReplaceByCR"`
 	Order                 uint8     `json:"order"`
+	// Retry is the policy applied when the execution of the action fails. If nil the action is executed once.
+	Retry *RetryPolicy `json:"retry,omitempty"`
+	// Timeout limits (in milliseconds) the duration of each attempt of the action. Zero means no limit.
+	Timeout int64 `json:"timeout,omitempty"`
+	// Condition must be satisfied by the result of the previous action to execute this one. If nil the action
+	// is always executed.
+	Condition *StepCondition `json:"condition,omitempty"`
+	// Parallel marks the action as part of a group of actions executed at the same time: the actions that share
+	// the same order. All the actions of a group must have it and, in a group, only the first action can have a
+	// condition (which applies to the whole group).
+	Parallel *ParallelGroup `json:"parallel,omitempty"`
+}
+
+// JoinPolicy represents how the results of the actions of a parallel group determine the result of the group.
+type JoinPolicy string
+
+const (
+	// JoinAll requires all the actions of the group to succeed. Once one of them fails, the others are canceled
+	// and the group fails with that error.
+	JoinAll JoinPolicy = "all"
+	// JoinAny requires at least one action of the group to succeed. The group only fails (with the error of the
+	// first action that failed) if all of them fail.
+	JoinAny JoinPolicy = "any"
+)
+
+// ParallelGroup is the struct that represents the configuration of a group of actions executed in parallel. The
+// next action receives, as chained result, a JSON array with the result of each action of the group.
+type ParallelGroup struct {
+	// Join is the policy used to determine the result of the group. If empty, `JoinAll` is used.
+	Join JoinPolicy `json:"join,omitempty"`
+}
+
+// ConditionOperator represents the comparison made by a `StepCondition`.
+type ConditionOperator string
+
+const (
+	// OperatorEqual checks if the result is equal to the value.
+	OperatorEqual ConditionOperator = "eq"
+	// OperatorNotEqual checks if the result is different than the value.
+	OperatorNotEqual ConditionOperator = "ne"
+	// OperatorGreater checks if the result is greater than the value.
+	OperatorGreater ConditionOperator = "gt"
+	// OperatorGreaterOrEqual checks if the result is greater than or equal to the value.
+	OperatorGreaterOrEqual ConditionOperator = "ge"
+	// OperatorLess checks if the result is less than the value.
+	OperatorLess ConditionOperator = "lt"
+	// OperatorLessOrEqual checks if the result is less than or equal to the value.
+	OperatorLessOrEqual ConditionOperator = "le"
+	// OperatorContains checks if the result contains the value.
+	OperatorContains ConditionOperator = "contains"
+	// OperatorMatches checks if the result matches the regular expression given as value.
+	OperatorMatches ConditionOperator = "matches"
+)
+
+// ConditionElse represents what to do when a `StepCondition` is not satisfied.
+type ConditionElse string
+
+const (
+	// ElseStop finishes the execution of the task quietly, without considering it a failure.
+	ElseStop ConditionElse = "stop"
+	// ElseGoto skips the execution to the action with the order given by `StepCondition.Goto`.
+	ElseGoto ConditionElse = "goto"
+)
+
+// StepCondition is the struct that represents a comparison between the result of the previous action (the
+// `ChainedResult`) and a value given by the user.
+type StepCondition struct {
+	Operator ConditionOperator `json:"operator"`
+	// Value is the content compared with the result. It can be a reference to a user variable.
+	Value string `json:"value"`
+	// Type is used to interpret both the result and the value. If empty, the type of the result is used.
+	Type types.PWType `json:"type,omitempty"`
+	// Else is the behavior when the condition is not satisfied. If empty, `ElseStop` is used.
+	Else ConditionElse `json:"else,omitempty"`
+	// Goto is the order of the action where the execution continues when `Else` is `ElseGoto`. It must be
+	// greater than the order of the action.
+	Goto uint8 `json:"goto,omitempty"`
+}
+
+// BackoffStrategy represents the way in which the time between the attempts of an action grows.
+type BackoffStrategy string
+
+const (
+	// BackoffFixed waits the same time before each attempt.
+	BackoffFixed BackoffStrategy = "fixed"
+	// BackoffExponential doubles the time to wait after each attempt.
+	BackoffExponential BackoffStrategy = "exponential"
+)
+
+// RetryPolicy is the struct that represents how a failed action must be executed again.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum amount of executions of the action, including the first one.
+	MaxAttempts uint8           `json:"maxAttempts"`
+	Backoff     BackoffStrategy `json:"backoff"`
+	// Delay is the time (in milliseconds) to wait before the first retry.
+	Delay int64 `json:"delay"`
+	// MaxDelay limits (in milliseconds) the time to wait when the backoff is exponential. Zero means the limit of
+	// the engine (one day).
+	MaxDelay int64 `json:"maxDelay"`
+	// RetryOn is a regular expression that the error must match to be retried. If empty, all the errors are retried.
+	RetryOn string `json:"retryOn"`
+	// RetryUnsuccessful enables the retry of the actions that finish without error but with an unsuccessful result.
+	RetryUnsuccessful bool `json:"retryUnsuccessful"`
 }
 
 // UserArg is the struct that represents an argument created by the user to use on a specific trigger or action.
@@ -47,6 +237,51 @@ type UserArg struct {
 	Content string `json:"content"`
 }
 
+// ExecutionOutcome represents the final result of an execution of a task.
+type ExecutionOutcome string
+
+const (
+	// OutcomeRunning represents an execution that hasn't finished yet.
+	OutcomeRunning ExecutionOutcome = "running"
+	// OutcomeSuccess represents an execution where all the actions have been executed successfully.
+	OutcomeSuccess ExecutionOutcome = "success"
+	// OutcomeFailure represents an execution interrupted by an error or by an unsuccessful action.
+	OutcomeFailure ExecutionOutcome = "failure"
+	// OutcomeTimedOut represents an execution interrupted because an action or the task exceeded its timeout.
+	OutcomeTimedOut ExecutionOutcome = "timed-out"
+	// OutcomeCanceled represents an execution interrupted because the task was deactivated or deleted, or
+	// because PiWorker is shutting down.
+	OutcomeCanceled ExecutionOutcome = "canceled"
+)
+
+// TaskExecution is the struct that represents a single run of the actions of a task, from the activation of
+// its trigger until the end of its last action.
+type TaskExecution struct {
+	ID          string            `json:"ID"`
+	TaskID      string            `json:"taskID"`
+	TriggeredAt time.Time         `json:"triggeredAt"`
+	Finished    time.Time         `json:"finished"`
+	Actions     []ActionExecution `json:"actions"`
+	Error       string            `json:"error"`
+	Outcome     ExecutionOutcome  `json:"outcome"`
+}
+
+// ActionExecution is the struct that represents the execution of an action as part of a `TaskExecution`.
+type ActionExecution struct {
+	ActionID   string       `json:"ID"`
+	Order      uint8        `json:"order"`
+	Started    time.Time    `json:"started"`
+	Finished   time.Time    `json:"finished"`
+	Result     string       `json:"result"`
+	ResultType types.PWType `json:"resultType"`
+	Successful bool         `json:"successful"`
+	Error      string       `json:"error"`
+	// Attempt is the number of the attempt (starting from 1) when the action has a retry policy.
+	Attempt uint8 `json:"attempt"`
+	// OnFailure indicates that the action is part of the actions executed after a failure of the task.
+	OnFailure bool `json:"onFailure,omitempty"`
+}
+
 // EventType represents the different situations in which a task can be involved.
 type EventType uint8
 
@@ -59,6 +294,8 @@ const (
 	Added
 	// Failed represents a task that failed during execution.
 	Failed
+	// RunRequested represents a task that must run its actions right now, without waiting for its trigger.
+	RunRequested
 )
 
 // Event is the struct used represent an event related with a specific task.
@@ -1,7 +1,9 @@
 package data
 
 import (
+	"database/sql"
 	"encoding/json"
+
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
@@ -27,35 +29,12 @@ func (db *DatabaseInstance) GetTasks() (*[]UserTask, error) {
 	var tasks []UserTask
 
 	for row.Next() {
-		var task UserTask
-		var trigger string
-		var actions string
-		err = row.Scan(
-			&task.ID,
-			&task.Name,
-			&task.State,
-			&trigger,
-			&actions,
-			&task.Created,
-			&task.LastTimeModified,
-		)
-		if err != nil {
-			return &tasks, err
-		}
-
-		// Parse the Trigger string into the proper struct.
-		err = json.Unmarshal([]byte(trigger), &task.Trigger)
+		task, err := scanTask(row)
 		if err != nil {
 			return &tasks, err
 		}
 
-		// Parse the Actions string into the proper struct.
-		err = json.Unmarshal([]byte(actions), &task.Actions)
-		if err != nil {
-			return &tasks, err
-		}
-
-		tasks = append(tasks, task)
+		tasks = append(tasks, *task)
 	}
 
 	return &tasks, nil
@@ -84,36 +63,7 @@ func (db *DatabaseInstance) GetTaskByName(name string) (taskFound *UserTask, err
 		return nil, ErrBadTaskID
 	}
 
-	var task UserTask
-	var trigger string
-	var actions string
-
-	err = row.Scan(
-		&task.ID,
-		&task.Name,
-		&task.State,
-		&trigger,
-		&actions,
-		&task.Created,
-		&task.LastTimeModified,
-	)
-	if err != nil {
-		return &task, err
-	}
-
-	// Parse the Trigger string into the proper struct.
-	err = json.Unmarshal([]byte(trigger), &task.Trigger)
-	if err != nil {
-		return &task, err
-	}
-
-	// Parse the Actions string into the proper struct.
-	err = json.Unmarshal([]byte(actions), &task.Actions)
-	if err != nil {
-		return &task, err
-	}
-
-	return &task, nil
+	return scanTask(row)
 }
 
 // GetTaskByID is a method that returns a specific task, searching it by the ID on the database.
@@ -139,36 +89,7 @@ func (db *DatabaseInstance) GetTaskByID(ID string) (taskFound *UserTask, err err
 		return nil, ErrBadTaskID
 	}
 
-	var task UserTask
-	var trigger string
-	var actions string
-
-	err = row.Scan(
-		&task.ID,
-		&task.Name,
-		&task.State,
-		&trigger,
-		&actions,
-		&task.Created,
-		&task.LastTimeModified,
-	)
-	if err != nil {
-		return &task, err
-	}
-
-	// Parse the Trigger string into the proper struct.
-	err = json.Unmarshal([]byte(trigger), &task.Trigger)
-	if err != nil {
-		return &task, err
-	}
-
-	// Parse the Actions string into the proper struct.
-	err = json.Unmarshal([]byte(actions), &task.Actions)
-	if err != nil {
-		return &task, err
-	}
-
-	return &task, nil
+	return scanTask(row)
 }
 
 // GetActiveTasks is a method that returns the tasks with the state `StateTaskActive`.
@@ -212,36 +133,72 @@ func (db *DatabaseInstance) getTasksByState(state TaskState) (matchedTasks *[]Us
 	var tasks []UserTask
 
 	for row.Next() {
-		var task UserTask
-		var trigger string
-		var actions string
-		err = row.Scan(
-			&task.ID,
-			&task.Name,
-			&task.State,
-			&trigger,
-			&actions,
-			&task.Created,
-			&task.LastTimeModified,
-		)
+		task, err := scanTask(row)
 		if err != nil {
 			return &tasks, err
 		}
 
-		// Parse the Trigger string into the proper struct.
-		err = json.Unmarshal([]byte(trigger), &task.Trigger)
-		if err != nil {
-			return &tasks, err
-		}
+		tasks = append(tasks, *task)
+	}
 
-		// Parse the Actions string into the proper struct.
-		err = json.Unmarshal([]byte(actions), &task.Actions)
-		if err != nil {
-			return &tasks, err
-		}
+	return &tasks, nil
+}
+
+func scanTask(row *sql.Rows) (*UserTask, error) {
+	var task UserTask
+	var trigger string
+	var actions string
+	var settings string
+	var onFailure string
+	var lastFailure sql.NullTime
+	var lastFired sql.NullTime
+
+	err := row.Scan(
+		&task.ID,
+		&task.Name,
+		&task.State,
+		&trigger,
+		&actions,
+		&task.Created,
+		&task.LastTimeModified,
+		&settings,
+		&onFailure,
+		&task.FailureCount,
+		&lastFailure,
+		&lastFired,
+	)
+	if err != nil {
+		return &task, err
+	}
 
-		tasks = append(tasks, task)
+	// NULL if the task has never failed.
+	task.LastFailure = lastFailure.Time
+	// NULL if the trigger of the task has never been activated (or isn't a schedule trigger).
+	task.LastFired = lastFired.Time
+
+	// Parse the Trigger string into the proper struct.
+	err = json.Unmarshal([]byte(trigger), &task.Trigger)
+	if err != nil {
+		return &task, err
 	}
 
-	return &tasks, nil
+	// Parse the Actions string into the proper struct.
+	err = json.Unmarshal([]byte(actions), &task.Actions)
+	if err != nil {
+		return &task, err
+	}
+
+	// Parse the Settings string into the proper struct.
+	err = json.Unmarshal([]byte(settings), &task.Settings)
+	if err != nil {
+		return &task, err
+	}
+
+	// Parse the OnFailure string into the proper struct.
+	err = json.Unmarshal([]byte(onFailure), &task.OnFailure)
+	if err != nil {
+		return &task, err
+	}
+
+	return &task, nil
 }
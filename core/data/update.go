@@ -14,7 +14,7 @@ func (db *DatabaseInstance) UpdateTask(ID string, updatedTask *UserTask) error {
 
 	sqlStatement := `
 		UPDATE Tasks 
-		SET Name = ?, State = ?, Trigger = ?, Actions = ?, LastTimeModified = ? 
+		SET Name = ?, State = ?, Trigger = ?, Actions = ?, LastTimeModified = ?, Settings = ?, OnFailure = ? 
 		WHERE ID = ?;
 	`
 	var trigger string
@@ -34,6 +34,18 @@ func (db *DatabaseInstance) UpdateTask(ID string, updatedTask *UserTask) error {
 	}
 	actions = string(a)
 
+	// Marshal the TaskSettings struct
+	settings, err := json.Marshal(updatedTask.Settings)
+	if err != nil {
+		return err
+	}
+
+	// Marshal the []UserAction slice executed on failure
+	onFailure, err := json.Marshal(updatedTask.OnFailure)
+	if err != nil {
+		return err
+	}
+
 	updatedTask.LastTimeModified = time.Now()
 
 	r, err := db.instance.Exec(sqlStatement,
@@ -42,6 +54,8 @@ func (db *DatabaseInstance) UpdateTask(ID string, updatedTask *UserTask) error {
 		trigger,
 		actions,
 		updatedTask.LastTimeModified,
+		string(settings),
+		string(onFailure),
 		ID,
 	)
 	if err != nil {
@@ -98,3 +112,106 @@ func (db *DatabaseInstance) UpdateTaskState(ID string, newState TaskState) error
 
 	return nil
 }
+
+// RestoreTaskState is a method that changes the state of a task on execution to its previous one. If the state has
+// been changed during the execution (by the user, for example), it's kept.
+func (db *DatabaseInstance) RestoreTaskState(ID string, previousState TaskState) error {
+	if !(previousState == StateTaskActive || previousState == StateTaskInactive || previousState == StateTaskFailed) {
+		return ErrIntegrity
+	}
+
+	sqlStatement := `
+		UPDATE Tasks 
+		SET State = ?
+		WHERE ID = ? AND State = ?;
+	`
+
+	_, err := db.instance.Exec(sqlStatement,
+		previousState,
+		ID,
+		StateTaskOnExecution,
+	)
+
+	return err
+}
+
+// RegisterTaskFailure is a method that changes the state of the task to failed, increasing its counter of
+// consecutive failures and storing the moment of the failure.
+func (db *DatabaseInstance) RegisterTaskFailure(ID string, failedAt time.Time) error {
+	sqlStatement := `
+		UPDATE Tasks 
+		SET State = ?, FailureCount = FailureCount + 1, LastFailure = ?
+		WHERE ID = ?;
+	`
+
+	r, err := db.instance.Exec(sqlStatement,
+		StateTaskFailed,
+		failedAt,
+		ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := r.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("the task with the ID '%s' does not exist", ID)
+	}
+
+	return nil
+}
+
+// ResetFailureCount is a method that resets the counter of consecutive failures of the task, used after a successful
+// execution.
+func (db *DatabaseInstance) ResetFailureCount(ID string) error {
+	sqlStatement := `
+		UPDATE Tasks 
+		SET FailureCount = 0
+		WHERE ID = ?;
+	`
+
+	r, err := db.instance.Exec(sqlStatement, ID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := r.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("the task with the ID '%s' does not exist", ID)
+	}
+
+	return nil
+}
+
+// UpdateLastFired is a method that stores the moment of the last activation of the trigger of the task.
+func (db *DatabaseInstance) UpdateLastFired(ID string, firedAt time.Time) error {
+	sqlStatement := `
+		UPDATE Tasks 
+		SET LastFired = ?
+		WHERE ID = ?;
+	`
+
+	r, err := db.instance.Exec(sqlStatement, firedAt, ID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := r.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("the task with the ID '%s' does not exist", ID)
+	}
+
+	return nil
+}
@@ -276,6 +276,95 @@ func (s *UpdateTestSuite) TestUpdateTaskState() {
 	end <- struct{}{}
 }
 
+func (s *UpdateTestSuite) TestRestoreTaskState() {
+	assert := assert2.New(s.T())
+
+	err := s.TestDB.UpdateTaskState(s.TestTasks[0].ID, StateTaskOnExecution)
+	if err != nil {
+		panic(err)
+	}
+
+	err = s.TestDB.RestoreTaskState(s.TestTasks[0].ID, StateTaskActive)
+	assert.NoError(err, "The state should be restored without errors")
+
+	task, err := s.TestDB.GetTaskByID(s.TestTasks[0].ID)
+	if assert.NoError(err) {
+		assert.Equal(StateTaskActive, task.State, "The previous state of the task must be restored")
+	}
+
+	// The state changed during the execution must be kept.
+	err = s.TestDB.UpdateTaskState(s.TestTasks[0].ID, StateTaskInactive)
+	if err != nil {
+		panic(err)
+	}
+
+	err = s.TestDB.RestoreTaskState(s.TestTasks[0].ID, StateTaskActive)
+	assert.NoError(err, "A task not on execution should not return an error")
+
+	task, err = s.TestDB.GetTaskByID(s.TestTasks[0].ID)
+	if assert.NoError(err) {
+		assert.Equal(StateTaskInactive, task.State, "The state changed by the user must be kept")
+	}
+
+	err = s.TestDB.RestoreTaskState(s.TestTasks[0].ID, StateTaskOnExecution)
+	assert.Error(err, "A task can't be restored to the state on-execution")
+}
+
+func (s *UpdateTestSuite) TestRegisterTaskFailure() {
+	assert := assert2.New(s.T())
+
+	failedAt := time.Now()
+
+	// Each failure must increase the counter.
+	for i := 0; i < 2; i++ {
+		err := s.TestDB.RegisterTaskFailure(s.TestTasks[0].ID, failedAt)
+		assert.NoError(err, "The failure should be registered without errors")
+	}
+
+	task, err := getTask(s.TestDB, s.TestTasks[0].Name)
+	if err != nil {
+		panic(err)
+	}
+
+	assert.Equal(StateTaskFailed, task.State, "The state of the task must be changed to failed")
+	assert.Equal(uint16(2), task.FailureCount, "The consecutive failures must be counted")
+	assert.True(failedAt.Equal(task.LastFailure), "The time of the last failure must be stored")
+
+	err = s.TestDB.ResetFailureCount(s.TestTasks[0].ID)
+	assert.NoError(err, "The counter of failures should be reset without errors")
+
+	task, err = s.TestDB.GetTaskByID(s.TestTasks[0].ID)
+	if assert.NoError(err) {
+		assert.Equal(uint16(0), task.FailureCount, "The counter of failures must be reset")
+		assert.True(failedAt.Equal(task.LastFailure), "The time of the last failure must be kept")
+	}
+
+	err = s.TestDB.RegisterTaskFailure(s.TestTasks[0].ID+"a", failedAt)
+	assert.Error(err, "Try to use an ID that does not exist should return an error")
+}
+
+func (s *UpdateTestSuite) TestUpdateLastFired() {
+	assert := assert2.New(s.T())
+
+	firedAt := time.Now()
+
+	err := s.TestDB.UpdateLastFired(s.TestTasks[0].ID, firedAt)
+	assert.NoError(err, "The activation should be registered without errors")
+
+	task, err := s.TestDB.GetTaskByID(s.TestTasks[0].ID)
+	if assert.NoError(err) {
+		assert.True(firedAt.Equal(task.LastFired), "The time of the last activation must be stored")
+	}
+
+	task, err = s.TestDB.GetTaskByID(s.TestTasks[1].ID)
+	if assert.NoError(err) {
+		assert.True(task.LastFired.IsZero(), "The rest of the tasks must not be modified")
+	}
+
+	err = s.TestDB.UpdateLastFired(s.TestTasks[0].ID+"a", firedAt)
+	assert.Error(err, "Try to use an ID that does not exist should return an error")
+}
+
 func (s *UpdateTestSuite) TearDownTest() {
 	err := os.RemoveAll(s.TestDir)
 	if err != nil {
@@ -2,6 +2,10 @@ package data
 
 import (
 	"encoding/json"
+	"regexp"
+
+	"github.com/Pegasus8/piworker/core/types"
+
 	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
 )
@@ -20,8 +24,10 @@ func (db *DatabaseInstance) NewTask(task *UserTask) error {
 		Trigger,
 		Actions,
 		Created,
-		LastTimeModified
-	) values (?,?,?,?,?,?,?)
+		LastTimeModified,
+		Settings,
+		OnFailure
+	) values (?,?,?,?,?,?,?,?,?)
 	`
 
 	// Set the task ID
@@ -48,6 +54,16 @@ func (db *DatabaseInstance) NewTask(task *UserTask) error {
 		return err
 	}
 
+	settings, err := json.Marshal(task.Settings)
+	if err != nil {
+		return err
+	}
+
+	onFailure, err := json.Marshal(task.OnFailure)
+	if err != nil {
+		return err
+	}
+
 	_, err = stmt.Exec(
 		task.ID,
 		task.Name,
@@ -56,6 +72,8 @@ func (db *DatabaseInstance) NewTask(task *UserTask) error {
 		string(actions),
 		task.Created,
 		task.LastTimeModified,
+		string(settings),
+		string(onFailure),
 	)
 	if err != nil {
 		return err
@@ -81,30 +99,207 @@ func checkIntegrity(t *UserTask) bool {
 	}
 
 	// *--- Trigger check ---*
-	if t.Trigger.ID == "" {
+	if !checkTriggerIntegrity(&t.Trigger) {
 		return false
 	}
+	// --- End of Trigger check ---
 
-	for _, tArg := range t.Trigger.Args {
-		if tArg.ID == "" {
+	if t.Settings.Timeout < 0 {
+		return false
+	}
+
+	switch t.Settings.Overlap {
+	case "", OverlapSkip, OverlapQueue:
+	case OverlapParallel:
+		if t.Settings.MaxConcurrentRuns == 0 {
+			return false
+		}
+	default:
+		return false
+	}
+
+	switch t.Settings.Misfire {
+	case "", MisfireSkip, MisfireFireOnce, MisfireFireAll:
+	default:
+		return false
+	}
+
+	if r := t.Settings.Restart; r != nil {
+		switch r.Mode {
+		case RestartNever, RestartAlways:
+		case RestartOnFailure:
+			if r.MaxRestarts == 0 {
+				return false
+			}
+		default:
+			return false
+		}
+
+		if r.Cooldown < 0 {
 			return false
 		}
 	}
-	// --- End of Trigger check ---
 
 	// *--- Actions check ---*
-	for _, action := range t.Actions {
-		if action.ID == "" {
+	if !checkActionListIntegrity(t.Actions) {
+		return false
+	}
+
+	if !checkActionListIntegrity(t.OnFailure) {
+		return false
+	}
+	// --- End of Actions check ---
+
+	return true
+}
+
+// checkTriggerIntegrity checks the fields of a trigger and, in the case of a composite one, of all its children.
+func checkTriggerIntegrity(t *UserTrigger) bool {
+	if t.ID == "" {
+		return false
+	}
+
+	for _, tArg := range t.Args {
+		if tArg.ID == "" {
+			return false
+		}
+	}
+
+	for i := range t.Children {
+		if !checkTriggerIntegrity(&t.Children[i]) {
 			return false
 		}
+	}
+
+	return true
+}
+
+// checkActionListIntegrity checks each action of the list and the parallel groups formed by them.
+func checkActionListIntegrity(actions []UserAction) bool {
+	groups := make(map[uint8][]*UserAction)
+
+	for i := range actions {
+		if !checkActionIntegrity(&actions[i]) {
+			return false
+		}
+
+		groups[actions[i].Order] = append(groups[actions[i].Order], &actions[i])
+	}
+
+	// A jump to an order without actions would finish the execution quietly.
+	for i := range actions {
+		c := actions[i].Condition
+		if c != nil && c.Else == ElseGoto && len(groups[c.Goto]) == 0 {
+			return false
+		}
+	}
+
+	for _, group := range groups {
+		if len(group) == 1 || !anyParallel(group) {
+			continue
+		}
 
-		for _, aArg := range action.Args {
-			if aArg.ID == "" {
+		// If one of the actions that share the same order is parallel, all of them must be.
+		for i, a := range group {
+			if a.Parallel == nil || a.Parallel.Join != group[0].Parallel.Join {
+				return false
+			}
+
+			if i > 0 && a.Condition != nil {
 				return false
 			}
 		}
 	}
-	// --- End of Actions check ---
+
+	return true
+}
+
+func anyParallel(actions []*UserAction) bool {
+	for _, a := range actions {
+		if a.Parallel != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+func checkActionIntegrity(a *UserAction) bool {
+	if a.ID == "" {
+		return false
+	}
+
+	for _, aArg := range a.Args {
+		if aArg.ID == "" {
+			return false
+		}
+	}
+
+	if a.Retry != nil && !checkRetryPolicyIntegrity(a.Retry) {
+		return false
+	}
+
+	if a.Timeout < 0 {
+		return false
+	}
+
+	if a.Condition != nil && !checkConditionIntegrity(a.Condition, a.Order) {
+		return false
+	}
+
+	if a.Parallel != nil && !(a.Parallel.Join == "" || a.Parallel.Join == JoinAll || a.Parallel.Join == JoinAny) {
+		return false
+	}
+
+	return true
+}
+
+func checkConditionIntegrity(c *StepCondition, order uint8) bool {
+	switch c.Operator {
+	case OperatorEqual, OperatorNotEqual, OperatorGreater, OperatorGreaterOrEqual, OperatorLess, OperatorLessOrEqual,
+		OperatorContains:
+	case OperatorMatches:
+		if _, err := regexp.Compile(c.Value); err != nil {
+			return false
+		}
+	default:
+		return false
+	}
+
+	if _, exists := types.CompatList()[c.Type]; c.Type != "" && !exists {
+		return false
+	}
+
+	switch c.Else {
+	case "", ElseStop:
+	case ElseGoto:
+		// Only forward jumps are admitted, so the execution always ends.
+		if c.Goto <= order {
+			return false
+		}
+	default:
+		return false
+	}
+
+	return true
+}
+
+func checkRetryPolicyIntegrity(p *RetryPolicy) bool {
+	if p.MaxAttempts == 0 {
+		return false
+	}
+
+	if !(p.Backoff == "" || p.Backoff == BackoffFixed || p.Backoff == BackoffExponential) {
+		return false
+	}
+
+	if p.Delay < 0 || p.MaxDelay < 0 {
+		return false
+	}
+
+	if _, err := regexp.Compile(p.RetryOn); err != nil {
+		return false
+	}
 
 	return true
 }
@@ -219,6 +219,99 @@ func (s *WriteTestSuite) TestNewTask() {
 	assert.Error(err, "If the task contains an empty field it shouldn't be added")
 	assert.EqualError(err, ErrIntegrity.Error(), "The returned error is not which should be")
 
+	// A child of a composite trigger with an empty ID must return an error.
+	s.TestTasks[0].Name = "Composite task"
+	s.TestTasks[0].Trigger.Children = []UserTrigger{{ID: ""}}
+	err = s.TestDB.NewTask(&s.TestTasks[0])
+
+	assert.Error(err, "If a child of the trigger contains an empty field the task shouldn't be added")
+	assert.EqualError(err, ErrIntegrity.Error(), "The returned error is not which should be")
+	s.TestTasks[0].Trigger.Children = nil
+
+	// A retry policy with a wrong regular expression must return an error.
+	s.TestTasks[0].Name = "Task with retries"
+	s.TestTasks[0].Actions[0].Retry = &RetryPolicy{MaxAttempts: 3, RetryOn: "(unclosed"}
+	err = s.TestDB.NewTask(&s.TestTasks[0])
+
+	assert.Error(err, "A retry policy with an invalid expression shouldn't be admitted")
+	assert.EqualError(err, ErrIntegrity.Error(), "The returned error is not which should be")
+	s.TestTasks[0].Actions[0].Retry = nil
+
+	// A negative timeout must return an error.
+	s.TestTasks[0].Name = "Task with timeout"
+	s.TestTasks[0].Settings.Timeout = -1
+	err = s.TestDB.NewTask(&s.TestTasks[0])
+
+	assert.Error(err, "A negative timeout shouldn't be admitted")
+	assert.EqualError(err, ErrIntegrity.Error(), "The returned error is not which should be")
+	s.TestTasks[0].Settings.Timeout = 0
+
+	// A restart policy on failure without a limit of restarts must return an error.
+	s.TestTasks[0].Name = "Task with restart policy"
+	s.TestTasks[0].Settings.Restart = &RestartPolicy{Mode: RestartOnFailure}
+	err = s.TestDB.NewTask(&s.TestTasks[0])
+
+	assert.Error(err, "A restart policy on failure must have a limit of restarts")
+	assert.EqualError(err, ErrIntegrity.Error(), "The returned error is not which should be")
+	s.TestTasks[0].Settings.Restart = nil
+
+	// Parallel executions without a limit must return an error.
+	s.TestTasks[0].Name = "Task with overlap policy"
+	s.TestTasks[0].Settings.Overlap = OverlapParallel
+	err = s.TestDB.NewTask(&s.TestTasks[0])
+
+	assert.Error(err, "The parallel executions of a task must have a limit")
+	assert.EqualError(err, ErrIntegrity.Error(), "The returned error is not which should be")
+	s.TestTasks[0].Settings.Overlap = ""
+
+	// An unknown misfire policy must return an error.
+	s.TestTasks[0].Name = "Task with misfire policy"
+	s.TestTasks[0].Settings.Misfire = "fire-twice"
+	err = s.TestDB.NewTask(&s.TestTasks[0])
+
+	assert.Error(err, "An unknown misfire policy shouldn't be admitted")
+	assert.EqualError(err, ErrIntegrity.Error(), "The returned error is not which should be")
+	s.TestTasks[0].Settings.Misfire = ""
+
+	// A condition that jumps backwards must return an error.
+	s.TestTasks[0].Name = "Task with condition"
+	s.TestTasks[0].Actions[0].Order = 1
+	s.TestTasks[0].Actions[0].Condition = &StepCondition{Operator: OperatorEqual, Else: ElseGoto, Goto: 0}
+	err = s.TestDB.NewTask(&s.TestTasks[0])
+
+	assert.Error(err, "A condition that jumps backwards shouldn't be admitted")
+	assert.EqualError(err, ErrIntegrity.Error(), "The returned error is not which should be")
+	s.TestTasks[0].Actions[0].Order = 0
+	s.TestTasks[0].Actions[0].Condition = nil
+
+	// A condition that jumps to a non-existent order must return an error.
+	s.TestTasks[0].Actions[0].Condition = &StepCondition{Operator: OperatorEqual, Else: ElseGoto, Goto: 200}
+	err = s.TestDB.NewTask(&s.TestTasks[0])
+
+	assert.Error(err, "A condition that jumps to an order without actions shouldn't be admitted")
+	assert.EqualError(err, ErrIntegrity.Error(), "The returned error is not which should be")
+	s.TestTasks[0].Actions[0].Condition = nil
+
+	// An action executed on failure with an empty ID must return an error.
+	s.TestTasks[0].Name = "Task with actions on failure"
+	s.TestTasks[0].OnFailure = []UserAction{{ID: ""}}
+	err = s.TestDB.NewTask(&s.TestTasks[0])
+
+	assert.Error(err, "If an action executed on failure contains an empty field the task shouldn't be added")
+	assert.EqualError(err, ErrIntegrity.Error(), "The returned error is not which should be")
+	s.TestTasks[0].OnFailure = nil
+
+	// A parallel group with an action not marked as parallel must return an error.
+	s.TestTasks[0].Name = "Task with parallel actions"
+	s.TestTasks[0].Actions = append(s.TestTasks[0].Actions, s.TestTasks[0].Actions[0])
+	s.TestTasks[0].Actions[0].Parallel = &ParallelGroup{Join: JoinAny}
+	err = s.TestDB.NewTask(&s.TestTasks[0])
+
+	assert.Error(err, "All the actions of a parallel group must be marked as parallel")
+	assert.EqualError(err, ErrIntegrity.Error(), "The returned error is not which should be")
+	s.TestTasks[0].Actions = s.TestTasks[0].Actions[:len(s.TestTasks[0].Actions)-1]
+	s.TestTasks[0].Actions[0].Parallel = nil
+
 	// The usage of a no admitted `State` must return an error.
 	s.TestTasks[0].Name = "Another name"
 	s.TestTasks[0].State = StateTaskFailed // Let's use a no admitted state.
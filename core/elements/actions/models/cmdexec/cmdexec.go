@@ -1,9 +1,9 @@
 package cmdexec
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"os/exec"
 	"strings"
 	"time"
 
@@ -11,6 +11,7 @@ import (
 	"github.com/Pegasus8/piworker/core/elements/actions/shared"
 	"github.com/Pegasus8/piworker/core/types"
 	"github.com/Pegasus8/piworker/utilities/files"
+	"github.com/Pegasus8/piworker/utilities/processes"
 )
 
 const actionID = "A3"
@@ -44,7 +45,7 @@ var ExecuteCommand = shared.Action{
 
 var outputPath = "."
 
-func action(previousResult *shared.ChainedResult, parentAction *data.UserAction, parentTaskID string) (result bool, chainedResult *shared.ChainedResult, err error) {
+func action(ctx context.Context, previousResult *shared.ChainedResult, parentAction *data.UserAction, parentTaskID string) (result bool, chainedResult *shared.ChainedResult, err error) {
 	if len(parentAction.Args) != len(actionArgs) {
 		return false, &shared.ChainedResult{}, fmt.Errorf("%d arguments were expected and %d were obtained", len(actionArgs), len(parentAction.Args))
 	}
@@ -82,9 +83,15 @@ func action(previousResult *shared.ChainedResult, parentAction *data.UserAction,
 		return false, &shared.ChainedResult{}, errors.New("Error: command or commandArgs empty")
 	}
 
-	cmd := exec.Command(command, commandArgs...)
+	// The process (and the ones started by it) is killed if the execution of the action is canceled or exceeds its
+	// timeout.
+	cmd := processes.CommandContext(ctx, command, commandArgs...)
 	output, err := cmd.Output()
 	if err != nil {
+		if ctx.Err() != nil {
+			// Report the cause of the interruption instead of the signal that killed the process.
+			return false, &shared.ChainedResult{}, ctx.Err()
+		}
 		return false, &shared.ChainedResult{}, err
 	}
 
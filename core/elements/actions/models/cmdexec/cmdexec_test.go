@@ -1,8 +1,10 @@
 package cmdexec
 
 import (
+	"context"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/Pegasus8/piworker/core/data"
 	"github.com/Pegasus8/piworker/core/elements/actions/shared"
@@ -163,7 +165,7 @@ func (suite *ActionTestSuite) TestExecuteCommand() {
 			Args:                  arg,
 		}
 
-		r, cr, err := ExecuteCommand.Run(&shared.ChainedResult{}, &ua, suite.TaskID)
+		r, cr, err := ExecuteCommand.Run(context.Background(), &shared.ChainedResult{}, &ua, suite.TaskID)
 		assert.Equalf(true, r, "argument %d should return a true result", i)
 		assert.NotEmptyf(*cr, "argument %d should return a not empty chained result", i)
 		assert.NoErrorf(err, "argument %d shouldn't return an error", i)
@@ -188,7 +190,7 @@ func (suite *ActionTestSuite) TestExecuteCommand() {
 			Args:                  arg,
 		}
 
-		r, cr, err := ExecuteCommand.Run(&shared.ChainedResult{}, &ua, suite.TaskID)
+		r, cr, err := ExecuteCommand.Run(context.Background(), &shared.ChainedResult{}, &ua, suite.TaskID)
 		assert.Equalf(false, r, "argument %d should return a false result", i)
 		assert.Emptyf(*cr, "argument %d should return an empty chained result", i)
 		assert.Errorf(err, "argument %d should return an error", i)
@@ -207,6 +209,28 @@ func (suite *ActionTestSuite) TestExecuteCommand() {
 	}
 }
 
+func (suite *ActionTestSuite) TestTimeout() {
+	assert := assert.New(suite.T())
+
+	// The command started by the shell keeps the output open after the shell is killed.
+	ua := data.UserAction{
+		ID: ExecuteCommand.ID,
+		Args: []data.UserArg{
+			{ID: ExecuteCommand.Args[0].ID, Content: "sh"},
+			{ID: ExecuteCommand.Args[1].ID, Content: "-c,sleep 10 | cat"},
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	r, _, err := ExecuteCommand.Run(ctx, &shared.ChainedResult{}, &ua, suite.TaskID)
+	assert.False(r)
+	assert.Equal(context.DeadlineExceeded, err)
+	assert.True(time.Since(start) < 5*time.Second, "the processes started by the command must be killed too")
+}
+
 func (suite *ActionTestSuite) TearDownTest() {
 	err := os.RemoveAll(suite.TestDir)
 	if err != nil {
@@ -1,6 +1,7 @@
 package compress
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -48,7 +49,7 @@ var CompressFilesOfDir = shared.Action{
 	ReturnedChainResultType:        types.Path,
 }
 
-func action(previousResult *shared.ChainedResult, parentAction *data.UserAction, parentTaskID string) (result bool, chainedResult *shared.ChainedResult, err error) {
+func action(ctx context.Context, previousResult *shared.ChainedResult, parentAction *data.UserAction, parentTaskID string) (result bool, chainedResult *shared.ChainedResult, err error) {
 	if len(parentAction.Args) != len(actionArgs) {
 		return false, &shared.ChainedResult{}, fmt.Errorf("%d arguments were expected and %d were obtained", len(actionArgs), len(parentAction.Args))
 	}
@@ -99,7 +100,7 @@ func action(previousResult *shared.ChainedResult, parentAction *data.UserAction,
 
 	outputDir = filepath.Join(outputDir, outputFilename+".zip")
 
-	err = zipWriter(targetDir, outputDir)
+	err = zipWriter(ctx, targetDir, outputDir)
 	if err != nil {
 		return false, &shared.ChainedResult{}, err
 	}
@@ -1,6 +1,7 @@
 package compress
 
 import (
+	"context"
 	"math/rand"
 	"os"
 	"path/filepath"
@@ -262,7 +263,7 @@ func (suite *ActionTestSuite) TestCompressFilesOfDir() {
 			Args:                  arg,
 		}
 
-		r, cr, err := CompressFilesOfDir.Run(&shared.ChainedResult{}, &ua, suite.TaskID)
+		r, cr, err := CompressFilesOfDir.Run(context.Background(), &shared.ChainedResult{}, &ua, suite.TaskID)
 		assert.Equalf(true, r, "argument %d should return a true result", i)
 		assert.NotEmptyf(*cr, "argument %d should return a not empty chained result", i)
 		assert.NoErrorf(err, "argument %d shouldn't return an error", i)
@@ -287,7 +288,7 @@ func (suite *ActionTestSuite) TestCompressFilesOfDir() {
 			Args:                  arg,
 		}
 
-		r, cr, err := CompressFilesOfDir.Run(&shared.ChainedResult{}, &ua, suite.TaskID)
+		r, cr, err := CompressFilesOfDir.Run(context.Background(), &shared.ChainedResult{}, &ua, suite.TaskID)
 		assert.Equalf(false, r, "argument %d should return a false result", i)
 		assert.Emptyf(*cr, "argument %d should return an empty chained result", i)
 		assert.Errorf(err, "argument %d should return an error", i)
@@ -2,12 +2,13 @@ package compress
 
 import (
 	"archive/zip"
+	"context"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 )
 
-func zipWriter(targetPath, outputPath string) error {
+func zipWriter(ctx context.Context, targetPath, outputPath string) error {
 	target := filepath.Clean(targetPath)
 	output := filepath.Clean(outputPath)
 
@@ -19,7 +20,7 @@ func zipWriter(targetPath, outputPath string) error {
 
 	w := zip.NewWriter(outputFile)
 
-	err = addFiles(w, target, "")
+	err = addFiles(ctx, w, target, "")
 	if err != nil {
 		return err
 	}
@@ -32,7 +33,7 @@ func zipWriter(targetPath, outputPath string) error {
 	return nil
 }
 
-func addFiles(w *zip.Writer, basePath, baseInZip string) error {
+func addFiles(ctx context.Context, w *zip.Writer, basePath, baseInZip string) error {
 	// Check if is a file.
 	f, err := os.Stat(basePath)
 	if err != nil {
@@ -56,6 +57,11 @@ func addFiles(w *zip.Writer, basePath, baseInZip string) error {
 	}
 
 	for _, file := range files {
+		// Stop as soon as possible if the execution of the action is canceled.
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		if !file.IsDir() {
 			err := compressFile(filepath.Join(basePath, file.Name()), w, baseInZip+file.Name())
 			if err != nil {
@@ -64,7 +70,7 @@ func addFiles(w *zip.Writer, basePath, baseInZip string) error {
 		} else {
 			// If it's a directory, execute the function recursively.
 			newBase := filepath.Join(basePath, file.Name())
-			err := addFiles(w, newBase, baseInZip+file.Name()+"/")
+			err := addFiles(ctx, w, newBase, baseInZip+file.Name()+"/")
 			if err != nil {
 				return err
 			}
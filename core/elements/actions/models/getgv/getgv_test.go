@@ -1,6 +1,7 @@
 package getgv
 
 import (
+	"context"
 	"os"
 	"sync"
 	"testing"
@@ -155,7 +156,7 @@ func (suite *ActionTestSuite) TestGetGlobalVariable() {
 			Args:                  arg,
 		}
 
-		r, cr, err := GetGlobalVariable.Run(&shared.ChainedResult{}, &ua, suite.TaskID)
+		r, cr, err := GetGlobalVariable.Run(context.Background(), &shared.ChainedResult{}, &ua, suite.TaskID)
 		assert.Equalf(true, r, "argument %d should return a true result", i)
 		assert.NotEmptyf(*cr, "argument %d should return a not empty chained result", i)
 		assert.NoErrorf(err, "argument %d shouldn't return an error", i)
@@ -171,7 +172,7 @@ func (suite *ActionTestSuite) TestGetGlobalVariable() {
 			Args:                  arg,
 		}
 
-		r, cr, err := GetGlobalVariable.Run(&shared.ChainedResult{}, &ua, suite.TaskID)
+		r, cr, err := GetGlobalVariable.Run(context.Background(), &shared.ChainedResult{}, &ua, suite.TaskID)
 		assert.Equalf(false, r, "argument %d should return a false result", i)
 		assert.Emptyf(*cr, "argument %d should return an empty chained result", i)
 		assert.Errorf(err, "argument %d should return an error", i)
@@ -1,6 +1,7 @@
 package getlv
 
 import (
+	"context"
 	"os"
 	"sync"
 	"testing"
@@ -179,7 +180,7 @@ func (suite *ActionTestSuite) TestGetLocalVariable() {
 			Args:                  arg,
 		}
 
-		r, cr, err := GetLocalVariable.Run(&shared.ChainedResult{}, &ua, suite.TaskID)
+		r, cr, err := GetLocalVariable.Run(context.Background(), &shared.ChainedResult{}, &ua, suite.TaskID)
 		assert.Equalf(true, r, "argument %d should return a true result", i)
 		assert.NotEmptyf(*cr, "argument %d should return a not empty chained result", i)
 		assert.NoErrorf(err, "argument %d shouldn't return an error", i)
@@ -195,7 +196,7 @@ func (suite *ActionTestSuite) TestGetLocalVariable() {
 			Args:                  arg,
 		}
 
-		r, cr, err := GetLocalVariable.Run(&shared.ChainedResult{}, &ua, suite.TaskID)
+		r, cr, err := GetLocalVariable.Run(context.Background(), &shared.ChainedResult{}, &ua, suite.TaskID)
 		assert.Equalf(false, r, "argument %d should return a false result", i)
 		assert.Emptyf(*cr, "argument %d should return an empty chained result", i)
 		assert.Errorf(err, "argument %d should return an error", i)
@@ -0,0 +1,326 @@
+package script
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/Pegasus8/piworker/core/data"
+	"github.com/Pegasus8/piworker/core/elements/actions/shared"
+	"github.com/Pegasus8/piworker/core/types"
+	"github.com/Pegasus8/piworker/core/uservariables"
+
+	"github.com/rs/zerolog/log"
+	"go.starlark.net/resolve"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkjson"
+	"go.starlark.net/starlarkstruct"
+)
+
+const actionID = "A8"
+
+var actionArgs = []shared.Arg{
+	{
+		ID:   actionID + "-1",
+		Name: "Script",
+		Description: "The Starlark (a dialect of Python) script to execute. The result of the previous action is " +
+			"available as `previous.result` and `previous.type`, the user variables can be used with the functions " +
+			"`get_global(name)`, `set_global(name, value)`, `get_local(name)` and `set_local(name, value)`, and the " +
+			"module `json` can be used to encode and decode JSON. The value assigned to `result` is the " +
+			"chained result of the action. Example: result = int(previous.result) * 2",
+		ContentType: types.Text,
+	},
+}
+
+// RunScript - Action
+var RunScript = shared.Action{
+	ID:   actionID,
+	Name: "Run Script",
+	Description: "Executes a Starlark script, without access to the file system or the network. The execution is " +
+		"interrupted if it exceeds the limits of computation and memory (the last one is approximate).",
+	Run:                            action,
+	Args:                           actionArgs,
+	ReturnedChainResultDescription: "The value assigned to the variable `result` by the script.",
+	ReturnedChainResultType:        types.Any,
+}
+
+var (
+	// MaxExecutionSteps limits the computation done by each execution of a script, which is interrupted once it
+	// exceeds the limit. Zero means no limit.
+	MaxExecutionSteps uint64 = 10000000
+	// MaxMemory limits (in bytes) the memory allocated by each execution of a script, which is interrupted once it
+	// exceeds the limit. Zero means no limit.
+	//
+	// The limit is best-effort, not a guarantee: the heap of the whole process is sampled every
+	// `memoryCheckInterval`, so a single operation of a script (like `'x' * n`, capped by Starlark at 1GB) can
+	// allocate far more before being detected, and the allocations of the rest of PiWorker (including other scripts
+	// running at the same time) count against the limit too.
+	MaxMemory uint64 = 32 << 20
+	// MaxValueSize limits (in bytes) the size of the values that a script passes to PiWorker: the user variables set
+	// and the result. Zero means no limit.
+	MaxValueSize = 1 << 20
+)
+
+// memoryCheckInterval is the interval between the checks of the memory used by a script.
+const memoryCheckInterval = 10 * time.Millisecond
+
+var (
+	// ErrStepsLimit is the error returned when a script exceeds `MaxExecutionSteps`.
+	ErrStepsLimit = errors.New("the script has exceeded the limit of execution steps")
+	// ErrMemoryLimit is the error returned when a script exceeds `MaxMemory`.
+	ErrMemoryLimit = errors.New("the script has exceeded the limit of memory")
+	// ErrValueSize is the error returned when a value of a script exceeds `MaxValueSize`.
+	ErrValueSize = errors.New("the value exceeds the maximum size allowed")
+)
+
+var (
+	globalNameRgx = regexp.MustCompile(`^[A-Z_0-9]+$`)
+	localNameRgx  = regexp.MustCompile(`^[a-z_0-9]+$`)
+)
+
+func init() {
+	// Allow loops and conditionals outside of functions, the scripts are short and usually don't define any.
+	resolve.AllowGlobalReassign = true
+}
+
+func action(ctx context.Context, previousResult *shared.ChainedResult, parentAction *data.UserAction, parentTaskID string) (result bool, chainedResult *shared.ChainedResult, err error) {
+	if len(parentAction.Args) != len(actionArgs) {
+		return false, &shared.ChainedResult{}, fmt.Errorf("%d arguments were expected and %d were obtained", len(actionArgs), len(parentAction.Args))
+	}
+
+	var args *[]data.UserArg
+
+	// The source of the script
+	var script string
+
+	args = &parentAction.Args
+
+	err = shared.HandleCR(parentAction, actionArgs, previousResult)
+	if err != nil {
+		return false, &shared.ChainedResult{}, err
+	}
+
+	for i, arg := range *args {
+		if arg.Content == "" {
+			return false, &shared.ChainedResult{}, fmt.Errorf("argument %d (ID: %s) is empty", i, arg.ID)
+		}
+
+		switch arg.ID {
+		case actionArgs[0].ID:
+			script = arg.Content
+		default:
+			return false, &shared.ChainedResult{}, shared.ErrUnrecognizedArgID
+		}
+	}
+
+	thread := &starlark.Thread{
+		Name: parentTaskID,
+		Print: func(_ *starlark.Thread, msg string) {
+			log.Info().Str("taskID", parentTaskID).Str("actionID", actionID).Msg(msg)
+		},
+	}
+	thread.SetMaxExecutionSteps(MaxExecutionSteps)
+
+	stop := make(chan struct{})
+	defer close(stop)
+	var memoryExceeded int32
+	go watch(ctx, thread, stop, &memoryExceeded)
+
+	globals, err := starlark.ExecFile(thread, "script", script, predeclared(previousResult, parentTaskID))
+	if err == nil {
+		r, ok := globals["result"]
+		if !ok || r == starlark.None {
+			return true, &shared.ChainedResult{ResultType: types.Any}, nil
+		}
+
+		var content string
+		var contentType types.PWType
+		content, contentType, err = toContent(thread, r)
+		if err == nil {
+			return true, &shared.ChainedResult{Result: content, ResultType: contentType}, nil
+		}
+	}
+
+	switch {
+	case ctx.Err() != nil:
+		// Report the cause of the interruption instead of the cancellation of the script.
+		return false, &shared.ChainedResult{}, ctx.Err()
+	case atomic.LoadInt32(&memoryExceeded) == 1:
+		return false, &shared.ChainedResult{}, ErrMemoryLimit
+	case MaxExecutionSteps > 0 && thread.ExecutionSteps() >= MaxExecutionSteps:
+		return false, &shared.ChainedResult{}, ErrStepsLimit
+	default:
+		return false, &shared.ChainedResult{}, err
+	}
+}
+
+// watch cancels the execution of `thread` when `ctx` is done or when the heap grows more than `MaxMemory`, until
+// `stop` is closed.
+func watch(ctx context.Context, thread *starlark.Thread, stop <-chan struct{}, memoryExceeded *int32) {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	baseline := m.HeapAlloc
+
+	ticker := time.NewTicker(memoryCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ctx.Done():
+			thread.Cancel(ctx.Err().Error())
+			return
+		case <-ticker.C:
+			runtime.ReadMemStats(&m)
+			if MaxMemory > 0 && m.HeapAlloc > baseline && m.HeapAlloc-baseline > MaxMemory {
+				atomic.StoreInt32(memoryExceeded, 1)
+				thread.Cancel(ErrMemoryLimit.Error())
+				return
+			}
+		}
+	}
+}
+
+// predeclared returns the values available to the scripts.
+func predeclared(previousResult *shared.ChainedResult, parentTaskID string) starlark.StringDict {
+	previous := starlarkstruct.FromStringDict(starlarkstruct.Default, starlark.StringDict{
+		"result": starlark.String(previousResult.Result),
+		"type":   starlark.String(previousResult.ResultType),
+	})
+
+	getGlobal := func(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var name string
+		if err := starlark.UnpackArgs(b.Name(), args, kwargs, "name", &name); err != nil {
+			return nil, err
+		}
+
+		gv, err := uservariables.GetGlobalVariable(name)
+		if err != nil {
+			if err == uservariables.ErrInvalidVariable {
+				return starlark.None, nil
+			}
+
+			return nil, err
+		}
+
+		return starlark.String(gv.Content), nil
+	}
+
+	setGlobal := func(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var name string
+		var value starlark.Value
+		if err := starlark.UnpackArgs(b.Name(), args, kwargs, "name", &name, "value", &value); err != nil {
+			return nil, err
+		}
+
+		if !globalNameRgx.MatchString(name) {
+			return nil, shared.ErrWrongUVFormat
+		}
+
+		content, _, err := toContent(thread, value)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = uservariables.SetGlobalVariable(name, content)
+		if err != nil {
+			return nil, err
+		}
+
+		return starlark.None, nil
+	}
+
+	getLocal := func(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var name string
+		if err := starlark.UnpackArgs(b.Name(), args, kwargs, "name", &name); err != nil {
+			return nil, err
+		}
+
+		lv, err := uservariables.GetLocalVariable(name, parentTaskID)
+		if err != nil {
+			if err == uservariables.ErrInvalidVariable {
+				return starlark.None, nil
+			}
+
+			return nil, err
+		}
+
+		return starlark.String(lv.Content), nil
+	}
+
+	setLocal := func(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var name string
+		var value starlark.Value
+		if err := starlark.UnpackArgs(b.Name(), args, kwargs, "name", &name, "value", &value); err != nil {
+			return nil, err
+		}
+
+		if !localNameRgx.MatchString(name) {
+			return nil, shared.ErrWrongUVFormat
+		}
+
+		content, _, err := toContent(thread, value)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = uservariables.SetLocalVariable(name, content, parentTaskID)
+		if err != nil {
+			return nil, err
+		}
+
+		return starlark.None, nil
+	}
+
+	return starlark.StringDict{
+		"previous":   previous,
+		"get_global": starlark.NewBuiltin("get_global", getGlobal),
+		"set_global": starlark.NewBuiltin("set_global", setGlobal),
+		"get_local":  starlark.NewBuiltin("get_local", getLocal),
+		"set_local":  starlark.NewBuiltin("set_local", setLocal),
+		"json":       starlarkjson.Module,
+		"struct":     starlark.NewBuiltin("struct", starlarkstruct.Make),
+	}
+}
+
+// toContent converts a value of a script to its representation on PiWorker. Lists, dicts and structs are encoded as
+// JSON. The representations bigger than `MaxValueSize` are rejected with `ErrValueSize`.
+func toContent(thread *starlark.Thread, value starlark.Value) (content string, contentType types.PWType, err error) {
+	content, contentType, err = encode(thread, value)
+	if err == nil && MaxValueSize > 0 && len(content) > MaxValueSize {
+		return "", "", ErrValueSize
+	}
+
+	return content, contentType, err
+}
+
+// encode returns the representation of `value` on PiWorker, without limits of size.
+func encode(thread *starlark.Thread, value starlark.Value) (content string, contentType types.PWType, err error) {
+	switch v := value.(type) {
+	case starlark.NoneType:
+		return "", types.Any, nil
+	case starlark.Bool:
+		return strconv.FormatBool(bool(v)), types.Bool, nil
+	case starlark.Int:
+		return v.String(), types.Int, nil
+	case starlark.Float:
+		return strconv.FormatFloat(float64(v), 'f', -1, 64), types.Float, nil
+	case starlark.String:
+		return string(v), types.GetType(string(v)), nil
+	case *starlark.List, starlark.Tuple, *starlark.Dict, *starlarkstruct.Struct:
+		encoded, err := starlark.Call(thread, starlarkjson.Module.Members["encode"], starlark.Tuple{value}, nil)
+		if err != nil {
+			return "", "", err
+		}
+
+		return string(encoded.(starlark.String)), types.JSON, nil
+	default:
+		return "", "", fmt.Errorf("values of type '%s' can't be used as result", value.Type())
+	}
+}
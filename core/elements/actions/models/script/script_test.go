@@ -0,0 +1,167 @@
+package script
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Pegasus8/piworker/core/data"
+	"github.com/Pegasus8/piworker/core/elements/actions/shared"
+	"github.com/Pegasus8/piworker/core/types"
+	"github.com/Pegasus8/piworker/core/uservariables"
+	test "github.com/Pegasus8/piworker/utilities/testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type ActionTestSuite struct {
+	TestDir string
+	TaskID  string
+	suite.Suite
+}
+
+func (suite *ActionTestSuite) SetupTest() {
+	// Change the path where the variables are stored to delete them after tests execution.
+	uservariables.UserVariablesPath = "./test"
+
+	suite.TestDir = uservariables.UserVariablesPath
+	suite.TaskID = uuid.New().String()
+
+	err := os.MkdirAll(suite.TestDir, 0755)
+	if err != nil {
+		panic(err)
+	}
+
+	gv, err := uservariables.ReadGlobalVariablesFromFiles()
+	if err != nil {
+		panic(err)
+	}
+	uservariables.GlobalVariablesSlice = gv
+
+	lv, err := uservariables.ReadLocalVariablesFromFiles()
+	if err != nil {
+		panic(err)
+	}
+	uservariables.LocalVariablesSlice = lv
+}
+
+// run executes `script` with the given previous result.
+func (suite *ActionTestSuite) run(ctx context.Context, script string, previousResult *shared.ChainedResult) (bool, *shared.ChainedResult, error) {
+	ua := data.UserAction{
+		ID:   RunScript.ID,
+		Args: []data.UserArg{{ID: RunScript.Args[0].ID, Content: script}},
+	}
+
+	return RunScript.Run(ctx, previousResult, &ua, suite.TaskID)
+}
+
+func (suite *ActionTestSuite) TestRunScript() {
+	assert := assert.New(suite.T())
+
+	test.CheckAFields(suite.T(), RunScript)
+
+	previousResult := &shared.ChainedResult{Result: "21", ResultType: types.Int}
+
+	results := []struct {
+		script   string
+		expected shared.ChainedResult
+	}{
+		{`result = int(previous.result) * 2`, shared.ChainedResult{Result: "42", ResultType: types.Int}},
+		{`result = previous.type`, shared.ChainedResult{Result: string(types.Int), ResultType: types.GetType(string(types.Int))}},
+		{`result = 1.5`, shared.ChainedResult{Result: "1.5", ResultType: types.Float}},
+		{`result = int(previous.result) > 20`, shared.ChainedResult{Result: "true", ResultType: types.Bool}},
+		{`result = {"values": [1, 2]}`, shared.ChainedResult{Result: `{"values":[1,2]}`, ResultType: types.JSON}},
+		{`result = json.decode('{"a": "b"}')["a"]`, shared.ChainedResult{Result: "b", ResultType: types.GetType("b")}},
+		{"total = 0\nfor i in range(10):\n  total += i\nresult = total", shared.ChainedResult{Result: "45", ResultType: types.Int}},
+		{`x = 1`, shared.ChainedResult{ResultType: types.Any}},
+	}
+	for i, r := range results {
+		ok, cr, err := suite.run(context.Background(), r.script, previousResult)
+		assert.NoErrorf(err, "script %d shouldn't return an error", i)
+		assert.Truef(ok, "script %d should return a true result", i)
+		assert.Equalf(&r.expected, cr, "script %d should return the expected chained result", i)
+	}
+
+	// User variables.
+	ok, cr, err := suite.run(context.Background(), `
+set_global("SCRIPT_COUNTER", 1)
+set_global("SCRIPT_COUNTER", int(get_global("SCRIPT_COUNTER")) + 1)
+set_local("script_value", "foo")
+result = [get_global("SCRIPT_COUNTER"), get_local("script_value"), get_local("nothing")]
+`, previousResult)
+	assert.NoError(err)
+	assert.True(ok)
+	assert.Equal(&shared.ChainedResult{Result: `["2","foo",null]`, ResultType: types.JSON}, cr)
+	assert.FileExists(filepath.Join(suite.TestDir, "SCRIPT_COUNTER"), "the global variable should be saved on a file")
+	assert.FileExists(filepath.Join(suite.TestDir, "script_value-"+suite.TaskID), "the local variable should be saved on a file")
+
+	failures := []string{
+		`result = `,                    // Syntax error.
+		`fail("custom error")`,         // Error raised by the script.
+		`set_global("wrong_name", 1)`,  // Wrong format of the name of the variable.
+		`set_local("WRONG_NAME", 1)`,   // Wrong format of the name of the variable.
+		`load("other.star", "x")`,      // Scripts can't be loaded.
+		`result = open("/etc/passwd")`, // There is no access to the file system.
+		`result = lambda: 1`,           // Functions can't be used as result.
+	}
+	for i, script := range failures {
+		ok, cr, err := suite.run(context.Background(), script, previousResult)
+		assert.Errorf(err, "script %d should return an error", i)
+		assert.Falsef(ok, "script %d should return a false result", i)
+		assert.Emptyf(*cr, "script %d should return an empty chained result", i)
+	}
+
+	// An empty script isn't valid.
+	_, _, err = suite.run(context.Background(), "", previousResult)
+	assert.Error(err)
+}
+
+func (suite *ActionTestSuite) TestLimits() {
+	assert := assert.New(suite.T())
+
+	maxSteps, maxMemory, maxValueSize := MaxExecutionSteps, MaxMemory, MaxValueSize
+	defer func() {
+		MaxExecutionSteps, MaxMemory, MaxValueSize = maxSteps, maxMemory, maxValueSize
+	}()
+
+	MaxExecutionSteps = 100000
+	_, _, err := suite.run(context.Background(), "for i in range(1000000):\n  pass", &shared.ChainedResult{})
+	assert.Equal(ErrStepsLimit, err, "the script must be interrupted once it exceeds the limit of steps")
+
+	MaxExecutionSteps, MaxMemory = maxSteps, 16<<20
+	_, _, err = suite.run(context.Background(), "l = []\nfor i in range(1000000):\n  l.append('x' * 1024)",
+		&shared.ChainedResult{})
+	assert.Equal(ErrMemoryLimit, err, "the script must be interrupted once it exceeds the limit of memory")
+
+	MaxExecutionSteps = maxSteps
+	for _, script := range []string{"result = 'x' * 2048", "set_local('big', ['x'] * 1024)"} {
+		MaxValueSize = 1024
+		_, _, err = suite.run(context.Background(), script, &shared.ChainedResult{})
+		assert.Truef(errors.Is(err, ErrValueSize), "[%s] the values bigger than the limit must be rejected", script)
+	}
+	MaxValueSize = maxValueSize
+
+	MaxExecutionSteps, MaxMemory = 0, maxMemory // Without limit of steps.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, _, err = suite.run(ctx, "for i in range(1000000000):\n  pass", &shared.ChainedResult{})
+	assert.Equal(context.DeadlineExceeded, err, "the script must be interrupted when the execution is canceled")
+	assert.True(time.Since(start) < 5*time.Second, "the script must not be waited after the cancellation")
+}
+
+func (suite *ActionTestSuite) TearDownTest() {
+	err := os.RemoveAll(suite.TestDir)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(ActionTestSuite))
+}
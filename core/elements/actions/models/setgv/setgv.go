@@ -1,10 +1,10 @@
 package setgv
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 	"strings"
-	"sync"
 
 	"github.com/Pegasus8/piworker/core/data"
 	"github.com/Pegasus8/piworker/core/elements/actions/shared"
@@ -42,7 +42,7 @@ var SetGlobalVariable = shared.Action{
 	ReturnedChainResultType:        types.Any,
 }
 
-func action(previousResult *shared.ChainedResult, parentAction *data.UserAction, parentTaskID string) (result bool, chainedResult *shared.ChainedResult, err error) {
+func action(ctx context.Context, previousResult *shared.ChainedResult, parentAction *data.UserAction, parentTaskID string) (result bool, chainedResult *shared.ChainedResult, err error) {
 	if len(parentAction.Args) != len(actionArgs) {
 		return false, &shared.ChainedResult{}, fmt.Errorf("%d arguments were expected and %d were obtained", len(actionArgs), len(parentAction.Args))
 	}
@@ -81,36 +81,10 @@ func action(previousResult *shared.ChainedResult, parentAction *data.UserAction,
 		return false, &shared.ChainedResult{}, shared.ErrWrongUVFormat
 	}
 
-	variableType := types.GetType(variableContent)
-
-	gv := &uservariables.GlobalVariable{
-		Name:    variableName,
-		Content: variableContent,
-		Type:    variableType,
-		RWMutex: &sync.RWMutex{},
-	}
-	err = gv.WriteToFile()
+	gv, err := uservariables.SetGlobalVariable(variableName, variableContent)
 	if err != nil {
 		return false, &shared.ChainedResult{}, err
 	}
 
-	var varExists bool
-	var index int
-	for i, variable := range *uservariables.GlobalVariablesSlice {
-		if variable.Name == variableName {
-			varExists = true
-			index = i
-		}
-	}
-	if varExists {
-		// If the variable already exists, replace it.
-		(*uservariables.GlobalVariablesSlice)[index] = *gv
-	} else {
-		// If the variable does not exists, add it.
-		// We can't append directly to the GVS because the function append doesn't returns a pointer.
-		newGVS := append(*uservariables.GlobalVariablesSlice, *gv)
-		uservariables.GlobalVariablesSlice = &newGVS
-	}
-
-	return true, &shared.ChainedResult{Result: variableContent, ResultType: variableType}, nil
+	return true, &shared.ChainedResult{Result: variableContent, ResultType: gv.Type}, nil
 }
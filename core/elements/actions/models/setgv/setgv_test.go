@@ -1,6 +1,7 @@
 package setgv
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"testing"
@@ -184,7 +185,7 @@ func (suite *ActionTestSuite) TestSetGlobalVariable() {
 			Args:                  arg,
 		}
 
-		r, cr, err := SetGlobalVariable.Run(&shared.ChainedResult{}, &ua, suite.TaskID)
+		r, cr, err := SetGlobalVariable.Run(context.Background(), &shared.ChainedResult{}, &ua, suite.TaskID)
 		assert.Equalf(true, r, "argument %d should return a true result", i)
 		assert.NotEmptyf(*cr, "argument %d should return a not empty chained result", i)
 		assert.NoErrorf(err, "argument %d shouldn't return an error", i)
@@ -211,7 +212,7 @@ func (suite *ActionTestSuite) TestSetGlobalVariable() {
 			Args:                  arg,
 		}
 
-		r, cr, err := SetGlobalVariable.Run(&shared.ChainedResult{}, &ua, suite.TaskID)
+		r, cr, err := SetGlobalVariable.Run(context.Background(), &shared.ChainedResult{}, &ua, suite.TaskID)
 		assert.Equalf(false, r, "argument %d should return a false result", i)
 		assert.Emptyf(*cr, "argument %d should return an empty chained result", i)
 		assert.Errorf(err, "argument %d should return an error", i)
@@ -1,10 +1,10 @@
 package setlv
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 	"strings"
-	"sync"
 
 	"github.com/Pegasus8/piworker/core/data"
 	"github.com/Pegasus8/piworker/core/elements/actions/shared"
@@ -44,7 +44,7 @@ var SetLocalVariable = shared.Action{
 	ReturnedChainResultType:        types.Any,
 }
 
-func action(previousResult *shared.ChainedResult, parentAction *data.UserAction, parentTaskID string) (result bool, chainedResult *shared.ChainedResult, err error) {
+func action(ctx context.Context, previousResult *shared.ChainedResult, parentAction *data.UserAction, parentTaskID string) (result bool, chainedResult *shared.ChainedResult, err error) {
 	if len(parentAction.Args) != len(actionArgs) {
 		return false, &shared.ChainedResult{}, fmt.Errorf("%d arguments were expected and %d were obtained", len(actionArgs), len(parentAction.Args))
 	}
@@ -85,37 +85,10 @@ func action(previousResult *shared.ChainedResult, parentAction *data.UserAction,
 		return false, &shared.ChainedResult{}, shared.ErrWrongUVFormat
 	}
 
-	variableType := types.GetType(variableContent)
-
-	lv := &uservariables.LocalVariable{
-		Name:         variableName,
-		Content:      variableContent,
-		Type:         variableType,
-		ParentTaskID: parentTaskID,
-		RWMutex:      &sync.RWMutex{},
-	}
-	err = lv.WriteToFile()
+	lv, err := uservariables.SetLocalVariable(variableName, variableContent, parentTaskID)
 	if err != nil {
 		return false, &shared.ChainedResult{}, err
 	}
 
-	var varExists bool
-	var index int
-	for i, variable := range *uservariables.LocalVariablesSlice {
-		if variable.Name == variableName {
-			varExists = true
-			index = i
-		}
-	}
-	if varExists {
-		// If the variable already exists, replace it.
-		(*uservariables.LocalVariablesSlice)[index] = *lv
-	} else {
-		// If the variable does not exists, add it.
-		// We can't append directly to the LVS because the function append doesn't returns a pointer.
-		newLVS := append(*uservariables.LocalVariablesSlice, *lv)
-		uservariables.LocalVariablesSlice = &newLVS
-	}
-
-	return true, &shared.ChainedResult{Result: variableContent, ResultType: variableType}, nil
+	return true, &shared.ChainedResult{Result: variableContent, ResultType: lv.Type}, nil
 }
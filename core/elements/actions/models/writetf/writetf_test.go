@@ -1,6 +1,7 @@
 package writetf
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"testing"
@@ -257,7 +258,7 @@ func (suite *ActionTestSuite) TestWriteTextFile() {
 			Args:                  arg,
 		}
 
-		r, cr, err := WriteTextFile.Run(&shared.ChainedResult{}, &ua, suite.TaskID)
+		r, cr, err := WriteTextFile.Run(context.Background(), &shared.ChainedResult{}, &ua, suite.TaskID)
 		assert.Equalf(true, r, "argument %d should return a true result", i)
 		assert.NotEmptyf(*cr, "argument %d should return a not empty chained result", i)
 		assert.NoErrorf(err, "argument %d shouldn't return an error", i)
@@ -282,7 +283,7 @@ func (suite *ActionTestSuite) TestWriteTextFile() {
 			Args:                  arg,
 		}
 
-		r, cr, err := WriteTextFile.Run(&shared.ChainedResult{}, &ua, suite.TaskID)
+		r, cr, err := WriteTextFile.Run(context.Background(), &shared.ChainedResult{}, &ua, suite.TaskID)
 		assert.Equalf(false, r, "argument %d should return a false result", i)
 		assert.Emptyf(*cr, "argument %d should return an empty chained result", i)
 		assert.Errorf(err, "argument %d should return an error", i)
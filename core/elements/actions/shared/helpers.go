@@ -7,7 +7,11 @@ import (
 // HandleCR checks if the usage of the `ChainedResult` it's enabled and, if it is,
 // the content of the argument chained will be replaced with the content of the `ChainedResult`.
 func HandleCR(userAction *data.UserAction, actionArgs []Arg, cr *ChainedResult) error {
-	if userAction.Order == 0 {
+	// The first action receives a chained result from the activation of a push trigger that provides one (like the
+	// path of a file, the body of a webhook, a message of MQTT or the result of a chained task), or from a failure of
+	// the task when it's the first one of the actions executed on failure (with the error as result). Otherwise there
+	// is nothing to chain.
+	if userAction.Order == 0 && cr.Result == "" {
 		return nil
 	}
 
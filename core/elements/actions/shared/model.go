@@ -1,20 +1,24 @@
 package shared
 
 import (
+	"context"
+
 	"github.com/Pegasus8/piworker/core/data"
 	"github.com/Pegasus8/piworker/core/types"
 )
 
 // Action represents an action of a task. Each task can have multiple actions
 // and they will be executed according to the order given by the user (see data.UserAction.Order).
+// The context received by Run is canceled when the execution exceeds its timeout or when the task is
+// stopped, long-running actions must watch it and return as soon as possible.
 type Action struct {
-	ID                             string                                                                                                                `json:"ID"`
-	Name                           string                                                                                                                `json:"name"`
-	Description                    string                                                                                                                `json:"description"`
-	Run                            func(previousResult *ChainedResult, parentAction *data.UserAction, parentTaskID string) (bool, *ChainedResult, error) `json:"-"`
-	ReturnedChainResultDescription string                                                                                                                `json:"returnedChainResultDescription"`
-	ReturnedChainResultType        types.PWType                                                                                                          `json:"returnedChainResultType"`
-	Args                           []Arg                                                                                                                 `json:"args"`
+	ID                             string                                                                                                                                     `json:"ID"`
+	Name                           string                                                                                                                                     `json:"name"`
+	Description                    string                                                                                                                                     `json:"description"`
+	Run                            func(ctx context.Context, previousResult *ChainedResult, parentAction *data.UserAction, parentTaskID string) (bool, *ChainedResult, error) `json:"-"`
+	ReturnedChainResultDescription string                                                                                                                                     `json:"returnedChainResultDescription"`
+	ReturnedChainResultType        types.PWType                                                                                                                               `json:"returnedChainResultType"`
+	Args                           []Arg                                                                                                                                      `json:"args"`
 }
 
 // Arg is the struct that defines each argument received by an Action.
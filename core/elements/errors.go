@@ -0,0 +1,9 @@
+package elements
+
+import "errors"
+
+// ErrDuplicatedID is the error used when an element is registered with the ID of another one already registered.
+var ErrDuplicatedID = errors.New("the ID is already registered")
+
+// ErrInvalidElement is the error used when an element (trigger or action) is incorrectly defined.
+var ErrInvalidElement = errors.New("invalid element")
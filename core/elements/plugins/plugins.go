@@ -0,0 +1,256 @@
+// Package plugins implements the triggers and the actions provided by external executables (written in any language),
+// placed on the directory of plugins.
+//
+// Each executable implements one element. On the handshake, it's executed with the argument `describe` and it must
+// print its description (see `Description`) as JSON. Then, on each execution of the element, it's executed with the
+// argument `run`, receiving a `Request` as JSON through the standard input, and it must print a `Response` as JSON.
+// Each execution is a new process, so a crash of the plugin only fails that execution, and the process is killed if
+// it exceeds its timeout. Only polled triggers can be implemented, their state between executions (identified by
+// `Request.StateKey`) must be kept by the plugin itself.
+package plugins
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Pegasus8/piworker/core/data"
+	"github.com/Pegasus8/piworker/core/elements"
+	actions "github.com/Pegasus8/piworker/core/elements/actions/shared"
+	triggers "github.com/Pegasus8/piworker/core/elements/triggers/shared"
+	"github.com/Pegasus8/piworker/utilities/processes"
+
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultTimeout is the timeout of each execution of a plugin used when none is configured.
+const DefaultTimeout = 10 * time.Second
+
+// maxOutput limits the output of the plugins kept in memory, the rest is discarded.
+const maxOutput = 1 << 20
+
+// Load registers on `registry` the elements implemented by the executables of `dir`. The plugins that fail on the
+// handshake or whose elements can't be registered are skipped, logging the reason. If the directory doesn't exist
+// there is nothing to load. `timeout` limits the duration of each execution of the plugins, `DefaultTimeout` is used
+// if zero.
+func Load(dir string, timeout time.Duration, registry *elements.Registry) (loaded int, err error) {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+
+		return 0, err
+	}
+
+	for _, f := range files {
+		// Only the executables are plugins, the rest of the files (for example, their configurations) are ignored.
+		if !f.Mode().IsRegular() || f.Mode()&0111 == 0 || strings.HasPrefix(f.Name(), ".") {
+			continue
+		}
+
+		path, err := filepath.Abs(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return loaded, err
+		}
+
+		p := &plugin{path: path, timeout: timeout}
+		err = p.register(registry)
+		if err != nil {
+			log.Error().Err(err).Str("plugin", path).Msg("Error when trying to load the plugin, skipping it")
+			continue
+		}
+
+		log.Info().
+			Str("plugin", path).
+			Str("kind", string(p.description.Kind)).
+			Str("ID", p.description.ID).
+			Msg("Plugin loaded")
+		loaded++
+	}
+
+	return loaded, nil
+}
+
+// plugin is an executable that implements an element.
+type plugin struct {
+	path        string
+	timeout     time.Duration
+	description Description
+}
+
+// register obtains the description of the plugin and registers its element.
+func (p *plugin) register(registry *elements.Registry) error {
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+
+	output, err := p.exec(ctx, describeCommand, nil)
+	if err != nil {
+		return fmt.Errorf("handshake: %w", err)
+	}
+
+	err = json.Unmarshal(output, &p.description)
+	if err != nil {
+		return fmt.Errorf("handshake: invalid description: %w", err)
+	}
+
+	if p.description.Protocol != protocolVersion {
+		return fmt.Errorf("handshake: unsupported protocol version %d, expected %d", p.description.Protocol,
+			protocolVersion)
+	}
+
+	switch p.description.Kind {
+	case KindTrigger:
+		return registry.RegisterTrigger(p.trigger())
+	case KindAction:
+		return registry.RegisterAction(p.action())
+	default:
+		return fmt.Errorf("handshake: unrecognized kind '%s'", p.description.Kind)
+	}
+}
+
+// trigger returns the trigger implemented by the plugin.
+func (p *plugin) trigger() triggers.Trigger {
+	args := make([]triggers.Arg, len(p.description.Args))
+	for i, a := range p.description.Args {
+		args[i] = triggers.Arg{ID: a.ID, Name: a.Name, Description: a.Description, ContentType: a.ContentType}
+	}
+
+	return triggers.Trigger{
+		ID:          p.description.ID,
+		Name:        p.description.Name,
+		Description: p.description.Description,
+		Args:        args,
+		Run: func(args *[]data.UserArg, stateKey string, _ time.Time) (bool, error) {
+			// The triggers don't receive the ID of the task, only the key of their state.
+			r, err := p.run(context.Background(), Request{Args: *args, StateKey: stateKey})
+			if err != nil {
+				return false, err
+			}
+
+			return r.Activated, nil
+		},
+	}
+}
+
+// action returns the action implemented by the plugin.
+func (p *plugin) action() actions.Action {
+	args := make([]actions.Arg, len(p.description.Args))
+	for i, a := range p.description.Args {
+		args[i] = actions.Arg{ID: a.ID, Name: a.Name, Description: a.Description, ContentType: a.ContentType}
+	}
+
+	return actions.Action{
+		ID:                             p.description.ID,
+		Name:                           p.description.Name,
+		Description:                    p.description.Description,
+		ReturnedChainResultDescription: p.description.ReturnedChainResultDescription,
+		ReturnedChainResultType:        p.description.ReturnedChainResultType,
+		Args:                           args,
+		Run: func(ctx context.Context, previousResult *actions.ChainedResult, parentAction *data.UserAction, parentTaskID string) (bool, *actions.ChainedResult, error) {
+			err := actions.HandleCR(parentAction, args, previousResult)
+			if err != nil {
+				return false, &actions.ChainedResult{}, err
+			}
+
+			r, err := p.run(ctx, Request{
+				TaskID: parentTaskID,
+				Args:   parentAction.Args,
+				PreviousResult: &ChainedResult{
+					Result:     previousResult.Result,
+					ResultType: previousResult.ResultType,
+				},
+			})
+			if err != nil {
+				return false, &actions.ChainedResult{}, err
+			}
+
+			return r.Success, &actions.ChainedResult{Result: r.Result, ResultType: r.ResultType}, nil
+		},
+	}
+}
+
+// run executes the element of the plugin with the given request. An error is returned if the plugin fails, exceeds
+// its timeout or reports an error on its response.
+func (p *plugin) run(ctx context.Context, request Request) (*Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	input, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := p.exec(ctx, runCommand, input)
+	if err != nil {
+		return nil, err
+	}
+
+	var r Response
+	err = json.Unmarshal(output, &r)
+	if err != nil {
+		return nil, fmt.Errorf("the plugin '%s' returned an invalid response: %w", p.description.ID, err)
+	}
+
+	if r.Error != "" {
+		return nil, errors.New(r.Error)
+	}
+
+	return &r, nil
+}
+
+// exec executes the plugin with the given command, writing `input` on its standard input, and returns its standard
+// output. The process (and the ones started by it) is killed if `ctx` is done before it finishes.
+func (p *plugin) exec(ctx context.Context, command string, input []byte) ([]byte, error) {
+	var stdout, stderr limitedBuffer
+
+	cmd := processes.CommandContext(ctx, p.path, command)
+	cmd.Dir = filepath.Dir(p.path)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		if ctx.Err() != nil {
+			// Report the cause of the interruption instead of the signal that killed the process.
+			return nil, ctx.Err()
+		}
+
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+
+		return nil, err
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// limitedBuffer is a buffer that discards the data written after `maxOutput` bytes.
+type limitedBuffer struct {
+	bytes.Buffer
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := maxOutput - b.Len(); room < len(p) {
+		if room > 0 {
+			b.Buffer.Write(p[:room])
+		}
+
+		return len(p), nil
+	}
+
+	return b.Buffer.Write(p)
+}
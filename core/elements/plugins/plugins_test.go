@@ -0,0 +1,166 @@
+package plugins
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Pegasus8/piworker/core/data"
+	"github.com/Pegasus8/piworker/core/elements"
+	actions "github.com/Pegasus8/piworker/core/elements/actions/shared"
+	"github.com/Pegasus8/piworker/core/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// script returns a shell script that prints `description` on the handshake and executes `run` on each execution.
+func script(description, run string) string {
+	return "#!/bin/sh\n" +
+		"if [ \"$1\" = describe ]; then\n" +
+		"  echo '" + description + "'\n" +
+		"  exit 0\n" +
+		"fi\n" +
+		run + "\n"
+}
+
+func TestPlugins(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "plugins")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	plugins := map[string]string{
+		// Activated when its argument is "yes".
+		"trigger.sh": script(`{"protocol": 1, "kind": "trigger", "ID": "PT1", "name": "Plugin trigger", `+
+			`"args": [{"ID": "PT1-1", "name": "Answer", "contentType": "text"}]}`,
+			`input=$(cat)
+case "$input" in
+  *'"content":"yes"'*) echo '{"activated": true}' ;;
+  *) echo '{"activated": false}' ;;
+esac`),
+		// Stores the request received and returns a fixed result.
+		"action.sh": script(`{"protocol": 1, "kind": "action", "ID": "PA1", "name": "Plugin action", `+
+			`"returnedChainResultType": "text", "args": [{"ID": "PA1-1", "name": "Path", "contentType": "path"}]}`,
+			`cat > request.json
+echo '{"success": true, "result": "done", "resultType": "text"}'`),
+		"crash.sh": script(`{"protocol": 1, "kind": "action", "ID": "PA2", "name": "Crash"}`,
+			`echo "boom" >&2
+exit 3`),
+		"slow.sh": script(`{"protocol": 1, "kind": "action", "ID": "PA3", "name": "Slow"}`,
+			`exec sleep 5`),
+		// The command started by the plugin keeps its output open after the plugin is killed.
+		"pipeline.sh": script(`{"protocol": 1, "kind": "action", "ID": "PA8", "name": "Pipeline"}`,
+			`sleep 10 | cat`),
+		"failure.sh": script(`{"protocol": 1, "kind": "action", "ID": "PA4", "name": "Failure"}`,
+			`echo '{"error": "the file does not exist"}'`),
+		// The following plugins must be rejected.
+		"garbage.sh":    script(`not json`, `exit 0`),
+		"version.sh":    script(`{"protocol": 2, "kind": "action", "ID": "PA5", "name": "Future"}`, `exit 0`),
+		"kind.sh":       script(`{"protocol": 1, "kind": "condition", "ID": "PA6", "name": "Kind"}`, `exit 0`),
+		"duplicated.sh": script(`{"protocol": 1, "kind": "action", "ID": "A1", "name": "Duplicated"}`, `exit 0`),
+		"type.sh": script(`{"protocol": 1, "kind": "action", "ID": "PA7", "name": "Type", `+
+			`"args": [{"ID": "PA7-1", "contentType": "random-type"}]}`, `exit 0`),
+	}
+	for name, content := range plugins {
+		err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0755)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	// The files that aren't executable are ignored.
+	err = ioutil.WriteFile(filepath.Join(dir, "README.md"), []byte("# Plugins"), 0644)
+	if err != nil {
+		panic(err)
+	}
+
+	registry := elements.NewRegistry()
+	assert.NoError(registry.RegisterAction(actions.Action{ID: "A1", Run: func(_ context.Context, _ *actions.ChainedResult, _ *data.UserAction, _ string) (bool, *actions.ChainedResult, error) {
+		return true, &actions.ChainedResult{}, nil
+	}}))
+
+	loaded, err := Load(dir, 500*time.Millisecond, registry)
+	assert.NoError(err)
+	assert.Equal(6, loaded, "only the valid plugins must be loaded")
+	assert.Len(registry.Triggers(), 1)
+	assert.Len(registry.Actions(), 6)
+
+	// Trigger.
+	trigger, found := registry.Trigger("PT1")
+	if assert.True(found, "the trigger of the plugin must be registered") {
+		assert.Equal("Plugin trigger", trigger.Name)
+		assert.Equal(types.Text, trigger.Args[0].ContentType)
+
+		r, err := trigger.Run(&[]data.UserArg{{ID: "PT1-1", Content: "yes"}}, "task-1", time.Now())
+		assert.NoError(err)
+		assert.True(r, "the trigger must be activated")
+
+		r, err = trigger.Run(&[]data.UserArg{{ID: "PT1-1", Content: "no"}}, "task-1", time.Now())
+		assert.NoError(err)
+		assert.False(r, "the trigger must not be activated")
+	}
+
+	// Action.
+	action, found := registry.Action("PA1")
+	if assert.True(found, "the action of the plugin must be registered") {
+		userAction := &data.UserAction{ID: "PA1", Args: []data.UserArg{{ID: "PA1-1", Content: "/tmp"}}}
+		ok, cr, err := action.Run(context.Background(), &actions.ChainedResult{Result: "previous", ResultType: types.Text},
+			userAction, "task-1")
+		assert.NoError(err)
+		assert.True(ok)
+		assert.Equal(&actions.ChainedResult{Result: "done", ResultType: types.Text}, cr)
+
+		content, err := ioutil.ReadFile(filepath.Join(dir, "request.json"))
+		if assert.NoError(err, "the plugin must be executed on its directory") {
+			var request Request
+			assert.NoError(json.Unmarshal(content, &request))
+			assert.Equal("task-1", request.TaskID)
+			assert.Equal(userAction.Args, request.Args)
+			assert.Equal(&ChainedResult{Result: "previous", ResultType: types.Text}, request.PreviousResult)
+		}
+	}
+
+	run := func(ctx context.Context, id string) error {
+		action, found := registry.Action(id)
+		if !assert.Truef(found, "the action '%s' must be registered", id) {
+			return nil
+		}
+
+		_, _, err := action.Run(ctx, &actions.ChainedResult{}, &data.UserAction{ID: id}, "task-1")
+		return err
+	}
+
+	err = run(context.Background(), "PA2")
+	if assert.Error(err, "a crash of the plugin must fail the execution") {
+		assert.Contains(err.Error(), "boom", "the error output of the plugin must be reported")
+	}
+
+	start := time.Now()
+	assert.Equal(context.DeadlineExceeded, run(context.Background(), "PA3"), "the plugin must be killed on timeout")
+	assert.True(time.Since(start) < 3*time.Second, "the plugin must not be waited after its timeout")
+
+	start = time.Now()
+	assert.Equal(context.DeadlineExceeded, run(context.Background(), "PA8"), "the plugin must be killed on timeout")
+	assert.True(time.Since(start) < 3*time.Second, "the processes started by the plugin must be killed too")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(context.Canceled, run(ctx, "PA3"), "the plugin must be killed when the execution is canceled")
+
+	err = run(context.Background(), "PA4")
+	if assert.Error(err) {
+		assert.Equal("the file does not exist", err.Error(), "the error reported by the plugin must be returned")
+	}
+
+	// A directory that doesn't exist has no plugins.
+	loaded, err = Load(filepath.Join(dir, "nothing"), 0, registry)
+	assert.NoError(err)
+	assert.Zero(loaded)
+}
@@ -0,0 +1,77 @@
+package plugins
+
+import (
+	"github.com/Pegasus8/piworker/core/data"
+	"github.com/Pegasus8/piworker/core/types"
+)
+
+// protocolVersion is the version of the protocol implemented by PiWorker, which must be the one declared by the
+// plugins on their description.
+const protocolVersion = 1
+
+const (
+	// describeCommand is the argument given to the executable to obtain its description (the handshake).
+	describeCommand = "describe"
+	// runCommand is the argument given to the executable to run the element.
+	runCommand = "run"
+)
+
+// Kind is the kind of element implemented by a plugin.
+type Kind string
+
+const (
+	// KindTrigger is the kind of the plugins that implement a (polled) trigger.
+	KindTrigger Kind = "trigger"
+	// KindAction is the kind of the plugins that implement an action.
+	KindAction Kind = "action"
+)
+
+// Description is the JSON printed by a plugin on the handshake, when it's executed with the argument `describe`.
+type Description struct {
+	Protocol    int    `json:"protocol"`
+	Kind        Kind   `json:"kind"`
+	ID          string `json:"ID"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Args        []Arg  `json:"args"`
+	// Only used by actions.
+	ReturnedChainResultDescription string       `json:"returnedChainResultDescription"`
+	ReturnedChainResultType        types.PWType `json:"returnedChainResultType"`
+}
+
+// Arg is the definition of an argument received by the element of a plugin.
+type Arg struct {
+	ID          string       `json:"ID"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	ContentType types.PWType `json:"contentType"`
+}
+
+// Request is the JSON received through the standard input by a plugin executed with the argument `run`.
+type Request struct {
+	TaskID string         `json:"taskID"`
+	Args   []data.UserArg `json:"args"`
+	// StateKey identifies the trigger to keep its state between executions. Only used by triggers.
+	StateKey string `json:"stateKey,omitempty"`
+	// PreviousResult is the result of the previous action. Only used by actions.
+	PreviousResult *ChainedResult `json:"previousResult,omitempty"`
+}
+
+// ChainedResult is the result given by an action to the next one.
+type ChainedResult struct {
+	Result     string       `json:"result"`
+	ResultType types.PWType `json:"resultType"`
+}
+
+// Response is the JSON printed through the standard output by a plugin executed with the argument `run`. A non empty
+// `Error` means that the execution has failed.
+type Response struct {
+	Error string `json:"error"`
+	// Activated indicates if the trigger has been activated. Only used by triggers.
+	Activated bool `json:"activated"`
+	// Success indicates if the action has been executed correctly, and `Result` and `ResultType` are its chained
+	// result. Only used by actions.
+	Success    bool         `json:"success"`
+	Result     string       `json:"result"`
+	ResultType types.PWType `json:"resultType"`
+}
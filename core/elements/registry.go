@@ -0,0 +1,235 @@
+package elements
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/Pegasus8/piworker/core/configs"
+	actionsList "github.com/Pegasus8/piworker/core/elements/actions/models"
+	actions "github.com/Pegasus8/piworker/core/elements/actions/shared"
+	triggersList "github.com/Pegasus8/piworker/core/elements/triggers/models"
+	"github.com/Pegasus8/piworker/core/elements/triggers/models/fswatch"
+	"github.com/Pegasus8/piworker/core/elements/triggers/models/mqtt"
+	"github.com/Pegasus8/piworker/core/elements/triggers/models/taskchain"
+	"github.com/Pegasus8/piworker/core/elements/triggers/models/webhook"
+	triggers "github.com/Pegasus8/piworker/core/elements/triggers/shared"
+	"github.com/Pegasus8/piworker/core/types"
+)
+
+// Registry keeps the triggers and the actions available to the tasks. The elements are kept in the order of their
+// registration, which is the order shown on the WebUI.
+type Registry struct {
+	mutex    sync.RWMutex
+	triggers []triggers.Trigger
+	actions  []actions.Action
+
+	// chains and hooks keep the subscriptions of the triggers `taskchain` and `webhook`, nil if they aren't
+	// registered.
+	chains *taskchain.Chains
+	hooks  *webhook.Hooks
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// NewDefaultRegistry returns the registry used by PiWorker, which initially contains the triggers and the actions
+// included on it. The MQTT brokers that can be used by the tasks are the ones of `brokers`. The custom elements must
+// be registered at startup, before starting the engine.
+func NewDefaultRegistry(brokers []configs.MQTTBroker) *Registry {
+	r := &Registry{
+		chains: taskchain.New(),
+		hooks:  webhook.New(),
+	}
+
+	for _, trigger := range triggersList.Triggers(r.chains, fswatch.New(), r.hooks, mqtt.New(brokers)) {
+		if err := r.RegisterTrigger(trigger); err != nil {
+			panic(err)
+		}
+	}
+
+	for _, action := range actionsList.ACTIONS {
+		if err := r.RegisterAction(action); err != nil {
+			panic(err)
+		}
+	}
+
+	return r
+}
+
+// Chains returns the subscriptions of the trigger `taskchain`, nil if the registry doesn't include it.
+func (r *Registry) Chains() *taskchain.Chains {
+	return r.chains
+}
+
+// Hooks returns the subscriptions of the trigger `webhook`, nil if the registry doesn't include it.
+func (r *Registry) Hooks() *webhook.Hooks {
+	return r.hooks
+}
+
+// RegisterTrigger adds the trigger to the registry. An error is returned if its definition is wrong or if its ID is
+// already in use by another trigger.
+func (r *Registry) RegisterTrigger(trigger triggers.Trigger) error {
+	err := validateTrigger(&trigger)
+	if err != nil {
+		return fmt.Errorf("%w: trigger '%s': %s", ErrInvalidElement, trigger.ID, err.Error())
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for i := range r.triggers {
+		if r.triggers[i].ID == trigger.ID {
+			return fmt.Errorf("%w: trigger '%s'", ErrDuplicatedID, trigger.ID)
+		}
+	}
+
+	r.triggers = append(r.triggers, trigger)
+
+	return nil
+}
+
+// RegisterAction adds the action to the registry. An error is returned if its definition is wrong or if its ID is
+// already in use by another action.
+func (r *Registry) RegisterAction(action actions.Action) error {
+	err := validateAction(&action)
+	if err != nil {
+		return fmt.Errorf("%w: action '%s': %s", ErrInvalidElement, action.ID, err.Error())
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for i := range r.actions {
+		if r.actions[i].ID == action.ID {
+			return fmt.Errorf("%w: action '%s'", ErrDuplicatedID, action.ID)
+		}
+	}
+
+	r.actions = append(r.actions, action)
+
+	return nil
+}
+
+// Trigger returns the trigger with the given ID.
+func (r *Registry) Trigger(id string) (trigger *triggers.Trigger, found bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for i := range r.triggers {
+		if r.triggers[i].ID == id {
+			t := r.triggers[i]
+			return &t, true
+		}
+	}
+
+	return &triggers.Trigger{}, false
+}
+
+// Action returns the action with the given ID.
+func (r *Registry) Action(id string) (action *actions.Action, found bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for i := range r.actions {
+		if r.actions[i].ID == id {
+			a := r.actions[i]
+			return &a, true
+		}
+	}
+
+	return &actions.Action{}, false
+}
+
+// Triggers returns all the triggers registered.
+func (r *Registry) Triggers() []triggers.Trigger {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return append([]triggers.Trigger(nil), r.triggers...)
+}
+
+// Actions returns all the actions registered.
+func (r *Registry) Actions() []actions.Action {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return append([]actions.Action(nil), r.actions...)
+}
+
+// validateTrigger checks that the trigger can be used by the engine: the polled triggers must implement `Run`, and
+// the push ones `Subscribe` and `Unsubscribe`.
+func validateTrigger(trigger *triggers.Trigger) error {
+	if trigger.ID == "" {
+		return fmt.Errorf("the ID is empty")
+	}
+
+	if trigger.IsPush() {
+		if trigger.Unsubscribe == nil {
+			return fmt.Errorf("a push trigger must implement Unsubscribe")
+		}
+		if trigger.Run != nil {
+			return fmt.Errorf("a push trigger can't implement Run")
+		}
+	} else if trigger.Run == nil {
+		return fmt.Errorf("the trigger must implement Run or Subscribe")
+	}
+
+	args := make([]arg, len(trigger.Args))
+	for i, a := range trigger.Args {
+		args[i] = arg{a.ID, a.ContentType}
+	}
+
+	return validateArgs(args)
+}
+
+// validateAction checks that the action can be executed and that the type of its result is valid.
+func validateAction(action *actions.Action) error {
+	if action.ID == "" {
+		return fmt.Errorf("the ID is empty")
+	}
+
+	if action.Run == nil {
+		return fmt.Errorf("the action must implement Run")
+	}
+
+	if action.ReturnedChainResultType != "" && !action.ReturnedChainResultType.IsValid() {
+		return fmt.Errorf("invalid type of the chained result '%s'", action.ReturnedChainResultType)
+	}
+
+	args := make([]arg, len(action.Args))
+	for i, a := range action.Args {
+		args[i] = arg{a.ID, a.ContentType}
+	}
+
+	return validateArgs(args)
+}
+
+// arg is the schema of an argument, common to triggers and actions.
+type arg struct {
+	id          string
+	contentType types.PWType
+}
+
+// validateArgs checks that the IDs of the arguments are unique and their types valid.
+func validateArgs(args []arg) error {
+	ids := make(map[string]bool)
+
+	for i, a := range args {
+		if a.id == "" {
+			return fmt.Errorf("arg %d: the ID is empty", i)
+		}
+
+		if ids[a.id] {
+			return fmt.Errorf("arg %d: the ID '%s' is duplicated", i, a.id)
+		}
+		ids[a.id] = true
+
+		if !a.contentType.IsValid() {
+			return fmt.Errorf("arg %d (ID: %s): invalid type '%s'", i, a.id, a.contentType)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package elements
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Pegasus8/piworker/core/data"
+	actionsList "github.com/Pegasus8/piworker/core/elements/actions/models"
+	actions "github.com/Pegasus8/piworker/core/elements/actions/shared"
+	triggersList "github.com/Pegasus8/piworker/core/elements/triggers/models"
+	"github.com/Pegasus8/piworker/core/elements/triggers/models/fswatch"
+	"github.com/Pegasus8/piworker/core/elements/triggers/models/mqtt"
+	"github.com/Pegasus8/piworker/core/elements/triggers/models/taskchain"
+	"github.com/Pegasus8/piworker/core/elements/triggers/models/webhook"
+	triggers "github.com/Pegasus8/piworker/core/elements/triggers/shared"
+	"github.com/Pegasus8/piworker/core/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultRegistry(t *testing.T) {
+	assert := assert.New(t)
+	r := NewDefaultRegistry(nil)
+
+	included := triggersList.Triggers(taskchain.New(), fswatch.New(), webhook.New(), mqtt.New(nil))
+	assert.Len(r.Triggers(), len(included), "the triggers of PiWorker must be registered")
+	assert.Len(r.Actions(), len(actionsList.ACTIONS), "the actions of PiWorker must be registered")
+
+	for _, trigger := range included {
+		_, found := r.Trigger(trigger.ID)
+		assert.Truef(found, "the trigger '%s' must be found", trigger.ID)
+	}
+
+	assert.NotNil(r.Chains(), "the subscriptions of the task chains must be kept by the registry")
+	assert.NotNil(r.Hooks(), "the subscriptions of the webhooks must be kept by the registry")
+	assert.NotSame(r.Chains(), NewDefaultRegistry(nil).Chains(), "each registry must have its own subscriptions")
+
+	assert.Nil(NewRegistry().Chains(), "an empty registry doesn't include the task chains")
+}
+
+func TestRegistry(t *testing.T) {
+	assert := assert.New(t)
+	r := NewRegistry()
+
+	run := func(_ *[]data.UserArg, _ string, _ time.Time) (bool, error) {
+		return true, nil
+	}
+	runAction := func(_ context.Context, _ *actions.ChainedResult, _ *data.UserAction, _ string) (bool, *actions.ChainedResult, error) {
+		return true, &actions.ChainedResult{}, nil
+	}
+
+	polled := triggers.Trigger{
+		ID:   "CT1",
+		Run:  run,
+		Args: []triggers.Arg{{ID: "CT1-1", ContentType: types.Int}},
+	}
+	assert.NoError(r.RegisterTrigger(polled))
+
+	push := triggers.Trigger{
+		ID: "CT2",
+		Subscribe: func(_ *[]data.UserArg, _ string) (<-chan triggers.Activation, error) {
+			return nil, nil
+		},
+		Unsubscribe: func(_ string) {},
+	}
+	assert.NoError(r.RegisterTrigger(push))
+
+	action := actions.Action{
+		ID:                      "CA1",
+		Run:                     runAction,
+		ReturnedChainResultType: types.Text,
+		Args:                    []actions.Arg{{ID: "CA1-1", ContentType: types.Path}},
+	}
+	assert.NoError(r.RegisterAction(action))
+
+	// The IDs of triggers and actions don't collide between them.
+	assert.NoError(r.RegisterAction(actions.Action{ID: polled.ID, Run: runAction}))
+
+	err := r.RegisterTrigger(triggers.Trigger{ID: polled.ID, Run: run})
+	assert.True(errors.Is(err, ErrDuplicatedID), "a trigger can't be registered twice")
+	err = r.RegisterAction(actions.Action{ID: action.ID, Run: runAction})
+	assert.True(errors.Is(err, ErrDuplicatedID), "an action can't be registered twice")
+
+	invalidTriggers := []triggers.Trigger{
+		{Run: run},
+		{ID: "X1"},
+		{ID: "X2", Subscribe: push.Subscribe},
+		{ID: "X3", Run: run, Subscribe: push.Subscribe, Unsubscribe: push.Unsubscribe},
+		{ID: "X4", Run: run, Args: []triggers.Arg{{ContentType: types.Text}}},
+		{ID: "X5", Run: run, Args: []triggers.Arg{{ID: "X5-1", ContentType: types.Text}, {ID: "X5-1", ContentType: types.Int}}},
+		{ID: "X6", Run: run, Args: []triggers.Arg{{ID: "X6-1", ContentType: "random-type"}}},
+	}
+	for i, trigger := range invalidTriggers {
+		err := r.RegisterTrigger(trigger)
+		assert.Truef(errors.Is(err, ErrInvalidElement), "[%d] the trigger must be rejected", i)
+	}
+
+	invalidActions := []actions.Action{
+		{Run: runAction},
+		{ID: "X1"},
+		{ID: "X2", Run: runAction, ReturnedChainResultType: "random-type"},
+		{ID: "X3", Run: runAction, Args: []actions.Arg{{ID: "X3-1"}}},
+	}
+	for i, action := range invalidActions {
+		err := r.RegisterAction(action)
+		assert.Truef(errors.Is(err, ErrInvalidElement), "[%d] the action must be rejected", i)
+	}
+
+	found, ok := r.Trigger(push.ID)
+	if assert.True(ok) {
+		assert.True(found.IsPush())
+	}
+	_, ok = r.Trigger("X1")
+	assert.False(ok, "a rejected trigger must not be registered")
+
+	found2, ok := r.Action(action.ID)
+	if assert.True(ok) {
+		assert.Equal(action.Args, found2.Args)
+	}
+	notFound, ok := r.Action("CA1000")
+	assert.False(ok)
+	assert.Empty(notFound.ID, "an empty action must be returned if it doesn't exist")
+
+	list := r.Triggers()
+	if assert.Len(list, 2) {
+		assert.Equal([]string{polled.ID, push.ID}, []string{list[0].ID, list[1].ID},
+			"the triggers must be kept in the order of their registration")
+	}
+	assert.Len(r.Actions(), 2)
+}
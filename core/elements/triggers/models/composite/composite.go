@@ -0,0 +1,133 @@
+package composite
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Pegasus8/piworker/core/data"
+	"github.com/Pegasus8/piworker/core/elements/triggers/shared"
+	"github.com/Pegasus8/piworker/core/types"
+)
+
+const triggerID = "T6"
+
+// Operator is the boolean operation used to combine the results of the children of a composite trigger.
+type Operator string
+
+const (
+	// And activates the composite trigger only if all its children are activated.
+	And Operator = "AND"
+	// Or activates the composite trigger if at least one of its children is activated.
+	Or Operator = "OR"
+	// Not activates the composite trigger when its only child is not activated.
+	Not Operator = "NOT"
+)
+
+var triggerArgs = []shared.Arg{
+	{
+		ID:   triggerID + "-1",
+		Name: "Operator",
+		Description: "The boolean operator used to combine the children triggers. Must be 'AND', 'OR' or" +
+			" 'NOT' (the last one admits only one child).",
+		ContentType: types.Text,
+	},
+}
+
+// ErrEvaluatedByEngine is the error returned when the composite trigger is run directly. Its children are only
+// known by the engine, which is the one that must evaluate them and combine their results with `Combine`.
+var ErrEvaluatedByEngine = errors.New("the composite trigger must be evaluated by the engine")
+
+// Composite - Trigger
+var Composite = shared.Trigger{
+	ID:   triggerID,
+	Name: "Composite Trigger",
+	Description: "Combines the result of other triggers (its children) using a boolean operator, for example" +
+		" to run a task every 5 minutes but only if the temperature is above 60ºC.",
+	Run:      trigger,
+	Args:     triggerArgs,
+	Validate: validate,
+}
+
+func trigger(_ *[]data.UserArg, _ string, _ time.Time) (bool, error) {
+	return false, ErrEvaluatedByEngine
+}
+
+func validate(args *[]data.UserArg) error {
+	_, err := ParseOperator(args)
+
+	return err
+}
+
+// ParseOperator obtains the operator from the arguments of a composite trigger.
+func ParseOperator(args *[]data.UserArg) (Operator, error) {
+	if len(*args) != len(triggerArgs) {
+		return "", fmt.Errorf("%d arguments were expected and %d were obtained", len(triggerArgs), len(*args))
+	}
+
+	var operator Operator
+
+	for i, arg := range *args {
+		if arg.Content == "" {
+			return "", fmt.Errorf("argument %d (ID: %s) is empty", i, arg.ID)
+		}
+
+		switch arg.ID {
+		case triggerArgs[0].ID:
+			operator = Operator(strings.ToUpper(strings.TrimSpace(arg.Content)))
+		default:
+			return "", shared.ErrUnrecognizedArgID
+		}
+	}
+
+	switch operator {
+	case And, Or, Not:
+		return operator, nil
+	default:
+		return "", fmt.Errorf("unrecognized operator '%s'", operator)
+	}
+}
+
+// CheckChildren checks if the amount of children is admitted by the given operator.
+func CheckChildren(operator Operator, n int) error {
+	if n == 0 {
+		return errors.New("a composite trigger must have at least one child")
+	}
+
+	if operator == Not && n != 1 {
+		return fmt.Errorf("the operator '%s' admits only one child and %d were obtained", Not, n)
+	}
+
+	return nil
+}
+
+// Combine applies the operator to the results of the children.
+func Combine(operator Operator, results []bool) (bool, error) {
+	if err := CheckChildren(operator, len(results)); err != nil {
+		return false, err
+	}
+
+	switch operator {
+	case And:
+		for _, r := range results {
+			if !r {
+				return false, nil
+			}
+		}
+
+		return true, nil
+	case Or:
+		for _, r := range results {
+			if r {
+				return true, nil
+			}
+		}
+
+		return false, nil
+	case Not:
+		return !results[0], nil
+	default:
+		return false, fmt.Errorf("unrecognized operator '%s'", operator)
+	}
+}
@@ -0,0 +1,88 @@
+package composite
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Pegasus8/piworker/core/data"
+	test "github.com/Pegasus8/piworker/utilities/testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComposite(t *testing.T) {
+	taskID := uuid.New().String()
+	assert := assert.New(t)
+
+	test.CheckTFields(t, Composite)
+
+	// The composite trigger can't be run directly.
+	r, err := Composite.Run(&[]data.UserArg{{ID: Composite.Args[0].ID, Content: "AND"}}, taskID, time.Now())
+	assert.False(r, "the trigger must return a false result when it's run directly")
+	assert.EqualError(err, ErrEvaluatedByEngine.Error(), "the returned error is not which should be")
+
+	args := [][]data.UserArg{
+		// [0] -- Correct --
+		{{ID: Composite.Args[0].ID, Content: "and"}},
+		// [1] -- Correct --
+		{{ID: Composite.Args[0].ID, Content: " OR "}},
+		// [2] -- Correct --
+		{{ID: Composite.Args[0].ID, Content: "NOT"}},
+		// [3] -- Incorrect --
+		// Problem: 		Unrecognized operator.
+		{{ID: Composite.Args[0].ID, Content: "XOR"}},
+		// [4] -- Incorrect --
+		// Problem: 		ID of an arg is incorrect.
+		{{ID: Composite.ID + "-5", Content: "AND"}},
+		// [5] -- Incorrect --
+		// Problem: 		There are no arguments (should be one).
+		{},
+		// [6] -- Incorrect --
+		// Problem: 		Content of an argument empty.
+		{{ID: Composite.Args[0].ID, Content: ""}},
+	}
+	expected := []Operator{And, Or, Not}
+
+	for i, arg := range args[:3] {
+		op, err := ParseOperator(&arg)
+		assert.NoErrorf(err, "[arg %d] there should be no errors", i)
+		assert.Equalf(expected[i], op, "[arg %d] wrong operator", i)
+		assert.NoErrorf(Composite.Validate(&arg), "[arg %d] the validation must pass", i)
+	}
+
+	for i, arg := range args[3:] {
+		_, err := ParseOperator(&arg)
+		assert.Errorf(err, "[arg %d] an error must be returned", i)
+		assert.Errorf(Composite.Validate(&arg), "[arg %d] the validation must fail", i)
+	}
+}
+
+func TestCombine(t *testing.T) {
+	assert := assert.New(t)
+
+	cases := []struct {
+		operator Operator
+		results  []bool
+		expected bool
+	}{
+		{And, []bool{true, true}, true},
+		{And, []bool{true, false}, false},
+		{Or, []bool{false, true}, true},
+		{Or, []bool{false, false}, false},
+		{Not, []bool{false}, true},
+		{Not, []bool{true}, false},
+	}
+
+	for i, c := range cases {
+		r, err := Combine(c.operator, c.results)
+		assert.NoErrorf(err, "[case %d] there should be no errors", i)
+		assert.Equalf(c.expected, r, "[case %d] wrong result", i)
+	}
+
+	_, err := Combine(And, []bool{})
+	assert.Error(err, "a composite trigger without children must return an error")
+
+	_, err = Combine(Not, []bool{true, false})
+	assert.Error(err, "the operator NOT with more than one child must return an error")
+}
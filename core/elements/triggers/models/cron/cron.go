@@ -0,0 +1,160 @@
+package cron
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Pegasus8/piworker/core/data"
+	"github.com/Pegasus8/piworker/core/elements/triggers/shared"
+	"github.com/Pegasus8/piworker/core/types"
+
+	"github.com/robfig/cron/v3"
+)
+
+const triggerID = "T5"
+
+var triggerArgs = []shared.Arg{
+	{
+		ID:   triggerID + "-1",
+		Name: "Cron Expression",
+		Description: "Standard cron expression of 5 fields (minute hour day-of-month month day-of-week) or 6" +
+			" fields (with the seconds first). Ranges, steps and lists are supported, as well as macros like" +
+			" '@daily' or '@every 1h30m'. Example: '30 7,19 * * 1-5'.",
+		ContentType: types.Text,
+	},
+	{
+		ID:   triggerID + "-2",
+		Name: "Timezone",
+		Description: "IANA name of the timezone used to interpret the expression. Leave it empty to use the" +
+			" timezone of the host. Example: 'America/Argentina/Buenos_Aires'.",
+		ContentType: types.Text,
+	},
+}
+
+// Cron - Trigger
+var Cron = shared.Trigger{
+	ID:          triggerID,
+	Name:        "Cron Expression",
+	Description: "The trigger will be activated every time that the given cron expression matches.",
+	Run:         trigger,
+	Args:        triggerArgs,
+	Validate:    validate,
+	Unsubscribe: forget,
+	Missed:      missed,
+}
+
+// parser accepts the expressions with 5 fields and, optionally, the seconds as first field.
+var parser = cron.NewParser(
+	cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor,
+)
+
+type activation struct {
+	// spec is the expression (and timezone) used to calculate the activation. If it changes (for example, after
+	// a modification of the task) the next activation must be calculated again.
+	spec string
+	next time.Time
+}
+
+var nextActivation = struct {
+	tasks map[string]activation
+	sync.Mutex
+}{tasks: make(map[string]activation)}
+
+func trigger(args *[]data.UserArg, parentTaskID string, now time.Time) (result bool, err error) {
+	schedule, loc, spec, err := parseArgs(args)
+	if err != nil {
+		return false, err
+	}
+
+	now = now.In(loc)
+
+	nextActivation.Lock()
+	defer nextActivation.Unlock()
+
+	a, exists := nextActivation.tasks[parentTaskID]
+
+	// First execution (or the expression has changed).
+	if !exists || a.spec != spec {
+		nextActivation.tasks[parentTaskID] = activation{spec: spec, next: schedule.Next(now)}
+
+		return false, nil
+	}
+
+	if !now.Before(a.next) {
+		nextActivation.tasks[parentTaskID] = activation{spec: spec, next: schedule.Next(now)}
+
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// forget removes the next activation of the task, calculated again if the task is evaluated later.
+func forget(parentTaskID string) {
+	nextActivation.Lock()
+	defer nextActivation.Unlock()
+
+	delete(nextActivation.tasks, parentTaskID)
+}
+
+func missed(args *[]data.UserArg, from, to time.Time, limit int) ([]time.Time, error) {
+	schedule, loc, _, err := parseArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	var activations []time.Time
+	for t := schedule.Next(from.In(loc)); !t.After(to) && len(activations) < limit; t = schedule.Next(t) {
+		activations = append(activations, t)
+	}
+
+	return activations, nil
+}
+
+func validate(args *[]data.UserArg) error {
+	_, _, _, err := parseArgs(args)
+
+	return err
+}
+
+func parseArgs(args *[]data.UserArg) (schedule cron.Schedule, loc *time.Location, spec string, err error) {
+	if len(*args) != len(triggerArgs) {
+		return nil, nil, "", fmt.Errorf("%d arguments were expected and %d were obtained", len(triggerArgs), len(*args))
+	}
+
+	var expression, timezone string
+
+	for i, arg := range *args {
+		switch arg.ID {
+		case triggerArgs[0].ID:
+			{
+				expression = strings.TrimSpace(arg.Content)
+				if expression == "" {
+					return nil, nil, "", fmt.Errorf("argument %d (ID: %s) is empty", i, arg.ID)
+				}
+			}
+		case triggerArgs[1].ID:
+			// The timezone is optional.
+			timezone = strings.TrimSpace(arg.Content)
+		default:
+			return nil, nil, "", shared.ErrUnrecognizedArgID
+		}
+	}
+
+	loc = time.Local
+	if timezone != "" {
+		loc, err = time.LoadLocation(timezone)
+		if err != nil {
+			return nil, nil, "", fmt.Errorf("invalid timezone '%s': %s", timezone, err.Error())
+		}
+	}
+
+	schedule, err = parser.Parse(expression)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("invalid cron expression '%s': %s", expression, err.Error())
+	}
+
+	return schedule, loc, timezone + " " + expression, nil
+}
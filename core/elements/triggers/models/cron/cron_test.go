@@ -0,0 +1,194 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Pegasus8/piworker/core/data"
+	test "github.com/Pegasus8/piworker/utilities/testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCron(t *testing.T) {
+	taskID := uuid.New().String()
+	assert := assert.New(t)
+
+	test.CheckTFields(t, Cron)
+
+	args := [][]data.UserArg{
+		// [0] -- Correct --
+		// Problem: 		None.
+		// Expected result: Should return no errors and a true result.
+		{
+			{
+				ID:      Cron.Args[0].ID,
+				Content: "30 7,19 * * 1-5",
+			},
+			{
+				ID:      Cron.Args[1].ID,
+				Content: "America/Argentina/Buenos_Aires",
+			},
+		},
+
+		// [1] -- Correct --
+		// Problem: 		None.
+		// Expected result: Should return no errors and a false result.
+		{
+			{
+				ID:      Cron.Args[0].ID,
+				Content: "30 7,19 * * 1-5",
+			},
+			{
+				ID:      Cron.Args[1].ID,
+				Content: "America/Argentina/Buenos_Aires",
+			},
+		},
+
+		// [2] -- Incorrect --
+		// Problem: 		The expression is incorrectly formatted.
+		// Expected result: Should return an error and a false result.
+		{
+			{
+				ID:      Cron.Args[0].ID,
+				Content: "61 * * * *", // Out of range.
+			},
+			{
+				ID:      Cron.Args[1].ID,
+				Content: "",
+			},
+		},
+
+		// [3] -- Incorrect --
+		// Problem: 		The timezone doesn't exist.
+		// Expected result: Should return an error and a false result.
+		{
+			{
+				ID:      Cron.Args[0].ID,
+				Content: "@daily",
+			},
+			{
+				ID:      Cron.Args[1].ID,
+				Content: "Mars/Olympus_Mons",
+			},
+		},
+
+		// [4] -- Incorrect --
+		// Problem: 		ID of an arg (0) is empty.
+		// Expected result: Should return an error and a false result.
+		{
+			{
+				ID:      "", // Empty ID
+				Content: "@daily",
+			},
+			{
+				ID:      Cron.Args[1].ID,
+				Content: "",
+			},
+		},
+
+		// [5] -- Incorrect --
+		// Problem: 		There are no arguments (should be two).
+		// Expected result: Should return an error and a false result.
+		{},
+
+		// [6] -- Incorrect --
+		// Problem: 		Content of the expression empty.
+		// Expected result: Should return an error and a false result.
+		{
+			{
+				ID:      Cron.Args[0].ID,
+				Content: "", // Empty content
+			},
+			{
+				ID:      Cron.Args[1].ID,
+				Content: "",
+			},
+		},
+	}
+
+	// Set the next activation to the current time to activate the trigger.
+	nextActivation.tasks[taskID] = activation{
+		spec: args[0][1].Content + " " + args[0][0].Content,
+		next: time.Now(),
+	}
+	r, err := Cron.Run(&args[0], taskID, time.Now())
+	assert.Equal(true, r, "the trigger must be executed correctly")
+	assert.NoError(err, "there should be no errors")
+
+	r, err = Cron.Run(&args[1], taskID, time.Now())
+	assert.Equal(false, r, "the trigger must be executed correctly")
+	assert.NoError(err, "there should be no errors")
+
+	for i, arg := range args[2:] {
+		r, err := Cron.Run(&arg, taskID, time.Now())
+		assert.Equalf(false, r, "[arg %d]the trigger must return a false result if at least one argument is incorrect", i)
+		assert.Errorf(err, "[arg %d] an error must be returned", i)
+
+		assert.Errorf(Cron.Validate(&arg), "[arg %d] the validation must fail", i)
+	}
+
+	assert.NoError(Cron.Validate(&args[0]), "a correct configuration must pass the validation")
+
+	Cron.Unsubscribe(taskID)
+	assert.NotContains(nextActivation.tasks, taskID, "the next activation must be forgotten")
+}
+
+func TestCronExpressions(t *testing.T) {
+	assert := assert.New(t)
+
+	// 2021-03-05 is a Friday.
+	from := time.Date(2021, 3, 5, 12, 0, 0, 0, time.UTC)
+
+	expressions := []struct {
+		expression string
+		expected   time.Time
+	}{
+		{"30 7,19 * * 1-5", time.Date(2021, 3, 5, 19, 30, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2021, 3, 5, 12, 15, 0, 0, time.UTC)},
+		{"0 0 9 * * SAT", time.Date(2021, 3, 6, 9, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2021, 3, 6, 0, 0, 0, 0, time.UTC)},
+		{"@every 90m", time.Date(2021, 3, 5, 13, 30, 0, 0, time.UTC)},
+	}
+
+	for _, e := range expressions {
+		schedule, err := parser.Parse(e.expression)
+		if !assert.NoErrorf(err, "the expression '%s' should be parsed correctly", e.expression) {
+			continue
+		}
+
+		assert.Equalf(e.expected, schedule.Next(from), "wrong next activation for '%s'", e.expression)
+	}
+}
+
+func TestCronMissed(t *testing.T) {
+	assert := assert.New(t)
+
+	args := []data.UserArg{
+		{ID: Cron.Args[0].ID, Content: "0 */6 * * *"},
+		{ID: Cron.Args[1].ID, Content: "UTC"},
+	}
+
+	from := time.Date(2021, 3, 5, 3, 0, 0, 0, time.UTC)
+	to := time.Date(2021, 3, 5, 18, 0, 0, 0, time.UTC)
+
+	missed, err := Cron.Missed(&args, from, to, 10)
+	assert.NoError(err)
+	assert.Equal([]time.Time{
+		time.Date(2021, 3, 5, 6, 0, 0, 0, time.UTC),
+		time.Date(2021, 3, 5, 12, 0, 0, 0, time.UTC),
+		time.Date(2021, 3, 5, 18, 0, 0, 0, time.UTC),
+	}, missed, "all the activations of the interval must be returned")
+
+	missed, err = Cron.Missed(&args, from, to, 2)
+	assert.NoError(err)
+	assert.Len(missed, 2, "the limit must be respected")
+
+	missed, err = Cron.Missed(&args, from, from.Add(time.Hour), 10)
+	assert.NoError(err)
+	assert.Empty(missed, "there are no activations in the interval")
+
+	_, err = Cron.Missed(&[]data.UserArg{}, from, to, 10)
+	assert.Error(err, "the arguments must be checked")
+}
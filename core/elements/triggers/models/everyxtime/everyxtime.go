@@ -32,7 +32,7 @@ var EveryXTime = shared.Trigger{
 
 var nextExecution = make(map[string]time.Time)
 
-func trigger(args *[]data.UserArg, parentTaskID string) (result bool, err error) {
+func trigger(args *[]data.UserArg, parentTaskID string, now time.Time) (result bool, err error) {
 	if len(*args) != len(triggerArgs) {
 		return false, fmt.Errorf("%d arguments were expected and %d were obtained", len(triggerArgs), len(*args))
 	}
@@ -60,13 +60,13 @@ func trigger(args *[]data.UserArg, parentTaskID string) (result bool, err error)
 
 	// First execution
 	if _, exists := nextExecution[parentTaskID]; !exists {
-		nextExecution[parentTaskID] = time.Now().Add(timeToWait)
+		nextExecution[parentTaskID] = now.Add(timeToWait)
 
 		return false, nil
 	}
 
-	if nextExecution[parentTaskID].Unix() <= time.Now().Unix() {
-		nextExecution[parentTaskID] = time.Now().Add(timeToWait)
+	if nextExecution[parentTaskID].Unix() <= now.Unix() {
+		nextExecution[parentTaskID] = now.Add(timeToWait)
 
 		return true, nil
 	}
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/Pegasus8/piworker/core/data"
+	"github.com/Pegasus8/piworker/utilities/clock"
 	test "github.com/Pegasus8/piworker/utilities/testing"
 
 	"github.com/google/uuid"
@@ -86,17 +87,45 @@ func TestEveryXTime(t *testing.T) {
 
 	// Set the next execution to the current time to activate the trigger.
 	nextExecution[taskID] = time.Now()
-	r, err := EveryXTime.Run(&args[0], taskID)
+	r, err := EveryXTime.Run(&args[0], taskID, time.Now())
 	assert.Equal(true, r, "the trigger must be executed correctly")
 	assert.NoError(err, "there should be no errors")
 
-	r, err = EveryXTime.Run(&args[1], taskID)
+	r, err = EveryXTime.Run(&args[1], taskID, time.Now())
 	assert.Equal(false, r, "the trigger must be executed correctly")
 	assert.NoError(err, "there should be no errors")
 
 	for i, arg := range args[2:] {
-		r, err := EveryXTime.Run(&arg, taskID)
+		r, err := EveryXTime.Run(&arg, taskID, time.Now())
 		assert.Equalf(false, r, "[arg %d]the trigger must return a false result if at least one argument is incorrect", i)
 		assert.Errorf(err, "[arg %d] an error must be returned", i)
 	}
 }
+
+func TestEveryXTimeClock(t *testing.T) {
+	assert := assert.New(t)
+	taskID := uuid.New().String()
+
+	fake := clock.NewFake(time.Date(2021, 3, 5, 12, 0, 0, 0, time.Local))
+
+	args := []data.UserArg{{ID: EveryXTime.Args[0].ID, Content: "1h"}}
+
+	r, err := EveryXTime.Run(&args, taskID, fake.Now())
+	assert.NoError(err)
+	assert.False(r, "the trigger must not be activated on its first execution")
+
+	fake.Advance(59 * time.Minute)
+	r, _ = EveryXTime.Run(&args, taskID, fake.Now())
+	assert.False(r, "the trigger must not be activated before the given time")
+
+	fake.Advance(time.Minute)
+	r, _ = EveryXTime.Run(&args, taskID, fake.Now())
+	assert.True(r, "the trigger must be activated once the given time has passed")
+
+	r, _ = EveryXTime.Run(&args, taskID, fake.Now())
+	assert.False(r, "the time must be counted again after the activation")
+
+	fake.Advance(time.Hour)
+	r, _ = EveryXTime.Run(&args, taskID, fake.Now())
+	assert.True(r)
+}
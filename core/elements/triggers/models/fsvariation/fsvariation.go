@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/Pegasus8/piworker/core/data"
 	"github.com/Pegasus8/piworker/core/elements/triggers/shared"
@@ -32,7 +33,7 @@ var VariationOfFileSize = shared.Trigger{
 
 var previousFileSize = make(map[string]int64)
 
-func trigger(args *[]data.UserArg, parentTaskID string) (result bool, err error) {
+func trigger(args *[]data.UserArg, parentTaskID string, _ time.Time) (result bool, err error) {
 	if len(*args) != len(triggerArgs) {
 		return false, fmt.Errorf("%d arguments were expected and %d were obtained", len(triggerArgs), len(*args))
 	}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/Pegasus8/piworker/core/data"
 	test "github.com/Pegasus8/piworker/utilities/testing"
@@ -110,20 +111,20 @@ func (suite *TriggerTestSuite) TestVariationOfFileSize() {
 	}
 
 	// First run should get the current size of the file for a posterior comparison.
-	_, _ = VariationOfFileSize.Run(&args[0], suite.TaskID)
+	_, _ = VariationOfFileSize.Run(&args[0], suite.TaskID, time.Now())
 
 	appendToFile(suite.Filepath, "1234") // Variate the size of the file.
-	r, err := VariationOfFileSize.Run(&args[0], suite.TaskID)
+	r, err := VariationOfFileSize.Run(&args[0], suite.TaskID, time.Now())
 	assert.Equal(true, r, "the trigger must be executed correctly")
 	assert.NoError(err, "there should be no errors")
 
 	// Don't variate the file of the size, must return false.
-	r, err = VariationOfFileSize.Run(&args[1], suite.TaskID)
+	r, err = VariationOfFileSize.Run(&args[1], suite.TaskID, time.Now())
 	assert.Equal(false, r, "the trigger must be executed correctly")
 	assert.NoError(err, "there should be no errors")
 
 	for i, arg := range args[2:] {
-		r, err := VariationOfFileSize.Run(&arg, suite.TaskID)
+		r, err := VariationOfFileSize.Run(&arg, suite.TaskID, time.Now())
 		assert.Equalf(false, r, "[arg %d]the trigger must return a false result if at least one argument is incorrect", i)
 		assert.Errorf(err, "[arg %d] an error must be returned", i)
 	}
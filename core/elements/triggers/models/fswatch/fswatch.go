@@ -0,0 +1,361 @@
+package fswatch
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/Pegasus8/piworker/core/data"
+	"github.com/Pegasus8/piworker/core/elements/triggers/shared"
+	"github.com/Pegasus8/piworker/core/types"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog/log"
+)
+
+const triggerID = "T8"
+
+// Event is a kind of change on the watched directory that activates the trigger.
+type Event string
+
+const (
+	// Create activates the trigger when a file is created (or moved into the directory).
+	Create Event = "create"
+	// Modify activates the trigger when the content of a file is modified.
+	Modify Event = "modify"
+	// Delete activates the trigger when a file is deleted.
+	Delete Event = "delete"
+	// Rename activates the trigger when a file is renamed (or moved out of the directory), with its old path.
+	Rename Event = "rename"
+)
+
+// operations are the operations of fsnotify that represent each event.
+var operations = map[Event]fsnotify.Op{
+	Create: fsnotify.Create,
+	Modify: fsnotify.Write,
+	Delete: fsnotify.Remove,
+	Rename: fsnotify.Rename,
+}
+
+var triggerArgs = []shared.Arg{
+	{
+		ID:          triggerID + "-1",
+		Name:        "Directory",
+		Description: "The path of the directory to watch. Example: '/home/pi/camera'",
+		ContentType: types.Path,
+	},
+	{
+		ID:          triggerID + "-2",
+		Name:        "Recursive",
+		Description: "If 'true', the subdirectories are watched too. If empty, 'false' is used.",
+		ContentType: types.Bool,
+	},
+	{
+		ID:   triggerID + "-3",
+		Name: "Include",
+		Description: "Comma separated glob patterns of the names of the files that activate the trigger. " +
+			"Example: '*.jpg, *.png'. If empty, all the files are included.",
+		ContentType: types.Text,
+	},
+	{
+		ID:   triggerID + "-4",
+		Name: "Exclude",
+		Description: "Comma separated glob patterns of the names of the files ignored, even if they are " +
+			"included. Example: '.*, *.tmp'. If empty, no files are excluded.",
+		ContentType: types.Text,
+	},
+	{
+		ID:   triggerID + "-5",
+		Name: "Events",
+		Description: "Comma separated events that activate the trigger: 'create', 'modify', 'delete' and/or " +
+			"'rename'. If empty, all of them are used.",
+		ContentType: types.Text,
+	},
+}
+
+type config struct {
+	directory string
+	recursive bool
+	include   []string
+	exclude   []string
+	ops       fsnotify.Op
+}
+
+// Watchers keeps the watcher of each task subscribed to the trigger. Each registry of elements has its own.
+type Watchers struct {
+	tasks map[string]*fsnotify.Watcher
+	sync.Mutex
+}
+
+// New returns the watchers without subscribed tasks.
+func New() *Watchers {
+	return &Watchers{tasks: make(map[string]*fsnotify.Watcher)}
+}
+
+// Trigger returns the trigger, whose subscriptions are kept by `watchers`.
+func (watchers *Watchers) Trigger() shared.Trigger {
+	return shared.Trigger{
+		ID:   triggerID,
+		Name: "Directory Watch",
+		Description: "The trigger will be activated when a file of a directory is created, modified, deleted or " +
+			"renamed. The path of the file is given to the first action of the task as chained result. The " +
+			"changes received while the task is running are kept in order and delivered one by one, so a burst " +
+			"of changes (like copying several files) executes the task once per file.",
+		Args:        triggerArgs,
+		Validate:    validate,
+		Subscribe:   watchers.subscribe,
+		Unsubscribe: watchers.unsubscribe,
+		Queued:      true,
+	}
+}
+
+func (watchers *Watchers) subscribe(args *[]data.UserArg, parentTaskID string) (<-chan shared.Activation, error) {
+	c, err := parseArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(c.directory)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("'%s' is not a directory", c.directory)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	err = add(watcher, c.directory, c.recursive)
+	if err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	watchers.Lock()
+	defer watchers.Unlock()
+
+	if previous, exists := watchers.tasks[parentTaskID]; exists {
+		previous.Close()
+	}
+	watchers.tasks[parentTaskID] = watcher
+
+	activations := make(chan shared.Activation)
+	go watch(watcher, c, parentTaskID, activations)
+
+	return activations, nil
+}
+
+func (watchers *Watchers) unsubscribe(parentTaskID string) {
+	watchers.Lock()
+	defer watchers.Unlock()
+
+	if watcher, exists := watchers.tasks[parentTaskID]; exists {
+		watcher.Close()
+		delete(watchers.tasks, parentTaskID)
+	}
+}
+
+func validate(args *[]data.UserArg) error {
+	_, err := parseArgs(args)
+
+	return err
+}
+
+// add watches the directory and, if `recursive`, its subdirectories.
+func add(watcher *fsnotify.Watcher, directory string, recursive bool) error {
+	if !recursive {
+		return watcher.Add(directory)
+	}
+
+	return filepath.Walk(directory, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !info.IsDir() {
+			return nil
+		}
+
+		return watcher.Add(path)
+	})
+}
+
+// watch sends through `activations` the paths of the events received by the watcher that match the configuration of
+// the task, until the watcher is closed, and then closes `activations`. The paths wait on a queue until they are
+// received, in the order of their events. A path already waiting isn't queued again, so the several events of a
+// single change (like the creation and the writes of a new file) result in one activation.
+func watch(watcher *fsnotify.Watcher, c config, parentTaskID string, activations chan<- shared.Activation) {
+	defer close(activations)
+
+	var pending []string
+
+	for {
+		// Nothing is sent while the queue is empty, a nil channel is never selected.
+		var send chan<- shared.Activation
+		var next shared.Activation
+		if len(pending) > 0 {
+			send = activations
+			next = shared.Activation{Result: pending[0], ResultType: types.Path}
+		}
+
+		select {
+		case send <- next:
+			pending = pending[1:]
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+
+			if c.recursive && event.Op&fsnotify.Create != 0 {
+				// The new subdirectories must be watched too.
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					err = add(watcher, event.Name, true)
+					if err != nil {
+						log.Error().
+							Err(err).
+							Str("taskID", parentTaskID).
+							Str("directory", event.Name).
+							Msg("Error when trying to watch the new directory")
+					}
+				}
+			}
+
+			if event.Op&c.ops == 0 || !c.matches(filepath.Base(event.Name)) || queued(pending, event.Name) {
+				continue
+			}
+
+			pending = append(pending, event.Name)
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+
+			log.Error().Err(err).Str("taskID", parentTaskID).Msg("Error when watching the directory")
+		}
+	}
+}
+
+// queued reports whether the path is waiting on the queue.
+func queued(pending []string, path string) bool {
+	for _, p := range pending {
+		if p == path {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matches reports whether the file with the given name is included and not excluded.
+func (c *config) matches(name string) bool {
+	for _, pattern := range c.exclude {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return false
+		}
+	}
+
+	if len(c.include) == 0 {
+		return true
+	}
+
+	for _, pattern := range c.include {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parseArgs(args *[]data.UserArg) (c config, err error) {
+	if len(*args) != len(triggerArgs) {
+		return c, fmt.Errorf("%d arguments were expected and %d were obtained", len(triggerArgs), len(*args))
+	}
+
+	for i, arg := range *args {
+		content := strings.TrimSpace(arg.Content)
+
+		switch arg.ID {
+		case triggerArgs[0].ID:
+			{
+				if content == "" {
+					return c, fmt.Errorf("argument %d (ID: %s) is empty", i, arg.ID)
+				}
+				c.directory = filepath.Clean(content)
+			}
+		case triggerArgs[1].ID:
+			{
+				if content == "" {
+					continue
+				}
+
+				var isBool bool
+				isBool, c.recursive = types.IsBool(content)
+				if !isBool {
+					return c, fmt.Errorf("argument %d (ID: %s) must be a boolean", i, arg.ID)
+				}
+			}
+		case triggerArgs[2].ID:
+			c.include, err = parsePatterns(content)
+			if err != nil {
+				return c, err
+			}
+		case triggerArgs[3].ID:
+			c.exclude, err = parsePatterns(content)
+			if err != nil {
+				return c, err
+			}
+		case triggerArgs[4].ID:
+			{
+				for _, e := range split(content) {
+					op, exists := operations[Event(strings.ToLower(e))]
+					if !exists {
+						return c, fmt.Errorf("unrecognized event '%s'", e)
+					}
+					c.ops |= op
+				}
+			}
+		default:
+			return c, shared.ErrUnrecognizedArgID
+		}
+	}
+
+	if c.ops == 0 {
+		for _, op := range operations {
+			c.ops |= op
+		}
+	}
+
+	return c, nil
+}
+
+// parsePatterns returns the comma separated glob patterns of `content`, checking their syntax.
+func parsePatterns(content string) ([]string, error) {
+	patterns := split(content)
+	for _, pattern := range patterns {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern '%s': %w", pattern, err)
+		}
+	}
+
+	return patterns, nil
+}
+
+// split returns the non empty elements of a comma separated list.
+func split(content string) []string {
+	var elements []string
+	for _, e := range strings.Split(content, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			elements = append(elements, e)
+		}
+	}
+
+	return elements
+}
@@ -0,0 +1,171 @@
+package fswatch
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Pegasus8/piworker/core/data"
+	"github.com/Pegasus8/piworker/core/elements/triggers/shared"
+	"github.com/Pegasus8/piworker/core/types"
+	test "github.com/Pegasus8/piworker/utilities/testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDirectoryWatch(t *testing.T) {
+	assert := assert.New(t)
+
+	trigger := New().Trigger()
+	test.CheckTFields(t, trigger)
+
+	dir, err := ioutil.TempDir("", "fswatch")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	watchArgs := func(directory, recursive, include, exclude, events string) []data.UserArg {
+		return []data.UserArg{
+			{ID: trigger.Args[0].ID, Content: directory},
+			{ID: trigger.Args[1].ID, Content: recursive},
+			{ID: trigger.Args[2].ID, Content: include},
+			{ID: trigger.Args[3].ID, Content: exclude},
+			{ID: trigger.Args[4].ID, Content: events},
+		}
+	}
+
+	incorrectArgs := [][]data.UserArg{
+		// The directory is empty.
+		watchArgs("", "", "", "", ""),
+		// Not a boolean.
+		watchArgs(dir, "maybe", "", "", ""),
+		// Invalid pattern.
+		watchArgs(dir, "", "[a-", "", ""),
+		// Unrecognized event.
+		watchArgs(dir, "", "", "", "create, open"),
+		// There are no arguments (should be five).
+		{},
+	}
+
+	for i, args := range incorrectArgs {
+		assert.Errorf(trigger.Validate(&args), "[args %d] the validation must fail", i)
+
+		_, err := trigger.Subscribe(&args, uuid.New().String())
+		assert.Errorf(err, "[args %d] the subscription must fail", i)
+	}
+
+	args := watchArgs(filepath.Join(dir, "nothing"), "", "", "", "")
+	_, err = trigger.Subscribe(&args, uuid.New().String())
+	assert.Error(err, "a directory that doesn't exist can't be watched")
+
+	write := func(path string) {
+		err := ioutil.WriteFile(path, []byte("content"), 0644)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	activated := func(c <-chan shared.Activation, path, msg string) {
+		select {
+		case a := <-c:
+			assert.Equal(path, a.Result, msg)
+			assert.Equal(types.Path, a.ResultType)
+		case <-time.After(5 * time.Second):
+			assert.Fail(msg)
+		}
+	}
+
+	notActivated := func(c <-chan shared.Activation, msg string) {
+		select {
+		case a := <-c:
+			assert.Failf(msg, "activated by '%s'", a.Result)
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+
+	// drain discards the pending activations, until no more are received.
+	drain := func(c <-chan shared.Activation) {
+		for {
+			select {
+			case <-c:
+			case <-time.After(200 * time.Millisecond):
+				return
+			}
+		}
+	}
+
+	// Only the creation of images, on the directory and its subdirectories.
+	images := uuid.New().String()
+	args = watchArgs(dir, "true", "*.jpg, *.png", "tmp_*", "create")
+	imagesC, err := trigger.Subscribe(&args, images)
+	assert.NoError(err)
+	defer trigger.Unsubscribe(images)
+
+	// Any change on the files of the directory.
+	all := uuid.New().String()
+	args = watchArgs(dir, "", "", "", "")
+	allC, err := trigger.Subscribe(&args, all)
+	assert.NoError(err)
+
+	snapshot := filepath.Join(dir, "snapshot.jpg")
+	write(snapshot)
+	activated(imagesC, snapshot, "the creation of an image must activate the trigger")
+	activated(allC, snapshot, "the creation of a file must activate the trigger")
+	drain(allC) // Discard the modification.
+
+	write(filepath.Join(dir, "notes.txt"))
+	notActivated(imagesC, "the files not included must not activate the trigger")
+	write(filepath.Join(dir, "tmp_snapshot.jpg"))
+	notActivated(imagesC, "the files excluded must not activate the trigger")
+
+	subdirectory := filepath.Join(dir, "camera")
+	err = os.Mkdir(subdirectory, 0755)
+	if err != nil {
+		panic(err)
+	}
+	// Wait until the new directory is watched.
+	time.Sleep(200 * time.Millisecond)
+	write(filepath.Join(subdirectory, "snapshot.png"))
+	activated(imagesC, filepath.Join(subdirectory, "snapshot.png"),
+		"the creation of an image on a new subdirectory must activate the trigger")
+
+	// Discard the activations of the previous files.
+	drain(allC)
+
+	err = os.Remove(snapshot)
+	if err != nil {
+		panic(err)
+	}
+	activated(allC, snapshot, "the deletion of a file must activate the trigger")
+	notActivated(imagesC, "the deletion must not activate the trigger if it's not chosen")
+
+	// A burst of changes activates the trigger once per file, in order.
+	var burst []string
+	for i := 0; i < 5; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("burst_%d.jpg", i))
+		write(path)
+		burst = append(burst, path)
+	}
+	for _, path := range burst {
+		activated(imagesC, path, "every file of the burst must activate the trigger")
+	}
+
+	// Once unsubscribed, the channel is closed.
+	trigger.Unsubscribe(all)
+	closed := make(chan struct{})
+	go func() {
+		for range allC {
+		}
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		assert.Fail("the channel must be closed after the unsubscription")
+	}
+}
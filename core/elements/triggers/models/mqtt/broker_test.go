@@ -0,0 +1,191 @@
+package mqtt
+
+import (
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/eclipse/paho.mqtt.golang/packets"
+)
+
+// testBroker is a minimal MQTT broker that runs in the process of the tests. It only supports QoS 0 and doesn't keep
+// sessions nor retained messages.
+type testBroker struct {
+	listener net.Listener
+	// clients keeps the topic filters subscribed by each client.
+	clients map[net.Conn][]string
+	sync.Mutex
+}
+
+func newTestBroker() (*testBroker, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	b := &testBroker{listener: l, clients: make(map[net.Conn][]string)}
+	go b.accept()
+
+	return b, nil
+}
+
+func (b *testBroker) url() string {
+	return "tcp://" + b.listener.Addr().String()
+}
+
+func (b *testBroker) accept() {
+	for {
+		conn, err := b.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go b.serve(conn)
+	}
+}
+
+func (b *testBroker) serve(conn net.Conn) {
+	defer func() {
+		b.Lock()
+		delete(b.clients, conn)
+		b.Unlock()
+
+		conn.Close()
+	}()
+
+	for {
+		p, err := packets.ReadPacket(conn)
+		if err != nil {
+			return
+		}
+
+		switch p := p.(type) {
+		case *packets.ConnectPacket:
+			b.Lock()
+			b.clients[conn] = nil
+			b.Unlock()
+
+			b.write(conn, packets.NewControlPacket(packets.Connack))
+		case *packets.SubscribePacket:
+			b.Lock()
+			b.clients[conn] = append(b.clients[conn], p.Topics...)
+			b.Unlock()
+
+			ack := packets.NewControlPacket(packets.Suback).(*packets.SubackPacket)
+			ack.MessageID = p.MessageID
+			ack.ReturnCodes = make([]byte, len(p.Topics))
+			b.write(conn, ack)
+		case *packets.UnsubscribePacket:
+			b.Lock()
+			var filters []string
+			for _, f := range b.clients[conn] {
+				if !contains(p.Topics, f) {
+					filters = append(filters, f)
+				}
+			}
+			b.clients[conn] = filters
+			b.Unlock()
+
+			ack := packets.NewControlPacket(packets.Unsuback).(*packets.UnsubackPacket)
+			ack.MessageID = p.MessageID
+			b.write(conn, ack)
+		case *packets.PublishPacket:
+			b.publish(p.TopicName, p.Payload)
+		case *packets.PingreqPacket:
+			b.write(conn, packets.NewControlPacket(packets.Pingresp))
+		case *packets.DisconnectPacket:
+			return
+		}
+	}
+}
+
+func (b *testBroker) write(conn net.Conn, p packets.ControlPacket) {
+	b.Lock()
+	defer b.Unlock()
+
+	p.Write(conn)
+}
+
+// publish sends the message to the clients subscribed to a filter that matches the topic.
+func (b *testBroker) publish(topic string, payload []byte) {
+	b.Lock()
+	defer b.Unlock()
+
+	for conn, filters := range b.clients {
+		for _, f := range filters {
+			if !topicMatches(f, topic) {
+				continue
+			}
+
+			p := packets.NewControlPacket(packets.Publish).(*packets.PublishPacket)
+			p.TopicName = topic
+			p.Payload = payload
+			p.Write(conn)
+
+			break
+		}
+	}
+}
+
+// subscribed reports whether there is a client subscribed to the topic filter.
+func (b *testBroker) subscribed(filter string) bool {
+	b.Lock()
+	defer b.Unlock()
+
+	for _, filters := range b.clients {
+		if contains(filters, filter) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// connectedClients returns the number of clients connected.
+func (b *testBroker) connectedClients() int {
+	b.Lock()
+	defer b.Unlock()
+
+	return len(b.clients)
+}
+
+// dropClients closes the connection of all the clients, like a restart of the broker.
+func (b *testBroker) dropClients() {
+	b.Lock()
+	defer b.Unlock()
+
+	for conn := range b.clients {
+		conn.Close()
+		delete(b.clients, conn)
+	}
+}
+
+func (b *testBroker) close() {
+	b.listener.Close()
+	b.dropClients()
+}
+
+func topicMatches(filter, topic string) bool {
+	f, t := strings.Split(filter, "/"), strings.Split(topic, "/")
+
+	for i, level := range f {
+		if level == "#" {
+			return true
+		}
+		if i >= len(t) || (level != "+" && level != t[i]) {
+			return false
+		}
+	}
+
+	return len(f) == len(t)
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
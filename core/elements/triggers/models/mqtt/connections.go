@@ -0,0 +1,191 @@
+package mqtt
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Pegasus8/piworker/core/configs"
+	"github.com/Pegasus8/piworker/core/elements/triggers/shared"
+	"github.com/Pegasus8/piworker/core/types"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// connectRetryInterval is the time waited between the attempts to establish the first connection to a broker.
+	connectRetryInterval = 5 * time.Second
+	// maxReconnectInterval is the maximum time waited between the attempts to reconnect to a broker.
+	maxReconnectInterval = time.Minute
+	// disconnectQuiesce is the time, in milliseconds, given to the pending work before closing a connection.
+	disconnectQuiesce = 250
+)
+
+// connection is the connection to a broker, shared by all the tasks that use it.
+type connection struct {
+	client paho.Client
+	// topics keeps the filters of the tasks subscribed to each topic, by task.
+	topics map[string]map[string]filter
+}
+
+// registry keeps the connections to the brokers by their name, and the connection used by each task.
+type registry struct {
+	brokers map[string]*connection
+	tasks   map[string]*connection
+	// subscriptions are notified of the messages that match the filters of their tasks.
+	subscriptions *shared.Subscriptions
+	sync.Mutex
+}
+
+func newRegistry(subscriptions *shared.Subscriptions) *registry {
+	return &registry{
+		brokers:       make(map[string]*connection),
+		tasks:         make(map[string]*connection),
+		subscriptions: subscriptions,
+	}
+}
+
+// The tokens of the client are never waited while the registry is locked: the handlers of the messages lock it too,
+// and the client can't complete its operations while they are running.
+
+// add subscribes the task to the topic of the filter, connecting to the broker if there is no connection yet. If the
+// task was already subscribed, its previous subscription is replaced.
+func (r *registry) add(broker configs.MQTTBroker, taskID string, f filter) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.removeTask(taskID)
+
+	conn, exists := r.brokers[broker.Name]
+	if !exists {
+		conn = r.connect(broker)
+		r.brokers[broker.Name] = conn
+	}
+	r.tasks[taskID] = conn
+
+	tasks, subscribed := conn.topics[f.topic]
+	if !subscribed {
+		tasks = make(map[string]filter)
+		conn.topics[f.topic] = tasks
+	}
+	tasks[taskID] = f
+
+	// If the client isn't connected yet, the topic is subscribed once it's connected.
+	if !subscribed && conn.client.IsConnectionOpen() {
+		r.subscribe(conn, f.topic)
+	}
+}
+
+// remove unsubscribes the task, closing the connection to its broker if no other task uses it.
+func (r *registry) remove(taskID string) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.removeTask(taskID)
+}
+
+func (r *registry) removeTask(taskID string) {
+	conn, exists := r.tasks[taskID]
+	if !exists {
+		return
+	}
+	delete(r.tasks, taskID)
+
+	for topic, tasks := range conn.topics {
+		if _, subscribed := tasks[taskID]; !subscribed {
+			continue
+		}
+
+		delete(tasks, taskID)
+		if len(tasks) == 0 {
+			delete(conn.topics, topic)
+			if conn.client.IsConnectionOpen() {
+				go waitToken(conn.client.Unsubscribe(topic), topic, "Error when unsubscribing from the MQTT topic")
+			}
+		}
+	}
+
+	if len(conn.topics) > 0 {
+		return
+	}
+
+	for name, c := range r.brokers {
+		if c == conn {
+			delete(r.brokers, name)
+		}
+	}
+	go conn.client.Disconnect(disconnectQuiesce)
+}
+
+// connect creates the client of the broker and starts its connection on background, retrying until it's established.
+func (r *registry) connect(broker configs.MQTTBroker) *connection {
+	conn := &connection{topics: make(map[string]map[string]filter)}
+
+	clientID := broker.ClientID
+	if clientID == "" {
+		clientID = "piworker-" + uuid.New().String()[:8]
+	}
+
+	opts := paho.NewClientOptions().
+		AddBroker(broker.URL).
+		SetClientID(clientID).
+		SetUsername(broker.Username).
+		SetPassword(broker.Password).
+		SetCleanSession(true).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetConnectRetryInterval(connectRetryInterval).
+		SetMaxReconnectInterval(maxReconnectInterval).
+		// The handlers lock the registry, so they can't block the reception of the acknowledgements.
+		SetOrderMatters(false).
+		SetOnConnectHandler(func(paho.Client) {
+			log.Info().Str("broker", broker.Name).Msg("Connected to the MQTT broker")
+
+			// The session isn't kept by the broker, so the topics are subscribed again on each connection.
+			r.Lock()
+			defer r.Unlock()
+
+			for topic := range conn.topics {
+				r.subscribe(conn, topic)
+			}
+		}).
+		SetConnectionLostHandler(func(_ paho.Client, err error) {
+			log.Warn().Err(err).Str("broker", broker.Name).Msg("Connection to the MQTT broker lost, reconnecting")
+		})
+
+	conn.client = paho.NewClient(opts)
+	go waitToken(conn.client.Connect(), broker.Name, "Error when connecting to the MQTT broker")
+
+	return conn
+}
+
+// subscribe subscribes the client of the connection to the topic, notifying the tasks subscribed to it whose filter
+// matches the messages received.
+func (r *registry) subscribe(conn *connection, topic string) {
+	handler := func(_ paho.Client, m paho.Message) {
+		payload := m.Payload()
+
+		r.Lock()
+		defer r.Unlock()
+
+		for taskID, f := range conn.topics[topic] {
+			if !f.matches(payload) {
+				continue
+			}
+
+			r.subscriptions.Notify(taskID, shared.Activation{
+				Result:     string(payload),
+				ResultType: types.GetType(string(payload)),
+			})
+		}
+	}
+
+	go waitToken(conn.client.Subscribe(topic, 0, handler), topic, "Error when subscribing to the MQTT topic")
+}
+
+func waitToken(token paho.Token, subject, msg string) {
+	if token.Wait(); token.Error() != nil {
+		log.Error().Err(token.Error()).Str("subject", subject).Msg(msg)
+	}
+}
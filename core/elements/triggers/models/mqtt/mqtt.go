@@ -0,0 +1,316 @@
+// Package mqtt implements the trigger activated by the messages published on a topic of an MQTT broker. The brokers
+// are defined on the configurations and their connections are shared by all the tasks of a registry of elements that
+// use them.
+package mqtt
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Pegasus8/piworker/core/configs"
+	"github.com/Pegasus8/piworker/core/data"
+	"github.com/Pegasus8/piworker/core/elements/triggers/shared"
+	"github.com/Pegasus8/piworker/core/types"
+)
+
+const triggerID = "T14"
+
+var triggerArgs = []shared.Arg{
+	{
+		ID:          triggerID + "-1",
+		Name:        "Broker",
+		Description: "The name of the broker, as defined on the configurations (mqtt-brokers).",
+		ContentType: types.Text,
+	},
+	{
+		ID:   triggerID + "-2",
+		Name: "Topic",
+		Description: "The topic to subscribe. The wildcards '+' (one level) and '#' (all the remaining levels) can " +
+			"be used. Example: home/+/temperature",
+		ContentType: types.Text,
+	},
+	{
+		ID:          triggerID + "-3",
+		Name:        "Payload",
+		Description: "If not empty, only the messages with this payload activate the trigger. Example: open",
+		ContentType: types.Text,
+	},
+	{
+		ID:   triggerID + "-4",
+		Name: "Conditions",
+		Description: "A JSON object with the conditions that the fields of the payload (which must be a JSON " +
+			"object) must meet to activate the trigger, with the format \"<operator> <value>\" where the operator " +
+			"is '==', '!=', '>', '>=', '<' or '<='. The nested fields are separated by dots. Example: " +
+			"{\"temperature\": \"> 30\", \"sensor.battery\": \">= 10\"}. If empty, the fields aren't checked.",
+		ContentType: types.JSON,
+	},
+}
+
+// operators are the operators of the conditions, ordered so the longest ones are checked first.
+var operators = []string{"==", "!=", ">=", "<=", ">", "<"}
+
+type filter struct {
+	broker     string
+	topic      string
+	payload    string
+	conditions []condition
+}
+
+type condition struct {
+	path     []string
+	operator string
+	value    string
+}
+
+// Brokers keeps the brokers that can be used by the tasks subscribed to the trigger, and their connections. Each
+// registry of elements has its own.
+type Brokers struct {
+	// configs keeps the brokers defined on the configurations, by name.
+	configs       map[string]configs.MQTTBroker
+	subscriptions *shared.Subscriptions
+	connections   *registry
+}
+
+// New returns the brokers of `list`, without connections. The connections are established once a task subscribes to
+// one of their topics.
+func New(list []configs.MQTTBroker) *Brokers {
+	brokers := &Brokers{
+		configs:       make(map[string]configs.MQTTBroker, len(list)),
+		subscriptions: shared.NewSubscriptions(),
+	}
+	for _, b := range list {
+		brokers.configs[b.Name] = b
+	}
+	brokers.connections = newRegistry(brokers.subscriptions)
+
+	return brokers
+}
+
+// Trigger returns the trigger, whose subscriptions are kept by `brokers`.
+func (brokers *Brokers) Trigger() shared.Trigger {
+	return shared.Trigger{
+		ID:   triggerID,
+		Name: "MQTT Message",
+		Description: "The trigger will be activated by the messages published on a topic of an MQTT broker. The " +
+			"payload of the message is given to the first action of the task as chained result.",
+		Args:        triggerArgs,
+		Validate:    validate,
+		Subscribe:   brokers.subscribe,
+		Unsubscribe: brokers.unsubscribe,
+	}
+}
+
+func (brokers *Brokers) subscribe(args *[]data.UserArg, parentTaskID string) (<-chan shared.Activation, error) {
+	f, err := parseArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	broker, exists := brokers.configs[f.broker]
+	if !exists {
+		return nil, fmt.Errorf("the MQTT broker '%s' isn't defined on the configurations", f.broker)
+	}
+
+	activations := brokers.subscriptions.Add(parentTaskID)
+	brokers.connections.add(broker, parentTaskID, f)
+
+	return activations, nil
+}
+
+func (brokers *Brokers) unsubscribe(parentTaskID string) {
+	brokers.connections.remove(parentTaskID)
+	brokers.subscriptions.Remove(parentTaskID)
+}
+
+func validate(args *[]data.UserArg) error {
+	_, err := parseArgs(args)
+
+	return err
+}
+
+// matches reports whether the message with the given payload meets the filter.
+func (f *filter) matches(payload []byte) bool {
+	if f.payload != "" && strings.TrimSpace(string(payload)) != f.payload {
+		return false
+	}
+
+	if len(f.conditions) == 0 {
+		return true
+	}
+
+	var content map[string]interface{}
+	if err := json.Unmarshal(payload, &content); err != nil {
+		return false
+	}
+
+	for _, c := range f.conditions {
+		if !c.meets(content) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// meets reports whether the field of the condition exists on `content` and meets it.
+func (c *condition) meets(content map[string]interface{}) bool {
+	var field interface{} = content
+	for _, key := range c.path {
+		object, ok := field.(map[string]interface{})
+		if !ok {
+			return false
+		}
+
+		field, ok = object[key]
+		if !ok {
+			return false
+		}
+	}
+
+	var value string
+	switch v := field.(type) {
+	case string:
+		value = v
+	case float64:
+		value = strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		value = strconv.FormatBool(v)
+	default:
+		// Objects, arrays and null are compared by their JSON representation.
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return false
+		}
+		value = string(encoded)
+	}
+
+	t := types.Text
+	switch types.GetType(c.value) {
+	case types.Int, types.Float:
+		t = types.Float
+	case types.Bool:
+		t = types.Bool
+	}
+
+	r, err := types.Compare(t, value, c.value)
+	if err != nil {
+		// The field doesn't have the type of the value of the condition.
+		return false
+	}
+
+	switch c.operator {
+	case "==":
+		return r == 0
+	case "!=":
+		return r != 0
+	case ">":
+		return r > 0
+	case ">=":
+		return r >= 0
+	case "<":
+		return r < 0
+	case "<=":
+		return r <= 0
+	default:
+		return false
+	}
+}
+
+func parseArgs(args *[]data.UserArg) (f filter, err error) {
+	if len(*args) != len(triggerArgs) {
+		return f, fmt.Errorf("%d arguments were expected and %d were obtained", len(triggerArgs), len(*args))
+	}
+
+	for i, arg := range *args {
+		content := strings.TrimSpace(arg.Content)
+
+		switch arg.ID {
+		case triggerArgs[0].ID:
+			{
+				if content == "" {
+					return f, fmt.Errorf("argument %d (ID: %s) is empty", i, arg.ID)
+				}
+				f.broker = content
+			}
+		case triggerArgs[1].ID:
+			{
+				err = validateTopic(content)
+				if err != nil {
+					return f, fmt.Errorf("argument %d (ID: %s): %w", i, arg.ID, err)
+				}
+				f.topic = content
+			}
+		case triggerArgs[2].ID:
+			f.payload = content
+		case triggerArgs[3].ID:
+			{
+				if content == "" {
+					continue
+				}
+
+				f.conditions, err = parseConditions(content)
+				if err != nil {
+					return f, fmt.Errorf("argument %d (ID: %s): %w", i, arg.ID, err)
+				}
+			}
+		default:
+			return f, shared.ErrUnrecognizedArgID
+		}
+	}
+
+	return f, nil
+}
+
+// validateTopic checks the format of a topic filter: the wildcard '+' must occupy an entire level, and '#' must be
+// the last level.
+func validateTopic(topic string) error {
+	if topic == "" {
+		return fmt.Errorf("the topic is empty")
+	}
+
+	levels := strings.Split(topic, "/")
+	for i, level := range levels {
+		if strings.Contains(level, "#") && (level != "#" || i != len(levels)-1) {
+			return fmt.Errorf("the wildcard '#' must be the last level of the topic '%s'", topic)
+		}
+
+		if strings.Contains(level, "+") && level != "+" {
+			return fmt.Errorf("the wildcard '+' must occupy an entire level of the topic '%s'", topic)
+		}
+	}
+
+	return nil
+}
+
+func parseConditions(content string) ([]condition, error) {
+	var fields map[string]string
+	err := json.Unmarshal([]byte(content), &fields)
+	if err != nil {
+		return nil, fmt.Errorf("the conditions must be a JSON object of strings: %w", err)
+	}
+
+	var conditions []condition
+	for path, expression := range fields {
+		expression = strings.TrimSpace(expression)
+
+		c := condition{path: strings.Split(path, ".")}
+		for _, operator := range operators {
+			if strings.HasPrefix(expression, operator) {
+				c.operator = operator
+				c.value = strings.TrimSpace(strings.TrimPrefix(expression, operator))
+				break
+			}
+		}
+
+		if c.operator == "" {
+			return nil, fmt.Errorf("the condition '%s' of the field '%s' doesn't start with an operator",
+				expression, path)
+		}
+
+		conditions = append(conditions, c)
+	}
+
+	return conditions, nil
+}
@@ -0,0 +1,166 @@
+package mqtt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Pegasus8/piworker/core/configs"
+	"github.com/Pegasus8/piworker/core/data"
+	"github.com/Pegasus8/piworker/core/elements/triggers/shared"
+	"github.com/Pegasus8/piworker/core/types"
+	test "github.com/Pegasus8/piworker/utilities/testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessage(t *testing.T) {
+	assert := assert.New(t)
+
+	broker, err := newTestBroker()
+	require.NoError(t, err)
+	defer broker.close()
+
+	trigger := New([]configs.MQTTBroker{{Name: "home", URL: broker.url()}}).Trigger()
+	test.CheckTFields(t, trigger)
+
+	messageArgs := func(broker, topic, payload, conditions string) []data.UserArg {
+		return []data.UserArg{
+			{ID: trigger.Args[0].ID, Content: broker},
+			{ID: trigger.Args[1].ID, Content: topic},
+			{ID: trigger.Args[2].ID, Content: payload},
+			{ID: trigger.Args[3].ID, Content: conditions},
+		}
+	}
+
+	incorrectArgs := [][]data.UserArg{
+		// The broker is empty.
+		messageArgs("", "home/door", "", ""),
+		// The topic is empty.
+		messageArgs("home", "", "", ""),
+		// The wildcard '#' isn't the last level.
+		messageArgs("home", "home/#/door", "", ""),
+		// The wildcard '+' doesn't occupy an entire level.
+		messageArgs("home", "home/door+", "", ""),
+		// The conditions aren't a JSON object.
+		messageArgs("home", "home/door", "", `["> 30"]`),
+		// The condition doesn't have an operator.
+		messageArgs("home", "home/door", "", `{"temperature": "30"}`),
+		// There are no arguments (should be four).
+		{},
+	}
+
+	for i, args := range incorrectArgs {
+		assert.Errorf(trigger.Validate(&args), "[args %d] the validation must fail", i)
+
+		_, err := trigger.Subscribe(&args, uuid.New().String())
+		assert.Errorf(err, "[args %d] the subscription must fail", i)
+	}
+
+	args := messageArgs("office", "home/door", "", "")
+	assert.NoError(trigger.Validate(&args), "the broker is only checked by the subscription")
+	_, err = trigger.Subscribe(&args, uuid.New().String())
+	assert.Error(err, "the subscription to a broker not configured must fail")
+
+	activated := func(c <-chan shared.Activation, payload string) {
+		t.Helper()
+
+		select {
+		case a := <-c:
+			assert.Equal(payload, a.Result, "the payload must be given as chained result")
+			assert.Equal(types.GetType(payload), a.ResultType)
+		case <-time.After(2 * time.Second):
+			assert.Failf("trigger not activated", "the message '%s' must activate the trigger", payload)
+		}
+	}
+	notActivated := func(c <-chan shared.Activation) {
+		t.Helper()
+
+		select {
+		case a := <-c:
+			assert.Failf("trigger activated", "the message '%s' must not activate the trigger", a.Result)
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+	waitSubscribed := func(topics ...string) {
+		t.Helper()
+
+		for _, topic := range topics {
+			assert.Eventuallyf(func() bool { return broker.subscribed(topic) }, 5*time.Second,
+				10*time.Millisecond, "the topic '%s' must be subscribed", topic)
+		}
+	}
+
+	hot, door, all := uuid.New().String(), uuid.New().String(), uuid.New().String()
+
+	args = messageArgs("home", "home/+/temperature", "", `{"value": "> 30", "sensor.unit": "== C"}`)
+	hotC, err := trigger.Subscribe(&args, hot)
+	assert.NoError(err)
+	defer trigger.Unsubscribe(hot)
+
+	args = messageArgs("home", "home/door", "open", "")
+	doorC, err := trigger.Subscribe(&args, door)
+	assert.NoError(err)
+	defer trigger.Unsubscribe(door)
+
+	args = messageArgs("home", "home/#", "", "")
+	allC, err := trigger.Subscribe(&args, all)
+	assert.NoError(err)
+
+	waitSubscribed("home/+/temperature", "home/door", "home/#")
+	assert.Equal(1, broker.connectedClients(), "the connection to the broker must be shared by the tasks")
+
+	hotMsg := `{"value": 35.5, "sensor": {"unit": "C"}}`
+	broker.publish("home/kitchen/temperature", []byte(hotMsg))
+	activated(hotC, hotMsg)
+	activated(allC, hotMsg)
+	notActivated(doorC)
+
+	for _, msg := range []string{
+		// The value doesn't meet the condition.
+		`{"value": 20, "sensor": {"unit": "C"}}`,
+		// The value isn't a number.
+		`{"value": "hot", "sensor": {"unit": "C"}}`,
+		// The nested field doesn't exist.
+		`{"value": 35}`,
+		// The payload isn't a JSON object.
+		"35",
+	} {
+		broker.publish("home/kitchen/temperature", []byte(msg))
+		activated(allC, msg)
+		notActivated(hotC)
+	}
+
+	broker.publish("home/door", []byte("closed"))
+	activated(allC, "closed")
+	notActivated(doorC)
+
+	broker.publish("home/door", []byte("open"))
+	activated(doorC, "open")
+	activated(allC, "open")
+
+	broker.publish("office/door", []byte("open"))
+	notActivated(doorC)
+	notActivated(allC)
+
+	// The topics are subscribed again once the connection is recovered.
+	broker.dropClients()
+	waitSubscribed("home/+/temperature", "home/door", "home/#")
+
+	broker.publish("home/door", []byte("open"))
+	activated(doorC, "open")
+	activated(allC, "open")
+
+	// The topic isn't used by any other task, so it's unsubscribed.
+	trigger.Unsubscribe(all)
+	_, open := <-allC
+	assert.False(open, "the channel must be closed after the unsubscription")
+	assert.Eventually(func() bool { return !broker.subscribed("home/#") }, 5*time.Second, 10*time.Millisecond)
+
+	// The connection is closed once there are no tasks using it.
+	trigger.Unsubscribe(hot)
+	trigger.Unsubscribe(door)
+	assert.Eventually(func() bool { return broker.connectedClients() == 0 }, 5*time.Second, 10*time.Millisecond,
+		"the connection must be closed when no task uses it")
+}
@@ -0,0 +1,51 @@
+package resources
+
+import (
+	"time"
+
+	"github.com/Pegasus8/piworker/core/data"
+	"github.com/Pegasus8/piworker/core/elements/triggers/shared"
+)
+
+const cpuTriggerID = "T9"
+
+var cpuArgs = shared.ThresholdArgs(cpuTriggerID, "The usage of the CPU, in percent,")
+
+// CPUUsage - Trigger
+var CPUUsage = shared.Trigger{
+	ID:   cpuTriggerID,
+	Name: "CPU Usage",
+	Description: "The trigger will be activated when the usage of the CPU of the host (in percent) crosses the " +
+		"threshold.",
+	Run:      cpuTrigger,
+	Args:     cpuArgs,
+	Validate: validateCPU,
+}
+
+var cpuStates = shared.NewThresholdStates()
+
+func cpuTrigger(args *[]data.UserArg, parentTaskID string, now time.Time) (bool, error) {
+	threshold, rest, err := parseArgs(args, cpuArgs)
+	if err != nil {
+		return false, err
+	}
+	if len(rest) > 0 {
+		return false, shared.ErrUnrecognizedArgID
+	}
+
+	usage, err := readCPUUsage()
+	if err != nil {
+		return false, err
+	}
+
+	return cpuStates.Evaluate(parentTaskID, threshold, usage, now), nil
+}
+
+func validateCPU(args *[]data.UserArg) error {
+	_, rest, err := parseArgs(args, cpuArgs)
+	if err == nil && len(rest) > 0 {
+		return shared.ErrUnrecognizedArgID
+	}
+
+	return err
+}
@@ -0,0 +1,73 @@
+package resources
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Pegasus8/piworker/core/data"
+	"github.com/Pegasus8/piworker/core/elements/triggers/shared"
+	"github.com/Pegasus8/piworker/core/types"
+)
+
+const diskTriggerID = "T11"
+
+var diskArgs = append(shared.ThresholdArgs(diskTriggerID, "The storage used, in percent,"), shared.Arg{
+	ID:          diskTriggerID + "-5",
+	Name:        "Mount Point",
+	Description: "The mount point of the file system. Example: '/media/usb'. If empty, '/' is used.",
+	ContentType: types.Path,
+})
+
+// DiskUsage - Trigger
+var DiskUsage = shared.Trigger{
+	ID:   diskTriggerID,
+	Name: "Disk Usage",
+	Description: "The trigger will be activated when the storage used (in percent) of a file system of the host " +
+		"crosses the threshold.",
+	Run:      diskTrigger,
+	Args:     diskArgs,
+	Validate: validateDisk,
+}
+
+var diskStates = shared.NewThresholdStates()
+
+func diskTrigger(args *[]data.UserArg, parentTaskID string, now time.Time) (bool, error) {
+	threshold, mountPoint, err := parseDiskArgs(args)
+	if err != nil {
+		return false, err
+	}
+
+	usage, err := readDiskUsage(mountPoint)
+	if err != nil {
+		return false, err
+	}
+
+	return diskStates.Evaluate(parentTaskID, threshold, usage, now), nil
+}
+
+func validateDisk(args *[]data.UserArg) error {
+	_, _, err := parseDiskArgs(args)
+
+	return err
+}
+
+func parseDiskArgs(args *[]data.UserArg) (threshold shared.Threshold, mountPoint string, err error) {
+	threshold, rest, err := parseArgs(args, diskArgs)
+	if err != nil {
+		return threshold, "", err
+	}
+
+	mountPoint = "/"
+	for _, arg := range rest {
+		if arg.ID != diskArgs[4].ID {
+			return threshold, "", shared.ErrUnrecognizedArgID
+		}
+
+		if content := strings.TrimSpace(arg.Content); content != "" {
+			mountPoint = filepath.Clean(content)
+		}
+	}
+
+	return threshold, mountPoint, nil
+}
@@ -0,0 +1,89 @@
+package resources
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Pegasus8/piworker/core/data"
+	"github.com/Pegasus8/piworker/core/elements/triggers/shared"
+	"github.com/Pegasus8/piworker/core/types"
+)
+
+const loadTriggerID = "T12"
+
+var loadArgs = append(shared.ThresholdArgs(loadTriggerID, "The load average"), shared.Arg{
+	ID:   loadTriggerID + "-5",
+	Name: "Period",
+	Description: "The period of the load average, in minutes. Must be '1', '5' or '15'. If empty, '1' is " +
+		"used.",
+	ContentType: types.Int,
+})
+
+// LoadAverage - Trigger
+var LoadAverage = shared.Trigger{
+	ID:   loadTriggerID,
+	Name: "Load Average",
+	Description: "The trigger will be activated when the load average of the host (the average number of " +
+		"processes running or waiting for the CPU) crosses the threshold.",
+	Run:      loadTrigger,
+	Args:     loadArgs,
+	Validate: validateLoad,
+}
+
+var loadStates = shared.NewThresholdStates()
+
+func loadTrigger(args *[]data.UserArg, parentTaskID string, now time.Time) (bool, error) {
+	threshold, period, err := parseLoadArgs(args)
+	if err != nil {
+		return false, err
+	}
+
+	avg, err := readLoadAverage()
+	if err != nil {
+		return false, err
+	}
+
+	var value float64
+	switch period {
+	case "1":
+		value = avg.Load1
+	case "5":
+		value = avg.Load5
+	case "15":
+		value = avg.Load15
+	}
+
+	return loadStates.Evaluate(parentTaskID, threshold, value, now), nil
+}
+
+func validateLoad(args *[]data.UserArg) error {
+	_, _, err := parseLoadArgs(args)
+
+	return err
+}
+
+func parseLoadArgs(args *[]data.UserArg) (threshold shared.Threshold, period string, err error) {
+	threshold, rest, err := parseArgs(args, loadArgs)
+	if err != nil {
+		return threshold, "", err
+	}
+
+	period = "1"
+	for _, arg := range rest {
+		if arg.ID != loadArgs[4].ID {
+			return threshold, "", shared.ErrUnrecognizedArgID
+		}
+
+		content := strings.TrimSpace(arg.Content)
+		switch content {
+		case "":
+		case "1", "5", "15":
+			period = content
+		default:
+			return threshold, "", fmt.Errorf("unrecognized period '%s', must be '1', '5' or '15'", arg.Content)
+		}
+	}
+
+	return threshold, period, nil
+}
@@ -0,0 +1,51 @@
+package resources
+
+import (
+	"time"
+
+	"github.com/Pegasus8/piworker/core/data"
+	"github.com/Pegasus8/piworker/core/elements/triggers/shared"
+)
+
+const ramTriggerID = "T10"
+
+var ramArgs = shared.ThresholdArgs(ramTriggerID, "The available RAM, in MB,")
+
+// AvailableRAM - Trigger
+var AvailableRAM = shared.Trigger{
+	ID:   ramTriggerID,
+	Name: "Available RAM",
+	Description: "The trigger will be activated when the RAM available on the host (in MB) crosses the threshold. " +
+		"Usually used with the comparison 'below', to react when the memory is running out.",
+	Run:      ramTrigger,
+	Args:     ramArgs,
+	Validate: validateRAM,
+}
+
+var ramStates = shared.NewThresholdStates()
+
+func ramTrigger(args *[]data.UserArg, parentTaskID string, now time.Time) (bool, error) {
+	threshold, rest, err := parseArgs(args, ramArgs)
+	if err != nil {
+		return false, err
+	}
+	if len(rest) > 0 {
+		return false, shared.ErrUnrecognizedArgID
+	}
+
+	available, err := readAvailableRAM()
+	if err != nil {
+		return false, err
+	}
+
+	return ramStates.Evaluate(parentTaskID, threshold, available, now), nil
+}
+
+func validateRAM(args *[]data.UserArg) error {
+	_, rest, err := parseArgs(args, ramArgs)
+	if err == nil && len(rest) > 0 {
+		return shared.ErrUnrecognizedArgID
+	}
+
+	return err
+}
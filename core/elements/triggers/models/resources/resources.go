@@ -0,0 +1,58 @@
+// Package resources implements the triggers that react to the usage of the resources of the host (CPU, RAM, storage
+// and load average), each one activated when its value crosses a `shared.Threshold`.
+package resources
+
+import (
+	"fmt"
+
+	"github.com/Pegasus8/piworker/core/data"
+	"github.com/Pegasus8/piworker/core/elements/triggers/shared"
+
+	"github.com/shirou/gopsutil/cpu"
+	"github.com/shirou/gopsutil/disk"
+	"github.com/shirou/gopsutil/load"
+	"github.com/shirou/gopsutil/mem"
+)
+
+// The readers of the resources, replaced to test the triggers.
+var (
+	// readCPUUsage returns the usage of the CPU (in percent) since its previous call.
+	readCPUUsage = func() (float64, error) {
+		percent, err := cpu.Percent(0, false)
+		if err != nil {
+			return 0, err
+		}
+
+		return percent[0], nil
+	}
+	// readAvailableRAM returns the available RAM, in MB.
+	readAvailableRAM = func() (float64, error) {
+		vms, err := mem.VirtualMemory()
+		if err != nil {
+			return 0, err
+		}
+
+		return float64(vms.Available) / (1 << 20), nil
+	}
+	// readDiskUsage returns the storage used (in percent) of the file system mounted on `mountPoint`.
+	readDiskUsage = func(mountPoint string) (float64, error) {
+		d, err := disk.Usage(mountPoint)
+		if err != nil {
+			return 0, err
+		}
+
+		return d.UsedPercent, nil
+	}
+	// readLoadAverage returns the load average of the system.
+	readLoadAverage = load.Avg
+)
+
+// parseArgs returns the threshold configured by the arguments and the rest of them, specific of each trigger.
+func parseArgs(args *[]data.UserArg, triggerArgs []shared.Arg) (shared.Threshold, []data.UserArg, error) {
+	if len(*args) != len(triggerArgs) {
+		return shared.Threshold{}, nil,
+			fmt.Errorf("%d arguments were expected and %d were obtained", len(triggerArgs), len(*args))
+	}
+
+	return shared.ParseThreshold(args, triggerArgs)
+}
@@ -0,0 +1,117 @@
+package resources
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Pegasus8/piworker/core/data"
+	"github.com/Pegasus8/piworker/core/elements/triggers/shared"
+	"github.com/Pegasus8/piworker/utilities/clock"
+	test "github.com/Pegasus8/piworker/utilities/testing"
+
+	"github.com/google/uuid"
+	"github.com/shirou/gopsutil/load"
+	"github.com/stretchr/testify/assert"
+)
+
+// thresholdArgs returns the arguments of the trigger with the given threshold, followed by the extra ones.
+func thresholdArgs(trigger shared.Trigger, limit, comparison, sustainedFor, hysteresis string, extra ...string) []data.UserArg {
+	args := []data.UserArg{
+		{ID: trigger.Args[0].ID, Content: limit},
+		{ID: trigger.Args[1].ID, Content: comparison},
+		{ID: trigger.Args[2].ID, Content: sustainedFor},
+		{ID: trigger.Args[3].ID, Content: hysteresis},
+	}
+	for i, content := range extra {
+		args = append(args, data.UserArg{ID: trigger.Args[4+i].ID, Content: content})
+	}
+
+	return args
+}
+
+func TestResources(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, trigger := range []shared.Trigger{CPUUsage, AvailableRAM, DiskUsage, LoadAverage} {
+		test.CheckTFields(t, trigger)
+	}
+
+	fake := clock.NewFake(time.Date(2021, 3, 5, 12, 0, 0, 0, time.Local))
+
+	var cpuUsage, availableRAM, diskUsage float64
+	var readMountPoint string
+	var readErr error
+	readCPUUsage = func() (float64, error) { return cpuUsage, readErr }
+	readAvailableRAM = func() (float64, error) { return availableRAM, readErr }
+	readDiskUsage = func(mountPoint string) (float64, error) {
+		readMountPoint = mountPoint
+		return diskUsage, readErr
+	}
+	readLoadAverage = func() (*load.AvgStat, error) { return &load.AvgStat{Load1: 0.5, Load5: 2, Load15: 4}, readErr }
+
+	run := func(trigger shared.Trigger, args []data.UserArg, taskID string) bool {
+		r, err := trigger.Run(&args, taskID, fake.Now())
+		assert.NoErrorf(err, "the trigger %s must not return an error", trigger.ID)
+
+		return r
+	}
+
+	// CPU: activated after 1 minute above 90%, rearmed below 80%.
+	taskID := uuid.New().String()
+	args := thresholdArgs(CPUUsage, "90", "above", "1m", "10")
+	cpuUsage = 95
+	assert.False(run(CPUUsage, args, taskID), "the usage must be sustained")
+	fake.Advance(time.Minute)
+	assert.True(run(CPUUsage, args, taskID))
+	cpuUsage = 85
+	assert.False(run(CPUUsage, args, taskID))
+	cpuUsage = 95
+	assert.False(run(CPUUsage, args, taskID), "the trigger must not flap inside the hysteresis")
+
+	// RAM: activated when below 100 MB.
+	args = thresholdArgs(AvailableRAM, "100", "below", "", "")
+	availableRAM = 500
+	assert.False(run(AvailableRAM, args, taskID))
+	availableRAM = 50
+	assert.True(run(AvailableRAM, args, taskID))
+
+	// Disk: on the given mount point, or on the root if empty.
+	args = thresholdArgs(DiskUsage, "90", "", "", "", "/media/usb/")
+	diskUsage = 91
+	assert.True(run(DiskUsage, args, taskID))
+	assert.Equal("/media/usb", readMountPoint)
+	args = thresholdArgs(DiskUsage, "90", "", "", "", "")
+	assert.True(run(DiskUsage, args, uuid.New().String()))
+	assert.Equal("/", readMountPoint)
+
+	// Load average: of the chosen period.
+	args = thresholdArgs(LoadAverage, "1", "", "", "", "")
+	assert.False(run(LoadAverage, args, taskID), "the load average of 1 minute must be used by default")
+	args = thresholdArgs(LoadAverage, "3", "", "", "", "15")
+	assert.True(run(LoadAverage, args, uuid.New().String()))
+
+	incorrectArgs := []struct {
+		trigger shared.Trigger
+		args    []data.UserArg
+	}{
+		{CPUUsage, thresholdArgs(CPUUsage, "", "", "", "")},
+		{CPUUsage, thresholdArgs(CPUUsage, "90", "over", "", "")},
+		{AvailableRAM, append(thresholdArgs(AvailableRAM, "90", "", "", ""), data.UserArg{ID: "T10-5", Content: "extra"})},
+		{DiskUsage, thresholdArgs(DiskUsage, "90", "", "", "")},
+		{LoadAverage, thresholdArgs(LoadAverage, "2", "", "", "", "10")},
+		{LoadAverage, []data.UserArg{}},
+	}
+	for i, c := range incorrectArgs {
+		assert.Errorf(c.trigger.Validate(&c.args), "[args %d] the validation must fail", i)
+
+		r, err := c.trigger.Run(&c.args, taskID, fake.Now())
+		assert.Errorf(err, "[args %d] the trigger must return an error", i)
+		assert.Falsef(r, "[args %d] the trigger must not be activated", i)
+	}
+
+	readErr = errors.New("unavailable")
+	args = thresholdArgs(CPUUsage, "90", "", "", "")
+	_, err := CPUUsage.Run(&args, uuid.New().String(), fake.Now())
+	assert.Equal(readErr, err, "the error of the reader must be returned")
+}
@@ -0,0 +1,191 @@
+package taskchain
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/Pegasus8/piworker/core/data"
+	"github.com/Pegasus8/piworker/core/elements/triggers/shared"
+	"github.com/Pegasus8/piworker/core/types"
+)
+
+const triggerID = "T7"
+
+// Outcome is the result of the execution of the referenced task that activates the trigger.
+type Outcome string
+
+const (
+	// Success activates the trigger when the referenced task is executed correctly.
+	Success Outcome = "success"
+	// Failure activates the trigger when the execution of the referenced task fails.
+	Failure Outcome = "failure"
+	// Any activates the trigger every time that the referenced task finishes its execution.
+	Any Outcome = "any"
+)
+
+var triggerArgs = []shared.Arg{
+	{
+		ID:          triggerID + "-1",
+		Name:        "Task ID",
+		Description: "The ID of the task whose finalization activates the trigger.",
+		ContentType: types.Text,
+	},
+	{
+		ID:   triggerID + "-2",
+		Name: "Outcome",
+		Description: "The outcome of the execution of the task that activates the trigger. Must be 'success'," +
+			" 'failure' or 'any'. If empty, 'success' is used.",
+		ContentType: types.Text,
+	},
+	{
+		ID:   triggerID + "-3",
+		Name: "Pass Result",
+		Description: "If 'true', the result of the last action of the referenced task (or its error, if it" +
+			" failed) is given to the first action of this task as chained result.",
+		ContentType: types.Bool,
+	},
+}
+
+type chain struct {
+	upstreamTaskID string
+	outcome        Outcome
+	passResult     bool
+}
+
+// Chains keeps the tasks subscribed to the trigger, which are activated by `TaskFinished`. Each registry of elements
+// has its own.
+type Chains struct {
+	subscriptions *shared.Subscriptions
+	// tasks keeps the configuration of the subscribed tasks.
+	tasks map[string]chain
+	sync.Mutex
+}
+
+// New returns the chains without subscribed tasks.
+func New() *Chains {
+	return &Chains{
+		subscriptions: shared.NewSubscriptions(),
+		tasks:         make(map[string]chain),
+	}
+}
+
+// Trigger returns the trigger, whose subscriptions are kept by `chains`.
+func (chains *Chains) Trigger() shared.Trigger {
+	return shared.Trigger{
+		ID:   triggerID,
+		Name: "Task Chain",
+		Description: "The trigger will be activated when another task finishes its execution with the chosen" +
+			" outcome. For example, to run an upload task after the success of a backup task.",
+		Args:        triggerArgs,
+		Validate:    validate,
+		Subscribe:   chains.subscribe,
+		Unsubscribe: chains.unsubscribe,
+	}
+}
+
+func (chains *Chains) subscribe(args *[]data.UserArg, parentTaskID string) (<-chan shared.Activation, error) {
+	c, err := parseArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.upstreamTaskID == parentTaskID {
+		return nil, fmt.Errorf("the task can't be chained to itself")
+	}
+
+	chains.Lock()
+	defer chains.Unlock()
+
+	chains.tasks[parentTaskID] = c
+
+	return chains.subscriptions.Add(parentTaskID), nil
+}
+
+func (chains *Chains) unsubscribe(parentTaskID string) {
+	chains.Lock()
+	defer chains.Unlock()
+
+	delete(chains.tasks, parentTaskID)
+	chains.subscriptions.Remove(parentTaskID)
+}
+
+func validate(args *[]data.UserArg) error {
+	_, err := parseArgs(args)
+
+	return err
+}
+
+// TaskFinished must be called every time that the execution of a task finishes, to activate the tasks chained to it.
+// `result` is the chained result returned by the last action of the task, or the error if the execution failed.
+func (chains *Chains) TaskFinished(taskID string, successful bool, result string, resultType types.PWType) {
+	chains.Lock()
+	defer chains.Unlock()
+
+	for downstreamTaskID, c := range chains.tasks {
+		if c.upstreamTaskID != taskID {
+			continue
+		}
+
+		if (c.outcome == Success && !successful) || (c.outcome == Failure && successful) {
+			continue
+		}
+
+		activation := shared.Activation{}
+		if c.passResult {
+			activation.Result = result
+			activation.ResultType = resultType
+		}
+
+		chains.subscriptions.Notify(downstreamTaskID, activation)
+	}
+}
+
+func parseArgs(args *[]data.UserArg) (c chain, err error) {
+	if len(*args) != len(triggerArgs) {
+		return c, fmt.Errorf("%d arguments were expected and %d were obtained", len(triggerArgs), len(*args))
+	}
+
+	c.outcome = Success
+
+	for i, arg := range *args {
+		content := strings.TrimSpace(arg.Content)
+
+		switch arg.ID {
+		case triggerArgs[0].ID:
+			{
+				if content == "" {
+					return c, fmt.Errorf("argument %d (ID: %s) is empty", i, arg.ID)
+				}
+				c.upstreamTaskID = content
+			}
+		case triggerArgs[1].ID:
+			{
+				if content == "" {
+					continue
+				}
+
+				c.outcome = Outcome(strings.ToLower(content))
+				if c.outcome != Success && c.outcome != Failure && c.outcome != Any {
+					return c, fmt.Errorf("unrecognized outcome '%s'", arg.Content)
+				}
+			}
+		case triggerArgs[2].ID:
+			{
+				if content == "" {
+					continue
+				}
+
+				var isBool bool
+				isBool, c.passResult = types.IsBool(content)
+				if !isBool {
+					return c, fmt.Errorf("argument %d (ID: %s) must be a boolean", i, arg.ID)
+				}
+			}
+		default:
+			return c, shared.ErrUnrecognizedArgID
+		}
+	}
+
+	return c, nil
+}
@@ -0,0 +1,100 @@
+package taskchain
+
+import (
+	"testing"
+
+	"github.com/Pegasus8/piworker/core/data"
+	"github.com/Pegasus8/piworker/core/types"
+	test "github.com/Pegasus8/piworker/utilities/testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaskChain(t *testing.T) {
+	assert := assert.New(t)
+	upstreamTaskID := uuid.New().String()
+
+	chains := New()
+	trigger := chains.Trigger()
+	test.CheckTFields(t, trigger)
+
+	chainArgs := func(taskID, outcome, passResult string) []data.UserArg {
+		return []data.UserArg{
+			{ID: trigger.Args[0].ID, Content: taskID},
+			{ID: trigger.Args[1].ID, Content: outcome},
+			{ID: trigger.Args[2].ID, Content: passResult},
+		}
+	}
+
+	incorrectArgs := [][]data.UserArg{
+		// The ID of the task is empty.
+		chainArgs("", "success", "false"),
+		// Unrecognized outcome.
+		chainArgs(upstreamTaskID, "finished", "false"),
+		// Not a boolean.
+		chainArgs(upstreamTaskID, "any", "maybe"),
+		// There are no arguments (should be three).
+		{},
+	}
+
+	for i, args := range incorrectArgs {
+		assert.Errorf(trigger.Validate(&args), "[args %d] the validation must fail", i)
+
+		_, err := trigger.Subscribe(&args, uuid.New().String())
+		assert.Errorf(err, "[args %d] the subscription must fail", i)
+	}
+
+	args := chainArgs(upstreamTaskID, "", "")
+	assert.NoError(trigger.Validate(&args), "a correct configuration must pass the validation")
+
+	_, err := trigger.Subscribe(&args, upstreamTaskID)
+	assert.Error(err, "a task can't be chained to itself")
+
+	// Three tasks chained to the same one, each one waiting for a different outcome.
+	onSuccess, onFailure, onAny := uuid.New().String(), uuid.New().String(), uuid.New().String()
+
+	successArgs := chainArgs(upstreamTaskID, "", "")
+	successC, err := trigger.Subscribe(&successArgs, onSuccess)
+	assert.NoError(err)
+	defer trigger.Unsubscribe(onSuccess)
+
+	failureArgs := chainArgs(upstreamTaskID, "FAILURE", "true")
+	failureC, err := trigger.Subscribe(&failureArgs, onFailure)
+	assert.NoError(err)
+	defer trigger.Unsubscribe(onFailure)
+
+	anyArgs := chainArgs(upstreamTaskID, "any", "true")
+	anyC, err := trigger.Subscribe(&anyArgs, onAny)
+	assert.NoError(err)
+	defer trigger.Unsubscribe(onAny)
+
+	// Other tasks must not activate the trigger.
+	chains.TaskFinished(uuid.New().String(), true, "", "")
+	assert.Len(successC, 0)
+	assert.Len(anyC, 0)
+
+	chains.TaskFinished(upstreamTaskID, true, "/tmp/backup.zip", types.Path)
+	if assert.Len(successC, 1, "the trigger must be activated by the success of the task") {
+		a := <-successC
+		assert.Empty(a.Result, "the result must not be given if it's not required")
+	}
+	assert.Len(failureC, 0, "the trigger must not be activated by the success of the task")
+	if assert.Len(anyC, 1, "the trigger must be activated by any outcome") {
+		a := <-anyC
+		assert.Equal("/tmp/backup.zip", a.Result, "the result of the task must be given")
+		assert.Equal(types.Path, a.ResultType)
+	}
+
+	chains.TaskFinished(upstreamTaskID, false, "exit status 1", types.Text)
+	assert.Len(successC, 0, "the trigger must not be activated by the failure of the task")
+	if assert.Len(failureC, 1, "the trigger must be activated by the failure of the task") {
+		assert.Equal("exit status 1", (<-failureC).Result, "the error must be given")
+	}
+	assert.Len(anyC, 1, "the trigger must be activated by any outcome")
+
+	// Once unsubscribed, the channel is closed.
+	trigger.Unsubscribe(onSuccess)
+	_, open := <-successC
+	assert.False(open, "the channel must be closed after the unsubscription")
+}
@@ -1,106 +1,80 @@
 package temp
 
 import (
-	"errors"
 	"fmt"
-	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Pegasus8/piworker/core/data"
 	"github.com/Pegasus8/piworker/core/elements/triggers/shared"
 	"github.com/Pegasus8/piworker/core/types"
-
-	"github.com/shirou/gopsutil/host"
 )
 
 const triggerID = "T2"
 
-var triggerArgs = []shared.Arg{
-	{
-		ID:   triggerID + "-1",
-		Name: "Expected Temperature",
-		Description: "The expected temperature of the host. Must be in" +
-			" float format and without the 'ºC'. Example: 55.1.",
-		ContentType: types.Float,
+var triggerArgs = append(shared.ThresholdArgs(triggerID, "The temperature of the host, in ºC and without the 'ºC',"),
+	shared.Arg{
+		ID:   triggerID + "-5",
+		Name: "Sensor Key",
+		Description: "The key of the sensor to use. If empty, the sensor of the CPU is used (or the first one " +
+			"found if it can't be identified).",
+		ContentType: types.Text,
 	},
-}
+)
 
 // RaspberryTemperature - Trigger
 var RaspberryTemperature = shared.Trigger{
-	ID:          triggerID,
-	Name:        "Raspberry's Temperature",
-	Description: "If the temperature of the host equals or exceeds the given number, the trigger will be activated.",
-	Run:         trigger,
-	Args:        triggerArgs,
+	ID:   triggerID,
+	Name: "Raspberry's Temperature",
+	Description: "If the temperature of the host crosses the threshold, the trigger will be activated. For " +
+		"example, to turn on a fan when the temperature exceeds 60ºC and turn it off when it goes below 50ºC.",
+	Run:      trigger,
+	Args:     triggerArgs,
+	Validate: validate,
 }
 
-var arch string
-
-func trigger(args *[]data.UserArg, parentTaskID string) (result bool, err error) {
-	if len(*args) != len(triggerArgs) {
-		return false, fmt.Errorf("%d arguments were expected and %d were obtained", len(triggerArgs), len(*args))
-	}
-
-	// Expected temperature received
-	var expectedTemp float64
+var states = shared.NewThresholdStates()
 
-	for i, arg := range *args {
-		if arg.Content == "" {
-			return false, fmt.Errorf("argument %d (ID: %s) is empty", i, arg.ID)
-		}
-
-		switch arg.ID {
-		// Temperature arg
-		case triggerArgs[0].ID:
-			{
-				expectedTemp, err = strconv.ParseFloat(arg.Content, 64)
-				if err != nil {
-					return false, err
-				}
-			}
-
-		default:
-			return false, shared.ErrUnrecognizedArgID
-		}
+func trigger(args *[]data.UserArg, parentTaskID string, now time.Time) (result bool, err error) {
+	threshold, sensorKey, err := parseArgs(args)
+	if err != nil {
+		return false, err
 	}
 
-	st, err := host.SensorsTemperatures()
+	temperature, err := readTemperature(sensorKey)
 	if err != nil {
 		return false, err
 	}
 
-	// Are we on a Raspberry Pi? Let's check it.
-	if arch == "" {
-		hostInfo, err := host.Info()
-		if err != nil {
-			return false, err
-		}
-		arch = hostInfo.KernelArch
-	}
+	return states.Evaluate(parentTaskID, threshold, temperature, now), nil
+}
 
-	var temperature float64
-
-	// Architecture of a Raspberry Pi.
-	if arch == "armv7l" {
-		// If it's a Raspberry Pi then the slice of sensors should be only one.
-		temperature = st[0].Temperature
-	} else {
-		// Not a Raspberry Pi, let's use our known key to find the temperature of the entire CPU.
-		// Note: I'm not sure if this will work on all the machines but unless someone reports that is not
-		// working I can't do anything else.
-		for _, t := range st {
-			if t.SensorKey == "coretemp_packageid0_input" {
-				temperature = t.Temperature
-			}
-		}
+func validate(args *[]data.UserArg) error {
+	_, _, err := parseArgs(args)
+
+	return err
+}
+
+// parseArgs returns the threshold and the key of the sensor configured by the arguments. Only the first one is
+// required, so the tasks created before the addition of the rest keep working.
+func parseArgs(args *[]data.UserArg) (threshold shared.Threshold, sensorKey string, err error) {
+	if len(*args) > len(triggerArgs) {
+		return threshold, "", fmt.Errorf("at most %d arguments were expected and %d were obtained", len(triggerArgs),
+			len(*args))
 	}
 
-	if temperature == 0.0 {
-		return false, errors.New("SensorKey incompatible with host")
+	threshold, rest, err := shared.ParseThreshold(args, triggerArgs)
+	if err != nil {
+		return threshold, "", err
 	}
 
-	if temperature >= expectedTemp {
-		return true, nil
+	for _, arg := range rest {
+		if arg.ID != triggerArgs[4].ID {
+			return threshold, "", shared.ErrUnrecognizedArgID
+		}
+
+		sensorKey = strings.TrimSpace(arg.Content)
 	}
 
-	return false, nil
+	return threshold, sensorKey, nil
 }
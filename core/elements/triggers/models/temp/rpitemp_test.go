@@ -1,8 +1,13 @@
 package temp
 
 import (
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/Pegasus8/piworker/core/data"
 	test "github.com/Pegasus8/piworker/utilities/testing"
@@ -12,10 +17,49 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+// fakeSysfs replaces the sensors of the host by the thermal zones with the given temperatures (in millidegrees), on a
+// fake sysfs root. The returned function restores the real sensors.
+func fakeSysfs(zones ...string) func() {
+	root, err := ioutil.TempDir("", "sysfs")
+	if err != nil {
+		panic(err)
+	}
+
+	for i, temperature := range zones {
+		zone := filepath.Join(root, "class", "thermal", fmt.Sprintf("thermal_zone%d", i))
+		err := os.MkdirAll(zone, 0755)
+		if err != nil {
+			panic(err)
+		}
+
+		err = ioutil.WriteFile(filepath.Join(zone, "temp"), []byte(temperature+"\n"), 0644)
+		if err != nil {
+			panic(err)
+		}
+		err = ioutil.WriteFile(filepath.Join(zone, "type"), []byte(fmt.Sprintf("zone-%d\n", i)), 0644)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	SysfsRoot = root
+	readHostSensors = func() ([]host.TemperatureStat, error) {
+		return nil, nil
+	}
+
+	return func() {
+		os.RemoveAll(root)
+		SysfsRoot = "/sys"
+		readHostSensors = host.SensorsTemperatures
+	}
+}
+
 func TestRaspberryTemperature(t *testing.T) {
 	taskID := uuid.New().String()
 	assert := assert.New(t)
 
+	defer fakeSysfs("48500")()
+
 	test.CheckTFields(t, RaspberryTemperature)
 
 	args := [][]data.UserArg{
@@ -64,7 +108,7 @@ func TestRaspberryTemperature(t *testing.T) {
 		// Expected result: Should return an error and a false result.
 		[]data.UserArg{
 			data.UserArg{
-				ID:      RaspberryTemperature.ID + "-5", // Non-existent ID
+				ID:      RaspberryTemperature.ID + "-6", // Non-existent ID
 				Content: fmt.Sprintf("%e", getTemperature(t)-20.0),
 			},
 		},
@@ -86,7 +130,7 @@ func TestRaspberryTemperature(t *testing.T) {
 	}
 
 	for i, arg := range args[:2] {
-		r, err := RaspberryTemperature.Run(&arg, taskID)
+		r, err := RaspberryTemperature.Run(&arg, taskID, time.Now())
 		if i == 0 {
 			assert.Equalf(true, r, "[arg %d] the trigger must be executed correctly", i)
 		} else {
@@ -96,24 +140,100 @@ func TestRaspberryTemperature(t *testing.T) {
 	}
 
 	for i, arg := range args[2:] {
-		r, err := RaspberryTemperature.Run(&arg, taskID)
+		r, err := RaspberryTemperature.Run(&arg, taskID, time.Now())
 		assert.Equalf(false, r, "[arg %d]the trigger must return a false result if at least one argument is incorrect", i)
 		assert.Errorf(err, "[arg %d] an error must be returned", i)
 	}
 }
 
 func getTemperature(t *testing.T) float64 {
-	st, err := host.SensorsTemperatures()
+	temperature, err := readTemperature("")
 	if err != nil {
-		assert.FailNowf(t, "cannot get sensors temperatures: %s\n", err.Error())
+		assert.FailNowf(t, "cannot get the temperature: %s\n", err.Error())
 	}
 
-	for _, sensor := range st {
-		if sensor.SensorKey == "coretemp_packageid0_input" {
-			return sensor.Temperature
+	return temperature
+}
+
+func TestSensors(t *testing.T) {
+	assert := assert.New(t)
+	taskID := uuid.New().String()
+
+	defer fakeSysfs("61000", "39500")()
+
+	sensors, err := Sensors()
+	assert.NoError(err)
+	assert.Equal([]Sensor{
+		{Key: "thermal_zone0", Name: "zone-0", Temperature: 61},
+		{Key: "thermal_zone1", Name: "zone-1", Temperature: 39.5},
+	}, sensors, "the thermal zones must be discovered")
+
+	// The sensors of the hardware monitors are preferred.
+	readHostSensors = func() ([]host.TemperatureStat, error) {
+		return []host.TemperatureStat{
+			{SensorKey: "acpitz_input", Temperature: 30},
+			{SensorKey: "coretemp_packageid0_input", Temperature: 55},
+		}, errors.New("a sensor has failed")
+	}
+	temperature, err := readTemperature("")
+	assert.NoError(err, "the errors of some sensors must be ignored")
+	assert.Equal(55.0, temperature, "the sensor of the CPU must be used by default")
+	temperature, err = readTemperature("thermal_zone1")
+	assert.NoError(err)
+	assert.Equal(39.5, temperature, "the chosen sensor must be used")
+	_, err = readTemperature("nothing")
+	assert.Error(err, "a sensor that doesn't exist can't be used")
+
+	tempArgs := func(limit, comparison, hysteresis, sensorKey string) []data.UserArg {
+		return []data.UserArg{
+			{ID: RaspberryTemperature.Args[0].ID, Content: limit},
+			{ID: RaspberryTemperature.Args[1].ID, Content: comparison},
+			{ID: RaspberryTemperature.Args[2].ID, Content: ""},
+			{ID: RaspberryTemperature.Args[3].ID, Content: hysteresis},
+			{ID: RaspberryTemperature.Args[4].ID, Content: sensorKey},
 		}
 	}
 
-	assert.FailNow(t, "SensorKey incompatible")
-	return 0.0
+	// A fan task: activated once above 40ºC, again only after going below 35ºC.
+	args := tempArgs("40", "above", "5", "thermal_zone1")
+	readZone1 := func(temperature string) bool {
+		err := ioutil.WriteFile(filepath.Join(SysfsRoot, "class", "thermal", "thermal_zone1", "temp"), []byte(temperature), 0644)
+		if err != nil {
+			panic(err)
+		}
+
+		r, err := RaspberryTemperature.Run(&args, taskID, time.Now())
+		assert.NoError(err)
+
+		return r
+	}
+	assert.False(readZone1("39500"))
+	assert.True(readZone1("40000"))
+	assert.False(readZone1("41000"), "the trigger must not be activated on each execution")
+	assert.False(readZone1("37000"))
+	assert.False(readZone1("40500"), "the trigger must not flap inside the hysteresis")
+	assert.False(readZone1("34000"))
+	assert.True(readZone1("40500"))
+
+	args = tempArgs("35", "below", "", "thermal_zone1")
+	r, err := RaspberryTemperature.Run(&args, uuid.New().String(), time.Now())
+	assert.NoError(err)
+	assert.False(r, "the temperature isn't below the threshold")
+	args = tempArgs("45", "below", "", "thermal_zone1")
+	r, err = RaspberryTemperature.Run(&args, uuid.New().String(), time.Now())
+	assert.NoError(err)
+	assert.True(r, "the temperature is below the threshold")
+
+	args = tempArgs("45", "", "", "nothing")
+	assert.NoError(RaspberryTemperature.Validate(&args))
+	_, err = RaspberryTemperature.Run(&args, taskID, time.Now())
+	assert.Error(err, "a sensor that doesn't exist can't be used")
+
+	// Without sensors.
+	SysfsRoot = filepath.Join(SysfsRoot, "nothing")
+	readHostSensors = func() ([]host.TemperatureStat, error) {
+		return nil, nil
+	}
+	_, err = Sensors()
+	assert.Equal(ErrNoSensors, err)
 }
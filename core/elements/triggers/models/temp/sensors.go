@@ -0,0 +1,138 @@
+package temp
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/shirou/gopsutil/host"
+)
+
+// SysfsRoot is the path where sysfs is mounted, replaced to test the reading of the thermal zones.
+var SysfsRoot = "/sys"
+
+// readHostSensors returns the sensors reported by the hardware monitors of the host, replaced on tests.
+var readHostSensors = host.SensorsTemperatures
+
+// defaultKeys are the keys of the sensors that represent the temperature of the entire CPU, by order of preference.
+// They are used when the user doesn't choose a sensor.
+var defaultKeys = []string{"coretemp_packageid0_input", "thermal_zone0"}
+
+// ErrNoSensors is the error returned when the host doesn't have any temperature sensor.
+var ErrNoSensors = errors.New("no temperature sensors found on the host")
+
+// Sensor is a temperature sensor of the host.
+type Sensor struct {
+	// Key identifies the sensor, it's the content expected by the argument `Sensor Key` of the trigger.
+	Key string `json:"key"`
+	// Name is the description of the sensor given by the system, if any. For example "cpu-thermal".
+	Name        string  `json:"name"`
+	Temperature float64 `json:"temperature"`
+}
+
+// Sensors returns the temperature sensors available on the host: the ones of its hardware monitors and the thermal
+// zones of sysfs.
+func Sensors() ([]Sensor, error) {
+	var sensors []Sensor
+
+	// Some sensors can fail while the rest are read correctly, so the error is only returned if there are no sensors.
+	st, err := readHostSensors()
+	for _, t := range st {
+		sensors = append(sensors, Sensor{Key: t.SensorKey, Temperature: t.Temperature})
+	}
+
+	zones, zonesErr := thermalZones()
+	sensors = append(sensors, zones...)
+
+	if len(sensors) == 0 {
+		if err != nil {
+			return nil, err
+		}
+		if zonesErr != nil {
+			return nil, zonesErr
+		}
+
+		return nil, ErrNoSensors
+	}
+
+	return sensors, nil
+}
+
+// thermalZones reads the thermal zones of sysfs (`<SysfsRoot>/class/thermal/thermal_zone*`), available on most of
+// the ARM boards like the Raspberry Pi.
+func thermalZones() ([]Sensor, error) {
+	paths, err := filepath.Glob(filepath.Join(SysfsRoot, "class", "thermal", "thermal_zone*"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	var zones []Sensor
+	for _, path := range paths {
+		content, err := ioutil.ReadFile(filepath.Join(path, "temp"))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		// The temperature is expressed in millidegrees Celsius.
+		milli, err := strconv.ParseInt(strings.TrimSpace(string(content)), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid temperature on '%s': %w", path, err)
+		}
+
+		name, _ := ioutil.ReadFile(filepath.Join(path, "type"))
+
+		zones = append(zones, Sensor{
+			Key:         filepath.Base(path),
+			Name:        strings.TrimSpace(string(name)),
+			Temperature: float64(milli) / 1000,
+		})
+	}
+
+	return zones, nil
+}
+
+// readTemperature returns the temperature of the sensor with the given key. If the key is empty, the sensor is chosen
+// from `defaultKeys`, or the first one found is used.
+func readTemperature(key string) (float64, error) {
+	sensors, err := Sensors()
+	if err != nil {
+		return 0, err
+	}
+
+	find := func(key string) (Sensor, bool) {
+		for _, s := range sensors {
+			if s.Key == key {
+				return s, true
+			}
+		}
+
+		return Sensor{}, false
+	}
+
+	if key != "" {
+		s, found := find(key)
+		if !found {
+			return 0, fmt.Errorf("the temperature sensor '%s' doesn't exist on the host", key)
+		}
+
+		return s.Temperature, nil
+	}
+
+	for _, k := range defaultKeys {
+		if s, found := find(k); found {
+			return s.Temperature, nil
+		}
+	}
+
+	return sensors[0].Temperature, nil
+}
@@ -35,20 +35,47 @@ var ByTime = shared.Trigger{
 	Description: "",
 	Run:         trigger,
 	Args:        triggerArgs,
+	Missed:      missed,
 }
 
-func trigger(args *[]data.UserArg, parentTaskID string) (result bool, err error) {
+func trigger(args *[]data.UserArg, parentTaskID string, now time.Time) (result bool, err error) {
+	t, err := parseArgs(args)
+	if err != nil {
+		return false, err
+	}
+
+	if now.Format("2006-01-02 15:04") == t.Format("2006-01-02 15:04") {
+		return true, nil
+	}
+
+	return false, nil
+}
+
+func missed(args *[]data.UserArg, from, to time.Time, limit int) ([]time.Time, error) {
+	t, err := parseArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	// The activation in the current minute isn't missed, the trigger is still activated during that minute.
+	if limit > 0 && t.After(from) && t.Before(to.Truncate(time.Minute)) {
+		return []time.Time{t}, nil
+	}
+
+	return nil, nil
+}
+
+// parseArgs returns the moment of the activation, in the timezone of the host.
+func parseArgs(args *[]data.UserArg) (time.Time, error) {
 	if len(*args) != len(triggerArgs) {
-		return false, fmt.Errorf("%d arguments were expected and %d were obtained", len(triggerArgs), len(*args))
+		return time.Time{}, fmt.Errorf("%d arguments were expected and %d were obtained", len(triggerArgs), len(*args))
 	}
 
-	// Contains the time and date received from the arguments.
-	var t time.Time
 	var date, hour string
 
 	for i, arg := range *args {
 		if arg.Content == "" {
-			return false, fmt.Errorf("argument %d (ID: %s) is empty", i, arg.ID)
+			return time.Time{}, fmt.Errorf("argument %d (ID: %s) is empty", i, arg.ID)
 		}
 
 		switch arg.ID {
@@ -57,18 +84,9 @@ func trigger(args *[]data.UserArg, parentTaskID string) (result bool, err error)
 		case triggerArgs[1].ID:
 			hour = arg.Content
 		default:
-			return false, shared.ErrUnrecognizedArgID
+			return time.Time{}, shared.ErrUnrecognizedArgID
 		}
 	}
 
-	t, err = time.Parse("2006-01-02 15:04", date+" "+hour)
-	if err != nil {
-		return false, err
-	}
-
-	if time.Now().Format("2006-01-02 15:04") == t.Format("2006-01-02 15:04") {
-		return true, nil
-	}
-
-	return false, nil
+	return time.ParseInLocation("2006-01-02 15:04", date+" "+hour, time.Local)
 }
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/Pegasus8/piworker/core/data"
+	"github.com/Pegasus8/piworker/utilities/clock"
 	test "github.com/Pegasus8/piworker/utilities/testing"
 
 	"github.com/google/uuid"
@@ -170,7 +171,7 @@ func TestByTime(t *testing.T) {
 	}
 
 	for i, arg := range args[:4] {
-		r, err := ByTime.Run(&arg, taskID)
+		r, err := ByTime.Run(&arg, taskID, time.Now())
 		if i == 0 {
 			assert.Equalf(true, r, "[arg %d] the trigger must be executed correctly", i)
 		} else {
@@ -180,8 +181,61 @@ func TestByTime(t *testing.T) {
 	}
 
 	for i, arg := range args[4:] {
-		r, err := ByTime.Run(&arg, taskID)
+		r, err := ByTime.Run(&arg, taskID, time.Now())
 		assert.Equalf(false, r, "[arg %d]the trigger must return a false result if at least one argument is incorrect", i)
 		assert.Errorf(err, "[arg %d] an error must be returned", i)
 	}
 }
+
+func TestByTimeMissed(t *testing.T) {
+	assert := assert.New(t)
+
+	args := []data.UserArg{
+		{ID: ByTime.Args[0].ID, Content: "2021-03-05"},
+		{ID: ByTime.Args[1].ID, Content: "13:45"},
+	}
+	activation := time.Date(2021, 3, 5, 13, 45, 0, 0, time.Local)
+
+	missed, err := ByTime.Missed(&args, activation.Add(-time.Hour), activation.Add(time.Hour), 10)
+	assert.NoError(err)
+	assert.Equal([]time.Time{activation}, missed, "the activation must be missed")
+
+	missed, err = ByTime.Missed(&args, activation.Add(-time.Hour), activation.Add(30*time.Second), 10)
+	assert.NoError(err)
+	assert.Empty(missed, "the activation of the current minute must be left to the trigger")
+
+	missed, err = ByTime.Missed(&args, activation, activation.Add(time.Hour), 10)
+	assert.NoError(err)
+	assert.Empty(missed, "the activation is previous to the interval")
+
+	missed, err = ByTime.Missed(&args, activation.Add(-time.Hour), activation.Add(-time.Minute), 10)
+	assert.NoError(err)
+	assert.Empty(missed, "the activation is after the interval")
+
+	_, err = ByTime.Missed(&[]data.UserArg{}, activation, activation, 10)
+	assert.Error(err, "the arguments must be checked")
+}
+
+func TestByTimeClock(t *testing.T) {
+	assert := assert.New(t)
+	taskID := uuid.New().String()
+
+	fake := clock.NewFake(time.Date(2021, 3, 5, 13, 44, 30, 0, time.Local))
+
+	args := []data.UserArg{
+		{ID: ByTime.Args[0].ID, Content: "2021-03-05"},
+		{ID: ByTime.Args[1].ID, Content: "13:45"},
+	}
+
+	r, err := ByTime.Run(&args, taskID, fake.Now())
+	assert.NoError(err)
+	assert.False(r, "the trigger must not be activated before the given time")
+
+	fake.Advance(30 * time.Second)
+	r, _ = ByTime.Run(&args, taskID, fake.Now())
+	assert.True(r, "the trigger must be activated at the given time")
+
+	fake.Advance(time.Minute)
+	r, _ = ByTime.Run(&args, taskID, fake.Now())
+	assert.False(r, "the trigger must not be activated after the given time")
+}
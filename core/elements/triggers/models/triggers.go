@@ -1,28 +1,38 @@
 package models
 
 import (
+	"github.com/Pegasus8/piworker/core/elements/triggers/models/composite"
+	"github.com/Pegasus8/piworker/core/elements/triggers/models/cron"
 	"github.com/Pegasus8/piworker/core/elements/triggers/models/everyxtime"
 	"github.com/Pegasus8/piworker/core/elements/triggers/models/fsvariation"
+	"github.com/Pegasus8/piworker/core/elements/triggers/models/fswatch"
+	"github.com/Pegasus8/piworker/core/elements/triggers/models/mqtt"
+	"github.com/Pegasus8/piworker/core/elements/triggers/models/resources"
+	"github.com/Pegasus8/piworker/core/elements/triggers/models/taskchain"
 	"github.com/Pegasus8/piworker/core/elements/triggers/models/temp"
 	"github.com/Pegasus8/piworker/core/elements/triggers/models/time"
+	"github.com/Pegasus8/piworker/core/elements/triggers/models/webhook"
 	"github.com/Pegasus8/piworker/core/elements/triggers/shared"
 )
 
-// TRIGGERS is the main variable used to store all the triggers of PiWorker
-var TRIGGERS = []shared.Trigger{
-	time.ByTime,
-	temp.RaspberryTemperature,
-	fsvariation.VariationOfFileSize,
-	everyxtime.EveryXTime,
-}
-
-// Get is a function that finds and returns a specific trigger.
-func Get(id string) *shared.Trigger {
-	for _, trigger := range TRIGGERS {
-		if trigger.ID == id {
-			return &trigger
-		}
+// Triggers returns the triggers included on PiWorker, registered by `elements.NewDefaultRegistry`. The push triggers
+// keep their subscriptions on the given instances.
+func Triggers(chains *taskchain.Chains, watchers *fswatch.Watchers, hooks *webhook.Hooks,
+	brokers *mqtt.Brokers) []shared.Trigger {
+	return []shared.Trigger{
+		time.ByTime,
+		temp.RaspberryTemperature,
+		fsvariation.VariationOfFileSize,
+		everyxtime.EveryXTime,
+		cron.Cron,
+		composite.Composite,
+		chains.Trigger(),
+		watchers.Trigger(),
+		resources.CPUUsage,
+		resources.AvailableRAM,
+		resources.DiskUsage,
+		resources.LoadAverage,
+		hooks.Trigger(),
+		brokers.Trigger(),
 	}
-
-	return &shared.Trigger{}
 }
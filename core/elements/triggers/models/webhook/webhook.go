@@ -0,0 +1,266 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/Pegasus8/piworker/core/data"
+	"github.com/Pegasus8/piworker/core/elements/triggers/shared"
+	"github.com/Pegasus8/piworker/core/types"
+)
+
+const triggerID = "T13"
+
+const (
+	// SecretHeader is the header of the requests that contains the secret of the hook.
+	SecretHeader = "X-PiWorker-Secret"
+	// SignatureHeader is the header of the requests that contains the HMAC-SHA256 signature of the body, generated
+	// with the secret of the hook, on the format "sha256=<hex>".
+	SignatureHeader = "X-PiWorker-Signature"
+	// GitHubSignatureHeader is the header used by GitHub (and compatible services) to sign the body of their
+	// webhooks, with the same format of `SignatureHeader`.
+	GitHubSignatureHeader = "X-Hub-Signature-256"
+)
+
+var (
+	// ErrUnauthorized is the error returned when the request isn't authenticated by the secret of any of the tasks
+	// subscribed to the hook. It's returned too if no task is subscribed to the hook, so the requests can't tell if
+	// a hook exists without knowing its secret.
+	ErrUnauthorized = errors.New("the request isn't authenticated by the secret of the hook")
+	// ErrInvalidBody is the error returned when the body of an authenticated request isn't a JSON object.
+	ErrInvalidBody = errors.New("the body of the request must be a JSON object")
+)
+
+var hookIDRgx = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+var triggerArgs = []shared.Arg{
+	{
+		ID:   triggerID + "-1",
+		Name: "Hook ID",
+		Description: "The identifier of the hook, the trigger is activated by the POST requests to " +
+			"'/api/hooks/<Hook ID>'. Only letters, numbers, '-' and '_' are allowed. Example: door-sensor",
+		ContentType: types.Text,
+	},
+	{
+		ID:   triggerID + "-2",
+		Name: "Secret",
+		Description: "The secret of the hook. The requests must include it on the header '" + SecretHeader +
+			"', or sign their body with it (HMAC-SHA256) on the header '" + SignatureHeader + "' or '" +
+			GitHubSignatureHeader + "' with the format 'sha256=<signature on hex>'.",
+		ContentType: types.Text,
+	},
+	{
+		ID:   triggerID + "-3",
+		Name: "Filter",
+		Description: "A JSON object with the fields that the body of the request must contain to activate the " +
+			"trigger. The nested fields are separated by dots. Example: {\"ref\": \"refs/heads/main\", " +
+			"\"door.state\": \"open\"}. If empty, all the requests activate the trigger.",
+		ContentType: types.JSON,
+	},
+}
+
+type hook struct {
+	id     string
+	secret string
+	filter map[string]interface{}
+}
+
+// Hooks keeps the tasks subscribed to the trigger, which are activated by `Deliver`. Each registry of elements has its
+// own.
+type Hooks struct {
+	subscriptions *shared.Subscriptions
+	// tasks keeps the configuration of the subscribed tasks.
+	tasks map[string]hook
+	sync.Mutex
+}
+
+// New returns the hooks without subscribed tasks.
+func New() *Hooks {
+	return &Hooks{
+		subscriptions: shared.NewSubscriptions(),
+		tasks:         make(map[string]hook),
+	}
+}
+
+// Trigger returns the trigger, whose subscriptions are kept by `hooks`.
+func (hooks *Hooks) Trigger() shared.Trigger {
+	return shared.Trigger{
+		ID:   triggerID,
+		Name: "Webhook",
+		Description: "The trigger will be activated by an HTTP request from an external system, like a CI service " +
+			"or a sensor. The body of the request is given to the first action of the task as chained result.",
+		Args:        triggerArgs,
+		Validate:    validate,
+		Subscribe:   hooks.subscribe,
+		Unsubscribe: hooks.unsubscribe,
+	}
+}
+
+func (hooks *Hooks) subscribe(args *[]data.UserArg, parentTaskID string) (<-chan shared.Activation, error) {
+	h, err := parseArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	hooks.Lock()
+	defer hooks.Unlock()
+
+	hooks.tasks[parentTaskID] = h
+
+	return hooks.subscriptions.Add(parentTaskID), nil
+}
+
+func (hooks *Hooks) unsubscribe(parentTaskID string) {
+	hooks.Lock()
+	defer hooks.Unlock()
+
+	delete(hooks.tasks, parentTaskID)
+	hooks.subscriptions.Remove(parentTaskID)
+}
+
+func validate(args *[]data.UserArg) error {
+	_, err := parseArgs(args)
+
+	return err
+}
+
+// Deliver activates the tasks subscribed to the hook whose secret authenticates the request and whose filter matches
+// its body, which is given to them as chained result. It returns the number of tasks activated. The request is
+// authenticated before parsing its body, which is only parsed if at least one task accepts it.
+func (hooks *Hooks) Deliver(hookID string, header http.Header, body []byte) (activated int, err error) {
+	hooks.Lock()
+	defer hooks.Unlock()
+
+	authenticated := make(map[string]hook)
+	for taskID, h := range hooks.tasks {
+		if h.id == hookID && h.authenticates(header, body) {
+			authenticated[taskID] = h
+		}
+	}
+	if len(authenticated) == 0 {
+		return 0, ErrUnauthorized
+	}
+
+	if len(strings.TrimSpace(string(body))) == 0 {
+		body = []byte("{}")
+	}
+
+	var content map[string]interface{}
+	if err := json.Unmarshal(body, &content); err != nil {
+		return 0, ErrInvalidBody
+	}
+
+	for taskID, h := range authenticated {
+		if !h.matches(content) {
+			continue
+		}
+
+		if hooks.subscriptions.Notify(taskID, shared.Activation{Result: string(body), ResultType: types.JSON}) {
+			activated++
+		}
+	}
+
+	return activated, nil
+}
+
+// authenticates reports whether the request contains the secret of the hook or a valid signature of its body.
+func (h *hook) authenticates(header http.Header, body []byte) bool {
+	if secret := header.Get(SecretHeader); secret != "" {
+		return subtle.ConstantTimeCompare([]byte(secret), []byte(h.secret)) == 1
+	}
+
+	signature := header.Get(SignatureHeader)
+	if signature == "" {
+		signature = header.Get(GitHubSignatureHeader)
+	}
+	if !strings.HasPrefix(signature, "sha256=") {
+		return false
+	}
+
+	received, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(h.secret))
+	mac.Write(body)
+
+	return hmac.Equal(received, mac.Sum(nil))
+}
+
+// matches reports whether the body contains all the fields of the filter of the hook.
+func (h *hook) matches(content map[string]interface{}) bool {
+	for path, expected := range h.filter {
+		var value interface{} = content
+		for _, key := range strings.Split(path, ".") {
+			object, ok := value.(map[string]interface{})
+			if !ok {
+				return false
+			}
+
+			value, ok = object[key]
+			if !ok {
+				return false
+			}
+		}
+
+		if !reflect.DeepEqual(value, expected) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func parseArgs(args *[]data.UserArg) (h hook, err error) {
+	if len(*args) != len(triggerArgs) {
+		return h, fmt.Errorf("%d arguments were expected and %d were obtained", len(triggerArgs), len(*args))
+	}
+
+	for i, arg := range *args {
+		content := strings.TrimSpace(arg.Content)
+
+		switch arg.ID {
+		case triggerArgs[0].ID:
+			{
+				if !hookIDRgx.MatchString(content) {
+					return h, fmt.Errorf("argument %d (ID: %s) must contain only letters, numbers, '-' and '_'",
+						i, arg.ID)
+				}
+				h.id = content
+			}
+		case triggerArgs[1].ID:
+			{
+				if content == "" {
+					return h, fmt.Errorf("argument %d (ID: %s) is empty", i, arg.ID)
+				}
+				h.secret = content
+			}
+		case triggerArgs[2].ID:
+			{
+				if content == "" {
+					continue
+				}
+
+				err = json.Unmarshal([]byte(content), &h.filter)
+				if err != nil {
+					return h, fmt.Errorf("argument %d (ID: %s) must be a JSON object: %w", i, arg.ID, err)
+				}
+			}
+		default:
+			return h, shared.ErrUnrecognizedArgID
+		}
+	}
+
+	return h, nil
+}
@@ -0,0 +1,137 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"testing"
+
+	"github.com/Pegasus8/piworker/core/data"
+	"github.com/Pegasus8/piworker/core/types"
+	test "github.com/Pegasus8/piworker/utilities/testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhook(t *testing.T) {
+	assert := assert.New(t)
+
+	hooks := New()
+	trigger := hooks.Trigger()
+	test.CheckTFields(t, trigger)
+
+	hookArgs := func(hookID, secret, filter string) []data.UserArg {
+		return []data.UserArg{
+			{ID: trigger.Args[0].ID, Content: hookID},
+			{ID: trigger.Args[1].ID, Content: secret},
+			{ID: trigger.Args[2].ID, Content: filter},
+		}
+	}
+
+	incorrectArgs := [][]data.UserArg{
+		// The ID of the hook is empty.
+		hookArgs("", "secret", ""),
+		// The ID of the hook can't be used on a URL.
+		hookArgs("door/sensor", "secret", ""),
+		// The secret is empty.
+		hookArgs("door", "", ""),
+		// The filter isn't a JSON object.
+		hookArgs("door", "secret", `["open"]`),
+		// There are no arguments (should be three).
+		{},
+	}
+
+	for i, args := range incorrectArgs {
+		assert.Errorf(trigger.Validate(&args), "[args %d] the validation must fail", i)
+
+		_, err := trigger.Subscribe(&args, uuid.New().String())
+		assert.Errorf(err, "[args %d] the subscription must fail", i)
+	}
+
+	secretHeader := func(secret string) http.Header {
+		h := http.Header{}
+		h.Set(SecretHeader, secret)
+		return h
+	}
+	signatureHeader := func(name, secret string, body []byte) http.Header {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+
+		h := http.Header{}
+		h.Set(name, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+		return h
+	}
+
+	// Two tasks on the same hook: one for any push, the other only for the pushes to main.
+	anyPush, mainPush := uuid.New().String(), uuid.New().String()
+	args := hookArgs("ci", "s3cr3t", "")
+	anyC, err := trigger.Subscribe(&args, anyPush)
+	assert.NoError(err)
+	defer trigger.Unsubscribe(anyPush)
+
+	args = hookArgs("ci", "s3cr3t", `{"ref": "refs/heads/main", "repository.private": true}`)
+	mainC, err := trigger.Subscribe(&args, mainPush)
+	assert.NoError(err)
+
+	body := []byte(`{"ref": "refs/heads/dev", "repository": {"name": "piworker", "private": true}}`)
+	activated, err := hooks.Deliver("ci", secretHeader("s3cr3t"), body)
+	assert.NoError(err)
+	assert.Equal(1, activated, "only the task without filter must be activated")
+	if assert.Len(anyC, 1) {
+		a := <-anyC
+		assert.Equal(string(body), a.Result, "the body must be given as chained result")
+		assert.Equal(types.JSON, a.ResultType)
+	}
+	assert.Len(mainC, 0)
+
+	body = []byte(`{"ref": "refs/heads/main", "repository": {"name": "piworker", "private": true}}`)
+	activated, err = hooks.Deliver("ci", signatureHeader(SignatureHeader, "s3cr3t", body), body)
+	assert.NoError(err)
+	assert.Equal(2, activated, "a signed request must activate the trigger")
+	<-anyC
+	<-mainC
+
+	activated, err = hooks.Deliver("ci", signatureHeader(GitHubSignatureHeader, "s3cr3t", body), body)
+	assert.NoError(err)
+	assert.Equal(2, activated, "the signature of GitHub must be accepted")
+	<-anyC
+	<-mainC
+
+	_, err = hooks.Deliver("ci", secretHeader("wrong"), body)
+	assert.Equal(ErrUnauthorized, err)
+	_, err = hooks.Deliver("ci", signatureHeader(SignatureHeader, "wrong", body), body)
+	assert.Equal(ErrUnauthorized, err)
+	_, err = hooks.Deliver("ci", signatureHeader(SignatureHeader, "s3cr3t", body), []byte(`{"ref": "refs/heads/main"}`))
+	assert.Equal(ErrUnauthorized, err, "the signature must be of the received body")
+	_, err = hooks.Deliver("ci", http.Header{}, body)
+	assert.Equal(ErrUnauthorized, err, "the requests without credentials must be rejected")
+	assert.Len(anyC, 0, "the requests not authenticated must not activate the trigger")
+
+	_, err = hooks.Deliver("other", secretHeader("s3cr3t"), body)
+	assert.Equal(ErrUnauthorized, err, "an unknown hook must be indistinguishable from a wrong secret")
+	_, err = hooks.Deliver("other", http.Header{}, []byte("not json"))
+	assert.Equal(ErrUnauthorized, err, "the body must not be parsed before the authentication")
+	_, err = hooks.Deliver("ci", secretHeader("wrong"), []byte("not json"))
+	assert.Equal(ErrUnauthorized, err, "the body must not be parsed before the authentication")
+	_, err = hooks.Deliver("ci", secretHeader("s3cr3t"), []byte("not json"))
+	assert.Equal(ErrInvalidBody, err)
+
+	activated, err = hooks.Deliver("ci", secretHeader("s3cr3t"), nil)
+	assert.NoError(err)
+	assert.Equal(1, activated, "a request without body must be accepted")
+	assert.Equal("{}", (<-anyC).Result)
+	activated, err = hooks.Deliver("ci", signatureHeader(SignatureHeader, "s3cr3t", nil), nil)
+	assert.NoError(err)
+	assert.Equal(1, activated, "the signature of an empty body must be accepted")
+	assert.Equal("{}", (<-anyC).Result)
+
+	// Once unsubscribed, the channel is closed and the hook doesn't exist anymore.
+	trigger.Unsubscribe(mainPush)
+	_, open := <-mainC
+	assert.False(open, "the channel must be closed after the unsubscription")
+	trigger.Unsubscribe(anyPush)
+	_, err = hooks.Deliver("ci", secretHeader("s3cr3t"), body)
+	assert.Equal(ErrUnauthorized, err)
+}
@@ -1,18 +1,56 @@
 package shared
 
 import (
+	"time"
+
 	"github.com/Pegasus8/piworker/core/data"
 	"github.com/Pegasus8/piworker/core/types"
 )
 
-// Trigger represents the trigger of a task. 
+// Trigger represents the trigger of a task.
 // Once activated it will cause the actions of the task to be executed.
+//
+// There are two kinds of triggers: the polled ones, which implement `Run` and are evaluated by the engine on each
+// tick, and the push ones, which implement `Subscribe` and `Unsubscribe` and notify their activations as soon as
+// they happen.
 type Trigger struct {
-	ID          string                                                        `json:"ID"`
-	Name        string                                                        `json:"name"`
-	Description string                                                        `json:"description"`
-	Run         func(args *[]data.UserArg, parentTaskID string) (bool, error) `json:"-"`
-	Args        []Arg                                                         `json:"args"`
+	ID          string `json:"ID"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Run evaluates the trigger. `now` is the current moment according to the clock of the engine, which the
+	// triggers that depend on the time must use instead of the clock of the system. Only used on polled triggers.
+	Run  func(args *[]data.UserArg, parentTaskID string, now time.Time) (bool, error) `json:"-"`
+	Args []Arg                                                                        `json:"args"`
+	// Validate checks the arguments of the trigger without running it, so a wrong configuration can be
+	// rejected before the creation of the task. Optional.
+	Validate func(args *[]data.UserArg) error `json:"-"`
+	// Subscribe starts watching the event of the trigger for the given task, whose activations are sent through
+	// the returned channel. Only used on push triggers.
+	Subscribe func(args *[]data.UserArg, parentTaskID string) (<-chan Activation, error) `json:"-"`
+	// Unsubscribe stops watching the event of the trigger for the given task and closes its channel. It's optional on
+	// polled triggers, where it forgets the state kept for the task once the trigger stops being evaluated (because
+	// the task has been modified, deleted or stopped).
+	Unsubscribe func(parentTaskID string) `json:"-"`
+	// Queued indicates that the push trigger keeps its pending activations and delivers them one by one, so the engine
+	// receives the next one only once the task can run it, instead of applying the overlap policy of the task to it.
+	// Optional, only used on push triggers.
+	Queued bool `json:"-"`
+	// Missed returns the moments in the interval (`from`, `to`] in which the trigger should have been activated,
+	// at most `limit` of them. Only implemented by the schedule triggers, which can miss activations while PiWorker is
+	// stopped. Optional.
+	Missed func(args *[]data.UserArg, from, to time.Time, limit int) ([]time.Time, error) `json:"-"`
+}
+
+// IsPush reports whether the trigger notifies its activations instead of being polled.
+func (t *Trigger) IsPush() bool {
+	return t.Subscribe != nil
+}
+
+// Activation represents an activation of a push trigger. The result (optional) is given to the first action of the
+// task as chained result.
+type Activation struct {
+	Result     string
+	ResultType types.PWType
 }
 
 // Arg is the struct that defines each argument received by a Trigger.
@@ -0,0 +1,73 @@
+package shared
+
+import "sync"
+
+// Subscriptions keeps the channels of the tasks subscribed to a push trigger. It's safe for concurrent use.
+type Subscriptions struct {
+	channels map[string]chan Activation
+	sync.Mutex
+}
+
+// NewSubscriptions returns an empty set of subscriptions.
+func NewSubscriptions() *Subscriptions {
+	return &Subscriptions{channels: make(map[string]chan Activation)}
+}
+
+// Add subscribes the task, replacing its previous subscription if it exists.
+func (s *Subscriptions) Add(taskID string) <-chan Activation {
+	s.Lock()
+	defer s.Unlock()
+
+	if c, exists := s.channels[taskID]; exists {
+		close(c)
+	}
+
+	// One activation can wait while the task is busy, the rest are discarded.
+	c := make(chan Activation, 1)
+	s.channels[taskID] = c
+
+	return c
+}
+
+// Remove cancels the subscription of the task, closing its channel.
+func (s *Subscriptions) Remove(taskID string) {
+	s.Lock()
+	defer s.Unlock()
+
+	if c, exists := s.channels[taskID]; exists {
+		close(c)
+		delete(s.channels, taskID)
+	}
+}
+
+// Notify sends the activation to the task without blocking. It returns false if the task isn't subscribed or if it
+// already has a pending activation, in which case the activation is discarded.
+func (s *Subscriptions) Notify(taskID string, activation Activation) bool {
+	s.Lock()
+	defer s.Unlock()
+
+	c, exists := s.channels[taskID]
+	if !exists {
+		return false
+	}
+
+	select {
+	case c <- activation:
+		return true
+	default:
+		return false
+	}
+}
+
+// TaskIDs returns the IDs of the subscribed tasks.
+func (s *Subscriptions) TaskIDs() []string {
+	s.Lock()
+	defer s.Unlock()
+
+	ids := make([]string, 0, len(s.channels))
+	for id := range s.channels {
+		ids = append(ids, id)
+	}
+
+	return ids
+}
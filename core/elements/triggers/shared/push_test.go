@@ -0,0 +1,32 @@
+package shared
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscriptions(t *testing.T) {
+	assert := assert.New(t)
+	s := NewSubscriptions()
+
+	assert.False(s.Notify("task-1", Activation{}), "a task without subscription must not be notified")
+
+	c := s.Add("task-1")
+	assert.Equal([]string{"task-1"}, s.TaskIDs())
+
+	assert.True(s.Notify("task-1", Activation{Result: "first"}), "the activation must be delivered")
+	assert.False(s.Notify("task-1", Activation{Result: "second"}), "only one activation can be pending")
+	assert.Equal("first", (<-c).Result)
+
+	// A new subscription replaces the previous one.
+	c2 := s.Add("task-1")
+	_, open := <-c
+	assert.False(open, "the channel of the previous subscription must be closed")
+
+	s.Remove("task-1")
+	_, open = <-c2
+	assert.False(open, "the channel must be closed after the unsubscription")
+	assert.Empty(s.TaskIDs())
+	assert.False(s.Notify("task-1", Activation{}), "an unsubscribed task must not be notified")
+}
@@ -0,0 +1,212 @@
+package shared
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Pegasus8/piworker/core/data"
+	"github.com/Pegasus8/piworker/core/types"
+)
+
+// Comparison is the direction in which a measured value must cross the limit of a `Threshold`.
+type Comparison string
+
+const (
+	// Above crosses the threshold when the value equals or exceeds the limit.
+	Above Comparison = "above"
+	// Below crosses the threshold when the value equals or is lower than the limit.
+	Below Comparison = "below"
+)
+
+// Threshold is the condition of the triggers that react to a measured value (like the CPU usage or the temperature).
+// The trigger is activated once when the value crosses the limit during (at least) `SustainedFor`, and it can't be
+// activated again until the value goes back beyond the limit by more than `Hysteresis`, so it doesn't flap while the
+// value oscillates around the limit.
+type Threshold struct {
+	Limit        float64
+	Comparison   Comparison
+	SustainedFor time.Duration
+	Hysteresis   float64
+}
+
+// ThresholdArgs returns the arguments that configure a `Threshold`, with the IDs `<triggerID>-1` to `<triggerID>-4`.
+// `limit` describes the measured value, for example "The CPU usage, in percent".
+func ThresholdArgs(triggerID, limit string) []Arg {
+	return []Arg{
+		{
+			ID:          triggerID + "-1",
+			Name:        "Threshold",
+			Description: limit + " that activates the trigger. Example: 80.5",
+			ContentType: types.Float,
+		},
+		{
+			ID:          triggerID + "-2",
+			Name:        "Comparison",
+			Description: "Must be 'above' or 'below', the side of the threshold that activates the trigger. If empty, 'above' is used.",
+			ContentType: types.Text,
+		},
+		{
+			ID:   triggerID + "-3",
+			Name: "Sustained For",
+			Description: "The time that the threshold must be crossed without interruption to activate the trigger. " +
+				"Example: 5m (5 minutes), 30s (30 seconds). If empty, the trigger is activated immediately.",
+			ContentType: types.Text,
+		},
+		{
+			ID:   triggerID + "-4",
+			Name: "Hysteresis",
+			Description: "How much the value must go back beyond the threshold before the trigger can be activated " +
+				"again. Example: 5. If empty, 0 is used.",
+			ContentType: types.Float,
+		},
+	}
+}
+
+// ParseThreshold returns the threshold configured by the arguments defined with `ThresholdArgs`, which must be the
+// first four of `triggerArgs`. The rest of the arguments, which are specific of each trigger, are returned without
+// changes.
+func ParseThreshold(args *[]data.UserArg, triggerArgs []Arg) (t Threshold, rest []data.UserArg, err error) {
+	t.Comparison = Above
+	limitFound := false
+
+	for i, arg := range *args {
+		content := strings.TrimSpace(arg.Content)
+
+		switch arg.ID {
+		case triggerArgs[0].ID:
+			{
+				t.Limit, err = strconv.ParseFloat(content, 64)
+				if err != nil {
+					return t, nil, fmt.Errorf("argument %d (ID: %s) must be a number: %w", i, arg.ID, err)
+				}
+				limitFound = true
+			}
+		case triggerArgs[1].ID:
+			{
+				if content == "" {
+					continue
+				}
+
+				t.Comparison = Comparison(strings.ToLower(content))
+				if t.Comparison != Above && t.Comparison != Below {
+					return t, nil, fmt.Errorf("unrecognized comparison '%s'", arg.Content)
+				}
+			}
+		case triggerArgs[2].ID:
+			{
+				if content == "" {
+					continue
+				}
+
+				t.SustainedFor, err = time.ParseDuration(content)
+				if err != nil {
+					return t, nil, err
+				}
+				if t.SustainedFor < 0 {
+					return t, nil, fmt.Errorf("argument %d (ID: %s) can't be negative", i, arg.ID)
+				}
+			}
+		case triggerArgs[3].ID:
+			{
+				if content == "" {
+					continue
+				}
+
+				t.Hysteresis, err = strconv.ParseFloat(content, 64)
+				if err != nil {
+					return t, nil, fmt.Errorf("argument %d (ID: %s) must be a number: %w", i, arg.ID, err)
+				}
+				if t.Hysteresis < 0 {
+					return t, nil, fmt.Errorf("argument %d (ID: %s) can't be negative", i, arg.ID)
+				}
+			}
+		default:
+			rest = append(rest, arg)
+		}
+	}
+
+	if !limitFound {
+		return t, nil, fmt.Errorf("the argument %s is required", triggerArgs[0].ID)
+	}
+
+	return t, rest, nil
+}
+
+// crossed reports whether the value is on the side of the limit that activates the trigger.
+func (t *Threshold) crossed(value float64) bool {
+	if t.Comparison == Below {
+		return value <= t.Limit
+	}
+
+	return value >= t.Limit
+}
+
+// rearmed reports whether the value is back beyond the limit by more than the hysteresis.
+func (t *Threshold) rearmed(value float64) bool {
+	if t.Comparison == Below {
+		return value > t.Limit+t.Hysteresis
+	}
+
+	return value < t.Limit-t.Hysteresis
+}
+
+// ThresholdStates keeps the state of the thresholds of a trigger between its executions, identified by the key of
+// the state of the trigger. It's safe for concurrent use.
+type ThresholdStates struct {
+	states map[string]*thresholdState
+	sync.Mutex
+}
+
+type thresholdState struct {
+	// crossedSince is the moment since the threshold is crossed, zero if it isn't.
+	crossedSince time.Time
+	// activated indicates that the trigger has been activated and the threshold isn't rearmed yet.
+	activated bool
+}
+
+// NewThresholdStates returns an empty set of states.
+func NewThresholdStates() *ThresholdStates {
+	return &ThresholdStates{states: make(map[string]*thresholdState)}
+}
+
+// Evaluate updates the state identified by `key` with the value measured at the moment `now`, and reports whether the
+// trigger must be activated.
+func (s *ThresholdStates) Evaluate(key string, t Threshold, value float64, now time.Time) bool {
+	s.Lock()
+	defer s.Unlock()
+
+	state, exists := s.states[key]
+	if !exists {
+		state = &thresholdState{}
+		s.states[key] = state
+	}
+
+	if state.activated {
+		if t.rearmed(value) {
+			state.activated = false
+			state.crossedSince = time.Time{}
+		}
+
+		return false
+	}
+
+	if !t.crossed(value) {
+		state.crossedSince = time.Time{}
+		return false
+	}
+
+	if state.crossedSince.IsZero() {
+		state.crossedSince = now
+	}
+
+	if now.Sub(state.crossedSince) < t.SustainedFor {
+		return false
+	}
+
+	state.activated = true
+
+	return true
+}
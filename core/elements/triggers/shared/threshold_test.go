@@ -0,0 +1,98 @@
+package shared
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Pegasus8/piworker/core/data"
+	"github.com/Pegasus8/piworker/utilities/clock"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseThreshold(t *testing.T) {
+	assert := assert.New(t)
+	triggerArgs := append(ThresholdArgs("T0", "The value"), Arg{ID: "T0-5", ContentType: "text"})
+
+	thresholdArgs := func(limit, comparison, sustainedFor, hysteresis string) []data.UserArg {
+		return []data.UserArg{
+			{ID: "T0-1", Content: limit},
+			{ID: "T0-2", Content: comparison},
+			{ID: "T0-3", Content: sustainedFor},
+			{ID: "T0-4", Content: hysteresis},
+			{ID: "T0-5", Content: "extra"},
+		}
+	}
+
+	args := thresholdArgs("80.5", "BELOW", "5m", "2")
+	threshold, rest, err := ParseThreshold(&args, triggerArgs)
+	assert.NoError(err)
+	assert.Equal(Threshold{Limit: 80.5, Comparison: Below, SustainedFor: 5 * time.Minute, Hysteresis: 2}, threshold)
+	assert.Equal([]data.UserArg{{ID: "T0-5", Content: "extra"}}, rest, "the rest of the arguments must be returned")
+
+	args = thresholdArgs("10", "", "", "")
+	threshold, _, err = ParseThreshold(&args, triggerArgs)
+	assert.NoError(err)
+	assert.Equal(Threshold{Limit: 10, Comparison: Above}, threshold, "the optional arguments must use their default")
+
+	incorrectArgs := [][]data.UserArg{
+		thresholdArgs("", "", "", ""),
+		thresholdArgs("high", "", "", ""),
+		thresholdArgs("10", "equal", "", ""),
+		thresholdArgs("10", "", "5 minutes", ""),
+		thresholdArgs("10", "", "-5m", ""),
+		thresholdArgs("10", "", "", "-1"),
+		{},
+	}
+	for i, args := range incorrectArgs {
+		_, _, err := ParseThreshold(&args, triggerArgs)
+		assert.Errorf(err, "[args %d] the parsing must fail", i)
+	}
+}
+
+func TestThresholdStates(t *testing.T) {
+	assert := assert.New(t)
+
+	fake := clock.NewFake(time.Date(2021, 3, 5, 12, 0, 0, 0, time.Local))
+
+	s := NewThresholdStates()
+
+	// Activated once per crossing, rearmed when the value goes below 75.
+	above := Threshold{Limit: 80, Comparison: Above, Hysteresis: 5}
+	values := []struct {
+		value     float64
+		activated bool
+	}{
+		{70, false},
+		{80, true},
+		{90, false},
+		{78, false},
+		{81, false},
+		{74.9, false},
+		{85, true},
+	}
+	for i, v := range values {
+		assert.Equalf(v.activated, s.Evaluate("above", above, v.value, fake.Now()), "[value %d] unexpected result", i)
+	}
+
+	below := Threshold{Limit: 20, Comparison: Below}
+	assert.True(s.Evaluate("below", below, 15, fake.Now()))
+	assert.False(s.Evaluate("below", below, 20, fake.Now()))
+	assert.False(s.Evaluate("below", below, 20.1, fake.Now()))
+	assert.True(s.Evaluate("below", below, 19, fake.Now()), "without hysteresis, the threshold must be rearmed as soon as it isn't crossed")
+
+	// The value must remain beyond the limit without interruption.
+	sustained := Threshold{Limit: 80, Comparison: Above, SustainedFor: 5 * time.Minute}
+	assert.False(s.Evaluate("sustained", sustained, 90, fake.Now()))
+	fake.Advance(4 * time.Minute)
+	assert.False(s.Evaluate("sustained", sustained, 90, fake.Now()))
+	assert.False(s.Evaluate("sustained", sustained, 70, fake.Now()), "the interruption must restart the count")
+	assert.False(s.Evaluate("sustained", sustained, 90, fake.Now()))
+	fake.Advance(4 * time.Minute)
+	assert.False(s.Evaluate("sustained", sustained, 90, fake.Now()))
+	fake.Advance(time.Minute)
+	assert.True(s.Evaluate("sustained", sustained, 90, fake.Now()))
+	assert.False(s.Evaluate("sustained", sustained, 90, fake.Now()))
+
+	assert.True(s.Evaluate("other", above, 100, fake.Now()), "the states must be independent")
+}
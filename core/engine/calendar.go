@@ -0,0 +1,61 @@
+package engine
+
+import (
+	"time"
+
+	"github.com/Pegasus8/piworker/core/data"
+)
+
+// allowedByCalendar reports whether the trigger of the task can be activated at the moment `t`, according to the
+// calendar referenced by the task. The suppressed activations are logged. If the calendar doesn't exist (for example,
+// because it has been removed from the configs), all the activations are suppressed.
+func (engine *Engine) allowedByCalendar(task *data.UserTask, t time.Time) bool {
+	name := task.Settings.Calendar
+	if name == "" || engine.configs == nil {
+		return true
+	}
+
+	calendar, found := engine.configs.Calendar(name)
+	if !found {
+		engine.logger.Error().
+			Str("taskID", task.ID).
+			Str("calendar", name).
+			Msg("The calendar of the task doesn't exist, activation of the trigger suppressed")
+
+		return false
+	}
+
+	if calendar.Allows(t) {
+		return true
+	}
+
+	engine.logger.Info().
+		Str("taskID", task.ID).
+		Str("calendar", name).
+		Msg("Activation of the trigger suppressed, outside the windows allowed by the calendar")
+
+	return false
+}
+
+// allowedActivations returns the moments, of the given activations of the trigger of the task, allowed by the calendar
+// referenced by the task (see `allowedByCalendar`).
+func (engine *Engine) allowedActivations(task *data.UserTask, activations []time.Time) []time.Time {
+	name := task.Settings.Calendar
+	if name == "" || engine.configs == nil {
+		return activations
+	}
+
+	calendar, found := engine.configs.Calendar(name)
+	if !found {
+		return nil
+	}
+
+	var allowed []time.Time
+	for _, t := range activations {
+		if calendar.Allows(t) {
+			allowed = append(allowed, t)
+		}
+	}
+
+	return allowed
+}
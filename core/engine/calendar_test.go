@@ -0,0 +1,34 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Pegasus8/piworker/core/configs"
+	"github.com/Pegasus8/piworker/core/data"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllowedByCalendar(t *testing.T) {
+	assert := assert.New(t)
+
+	e := NewEngine(nil, &configs.Configs{Calendars: []configs.Calendar{
+		{Name: "office", Include: []configs.Window{{From: "09:00", To: "18:00"}}},
+	}})
+
+	// 2021-03-05 is a Friday.
+	morning := time.Date(2021, 3, 5, 10, 0, 0, 0, time.Local)
+	night := time.Date(2021, 3, 5, 23, 0, 0, 0, time.Local)
+
+	task := &data.UserTask{ID: "calendar"}
+	assert.True(e.allowedByCalendar(task, night), "a task without calendar can be activated at any moment")
+
+	task.Settings.Calendar = "office"
+	assert.True(e.allowedByCalendar(task, morning))
+	assert.False(e.allowedByCalendar(task, night), "the activation must be suppressed outside the windows")
+
+	task.Settings.Calendar = "holidays"
+	assert.False(e.allowedByCalendar(task, morning), "the activations of a task with an unknown calendar must be "+
+		"suppressed")
+}
@@ -0,0 +1,64 @@
+package engine
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Pegasus8/piworker/core/data"
+	actionsModel "github.com/Pegasus8/piworker/core/elements/actions/shared"
+	"github.com/Pegasus8/piworker/core/types"
+)
+
+// evalCondition checks if the result of the previous action satisfies the given condition. The comparisons are
+// made according to the type of the condition or, if it doesn't have one, to the type of the result.
+func evalCondition(condition *data.StepCondition, cr *actionsModel.ChainedResult, parentTaskID string) (bool, error) {
+	// The value can be a reference to a user variable.
+	value := data.UserArg{Content: condition.Value}
+	err := searchAndReplaceVariable(&value, parentTaskID)
+	if err != nil {
+		return false, err
+	}
+
+	switch condition.Operator {
+	case data.OperatorContains:
+		return strings.Contains(cr.Result, value.Content), nil
+	case data.OperatorMatches:
+		rgx, err := regexp.Compile(value.Content)
+		if err != nil {
+			return false, err
+		}
+
+		return rgx.MatchString(cr.Result), nil
+	}
+
+	t := condition.Type
+	if t == "" {
+		t = cr.ResultType
+	}
+	if t == "" {
+		t = types.Text
+	}
+
+	r, err := types.Compare(t, cr.Result, value.Content)
+	if err != nil {
+		return false, err
+	}
+
+	switch condition.Operator {
+	case data.OperatorEqual:
+		return r == 0, nil
+	case data.OperatorNotEqual:
+		return r != 0, nil
+	case data.OperatorGreater:
+		return r > 0, nil
+	case data.OperatorGreaterOrEqual:
+		return r >= 0, nil
+	case data.OperatorLess:
+		return r < 0, nil
+	case data.OperatorLessOrEqual:
+		return r <= 0, nil
+	default:
+		return false, fmt.Errorf("unrecognized operator '%s'", condition.Operator)
+	}
+}
@@ -0,0 +1,121 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Pegasus8/piworker/core/data"
+	"github.com/Pegasus8/piworker/core/elements"
+	actionsModel "github.com/Pegasus8/piworker/core/elements/actions/shared"
+	"github.com/Pegasus8/piworker/core/engine/queue"
+	"github.com/Pegasus8/piworker/core/types"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvalCondition(t *testing.T) {
+	assert := assert.New(t)
+	taskID := uuid.New().String()
+
+	cases := []struct {
+		condition data.StepCondition
+		cr        actionsModel.ChainedResult
+		expected  bool
+	}{
+		// The type of the result is used by default.
+		{data.StepCondition{Operator: data.OperatorGreater, Value: "10"}, actionsModel.ChainedResult{Result: "9.5", ResultType: types.Float}, false},
+		{data.StepCondition{Operator: data.OperatorLessOrEqual, Value: "10"}, actionsModel.ChainedResult{Result: "9", ResultType: types.Int}, true},
+		{data.StepCondition{Operator: data.OperatorEqual, Value: "TRUE"}, actionsModel.ChainedResult{Result: "true", ResultType: types.Bool}, true},
+		{data.StepCondition{Operator: data.OperatorLess, Value: "2021-03-05"}, actionsModel.ChainedResult{Result: "04/03/2021", ResultType: types.Date}, true},
+		// The type of the condition has priority over the type of the result.
+		{data.StepCondition{Operator: data.OperatorGreater, Value: "10", Type: types.Int}, actionsModel.ChainedResult{Result: "9", ResultType: types.Text}, false},
+		{data.StepCondition{Operator: data.OperatorGreater, Value: "10"}, actionsModel.ChainedResult{Result: "9", ResultType: types.Text}, true},
+		{data.StepCondition{Operator: data.OperatorNotEqual, Value: "ok"}, actionsModel.ChainedResult{Result: "ok"}, false},
+		{data.StepCondition{Operator: data.OperatorContains, Value: "error"}, actionsModel.ChainedResult{Result: "fatal error: x"}, true},
+		{data.StepCondition{Operator: data.OperatorMatches, Value: `^\d+ files?$`}, actionsModel.ChainedResult{Result: "3 files"}, true},
+	}
+
+	for i, c := range cases {
+		r, err := evalCondition(&c.condition, &c.cr, taskID)
+		assert.NoErrorf(err, "[case %d] the condition should be evaluated without errors", i)
+		assert.Equalf(c.expected, r, "[case %d] wrong result of the condition", i)
+	}
+
+	_, err := evalCondition(&data.StepCondition{Operator: data.OperatorGreater, Value: "10"},
+		&actionsModel.ChainedResult{Result: "hello", ResultType: types.Int}, taskID)
+	assert.Error(err, "a result without the format of its type must return an error")
+}
+
+func TestRunActionList(t *testing.T) {
+	assert := assert.New(t)
+	registry := elements.NewRegistry()
+	e := NewEngine(nil, nil, WithRegistry(registry))
+	taskID := uuid.New().String()
+
+	// An action that returns its first argument as result, or fails if it's empty.
+	var executed []uint8
+	echo := actionsModel.Action{
+		ID:   "A99",
+		Args: []actionsModel.Arg{{ID: "A99-1", ContentType: types.Any}},
+		Run: func(_ context.Context, previousResult *actionsModel.ChainedResult, parentAction *data.UserAction, _ string) (bool, *actionsModel.ChainedResult, error) {
+			err := actionsModel.HandleCR(parentAction, []actionsModel.Arg{{ID: "A99-1", ContentType: types.Any}}, previousResult)
+			if err != nil {
+				return false, &actionsModel.ChainedResult{}, err
+			}
+
+			executed = append(executed, parentAction.Order)
+			if parentAction.Args[0].Content == "" {
+				return false, &actionsModel.ChainedResult{}, errors.New("empty content")
+			}
+
+			return true, &actionsModel.ChainedResult{Result: parentAction.Args[0].Content, ResultType: types.Int}, nil
+		},
+	}
+	if err := registry.RegisterAction(echo); err != nil {
+		panic(err)
+	}
+
+	action := func(order uint8, content string, condition *data.StepCondition) data.UserAction {
+		return data.UserAction{
+			ID:        echo.ID,
+			Args:      []data.UserArg{{ID: "A99-1", Content: content}},
+			Order:     order,
+			Condition: condition,
+		}
+	}
+
+	actions := []data.UserAction{
+		action(0, "5", nil),
+		action(1, "1", &data.StepCondition{Operator: data.OperatorGreater, Value: "10", Else: data.ElseGoto, Goto: 3}),
+		action(2, "2", nil),
+		action(3, "3", &data.StepCondition{Operator: data.OperatorEqual, Value: "5"}),
+		action(4, "4", &data.StepCondition{Operator: data.OperatorEqual, Value: "5", Else: data.ElseStop}),
+		action(5, "6", nil),
+	}
+
+	execution := &data.TaskExecution{TaskID: taskID}
+	cr, err := e.runActionList(context.Background(), taskID, actions, &actionsModel.ChainedResult{}, queue.NewQueue(0, nil), execution, false)
+	assert.NoError(err, "a condition not satisfied must not be considered a failure")
+	if assert.NotNil(cr) {
+		assert.Equal("3", cr.Result, "the result of the last executed action must be returned")
+	}
+	assert.Equal([]uint8{0, 3}, executed, "the actions must be skipped according to their conditions")
+	assert.Len(execution.Actions, 2, "only the executed actions must be registered")
+
+	// The actions executed on failure receive the error as chained result.
+	executed = nil
+	onFailure := []data.UserAction{action(0, "", nil)}
+	onFailure[0].Chained = true
+	onFailure[0].ArgumentToReplaceByCR = "A99-1"
+	execution = &data.TaskExecution{TaskID: taskID}
+
+	e.runOnFailure(context.Background(), &data.UserTask{ID: taskID, OnFailure: onFailure}, errors.New("exit status 1"),
+		queue.NewQueue(0, nil), execution)
+	assert.Equal([]uint8{0}, executed, "the actions defined for the failure must be executed")
+	if assert.Len(execution.Actions, 1) {
+		assert.True(execution.Actions[0].OnFailure, "the action must be registered as executed on failure")
+		assert.Equal("exit status 1", execution.Actions[0].Result, "the action must receive the error")
+	}
+}
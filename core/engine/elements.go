@@ -0,0 +1,20 @@
+package engine
+
+import (
+	actionsModel "github.com/Pegasus8/piworker/core/elements/actions/shared"
+	triggersModel "github.com/Pegasus8/piworker/core/elements/triggers/shared"
+)
+
+// trigger returns the trigger with the given ID among the ones registered on the registry of the engine. If it
+// doesn't exist, an empty trigger is returned.
+func (engine *Engine) trigger(id string) *triggersModel.Trigger {
+	trigger, _ := engine.registry.Trigger(id)
+	return trigger
+}
+
+// action returns the action with the given ID among the ones registered on the registry of the engine. If it doesn't
+// exist, an empty action is returned.
+func (engine *Engine) action(id string) *actionsModel.Action {
+	action, _ := engine.registry.Action(id)
+	return action
+}
@@ -1,279 +1,560 @@
 package engine
 
 import (
-	// "time"
-	"os"
+	"context"
+	"fmt"
+	"sync"
 
-	// "github.com/Pegasus8/piworker/core/configs"
 	"github.com/Pegasus8/piworker/core/data"
 	"github.com/Pegasus8/piworker/core/engine/queue"
-	"github.com/Pegasus8/piworker/core/signals"
 	"github.com/Pegasus8/piworker/core/stats"
 	"github.com/Pegasus8/piworker/webui/backend"
-	"github.com/rs/zerolog/log"
 )
 
-// StartEngine is the function used to start the Dynamic Engine
-func (engine *Engine) Start() {
-	log.Info().Msg("Starting the Dynamic Engine...")
+// Start starts the Dynamic Engine: the loops of the active tasks, the management of the events of the database and,
+// unless they are disabled, the server of the WebUI and the stats loop. It returns once the engine is running, which
+// keeps doing it until `ctx` is canceled or `Stop` is called. In both cases, `Stop` must be called before starting it
+// again.
+func (engine *Engine) Start(ctx context.Context) error {
+	engine.lifecycleMutex.Lock()
+	defer engine.lifecycleMutex.Unlock()
 
-	// Hook
-	if !engine.OnStart() {
-		return
+	if engine.cancel != nil {
+		return ErrAlreadyStarted
 	}
 
-	defer func() {
-		err := os.RemoveAll(TempDir)
-		if err != nil {
-			log.Error().Err(err).Msg("Error when trying to remove temp dir")
-		}
-	}()
+	engine.logger.Info().Msg("Starting the Dynamic Engine...")
 
-	var stopSignal = make(chan struct{})
-	var tasksGoroutines = make(map[string]chan data.UserTask)
-	// 0 = stopped by the system. For example, on a system shutdown.
-	// 1 = stopped by the user. For example, changing the state of the task.
-	// 2 = task deleted by the user.
-	var managementChannels = make(map[string]chan uint8)
-	defer func() {
-		stopSignal <- struct{}{}
-		// Stop each loop when the engine is going to shutdown. This is with the intention
-		// of handle some post execution operations.
-		for c := range managementChannels {
-			managementChannels[c] <- 0
-		}
-	}()
-	data.EventBus = make(chan data.Event)
-	actionsQ := queue.NewQueue()
-
-	err := checkTempDir()
-	if err != nil {
-		log.Panic().Err(err).Msg("Error when trying to create the temp dir")
+	// Hook
+	if !engine.OnStart() {
+		return ErrStoppedByHook
 	}
 
-	log.Info().Msg("Reading the user data for first time...")
+	engine.logger.Info().Msg("Reading the user data for first time...")
 
 	activeTasks, err := engine.userdataDB.GetActiveTasks()
 	if err != nil {
-		log.Panic().
-			Err(err).
-			Msgf("Error when trying to read the tasks with state '%s'\n", data.StateTaskActive)
+		return fmt.Errorf("error when trying to read the tasks with state '%s': %w", data.StateTaskActive, err)
 	}
 
-	// Set Stats.Inactive counter (`inactiveTasks` will be used only here).
 	inactiveTasks, err := engine.userdataDB.GetInactiveTasks()
 	if err != nil {
-		log.Panic().
-			Err(err).
-			Msgf("Error when trying to read the tasks with state '%s'\n", data.StateTaskInactive)
+		return fmt.Errorf("error when trying to read the tasks with state '%s': %w", data.StateTaskInactive, err)
 	}
-	stats.Current.Lock()
-	stats.Current.TasksStats.InactiveTasks = uint16(len(*inactiveTasks))
-	stats.Current.Unlock()
 
 	failedTasks, err := engine.userdataDB.GetFailedTasks()
 	if err != nil {
-		log.Panic().
-			Err(err).
-			Msgf("Error when trying to read the tasks with state '%s'\n", data.StateTaskFailed)
+		return fmt.Errorf("error when trying to read the tasks with state '%s': %w", data.StateTaskFailed, err)
+	}
+
+	engine.updateStats(func(s *stats.TasksStats) {
+		s.InactiveTasks = uint16(len(*inactiveTasks))
+		s.FailedTasks = uint8(len(*failedTasks))
+	})
+
+	// Canceled to stop the engine. It interrupts the executions requested by the user (not related with a task
+	// loop) and the restarts scheduled.
+	ctx, cancel := context.WithCancel(ctx)
+	engine.cancel = cancel
+	engine.done = make(chan struct{})
+
+	loops := &taskLoops{
+		engine:       engine,
+		ctx:          ctx,
+		actionsQueue: queue.NewQueue(engine.configs.Behavior.ActionWorkers, engine.updateStats),
+		tasks:        make(map[string]chan data.UserTask),
+		management:   make(map[string]chan uint8),
+		done:         make(map[string]chan struct{}),
+		cancelFuncs:  make(map[string]context.CancelFunc),
+		runners:      make(map[string]*taskRunner),
 	}
-	stats.Current.Lock()
-	stats.Current.TasksStats.FailedTasks = uint8(len(*failedTasks))
-	stats.Current.Unlock()
 
-	log.Info().Msg("Creating channels for active tasks...")
+	engine.logger.Info().Msg("Creating channels for active tasks...")
 	for _, task := range *activeTasks {
-		// Create the channel for each task (with active state).
-		tasksGoroutines[task.ID] = make(chan data.UserTask)
-		managementChannels[task.ID] = make(chan uint8)
-		go engine.runTaskLoop(task.ID, tasksGoroutines[task.ID], managementChannels[task.ID], actionsQ)
+		// Only on the start of the engine, the activations missed while PiWorker was stopped are recovered.
+		catchUp, err := engine.missedActivations(&task, engine.Clock.Now())
+		if err != nil {
+			engine.logger.Error().
+				Err(err).
+				Str("taskID", task.ID).
+				Msg("Error when trying to check the missed activations of the trigger of the task")
+		}
+
+		loops.start(task, catchUp)
+
+		engine.updateStats(func(s *stats.TasksStats) {
+			s.ActiveTasks++
+		})
+	}
+	engine.logger.Info().Msg("Channels created correctly")
+
+	// The engine is stopped completely once the management and the server of the WebUI have finished.
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		engine.manage(ctx, loops, *failedTasks)
+	}()
 
-		tasksGoroutines[task.ID] <- task
+	if engine.webUI {
+		// Start the server of the WebUI.
+		engine.logger.Info().Msg("Starting the WebUI server...")
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
 
-		stats.Current.Lock()
-		stats.Current.TasksStats.ActiveTasks++
-		stats.Current.Unlock()
+			err := backend.Run(ctx, engine.userdataDB, engine.configs, engine.registry, engine.stats)
+			if err != nil {
+				engine.logger.Error().Err(err).Msg("Error on the server of the WebUI")
+			}
+		}()
 	}
-	log.Info().Msg("Channels created correctly")
 
-	// Start the server of the WebUI.
-	log.Info().Msg("Starting the WebUI server...")
-	go backend.Run(engine.userdataDB, engine.configs)
+	go func() {
+		wg.Wait()
+		close(engine.done)
+	}()
 
 	// Hook
-	if !engine.OnBackendInit() {
-		return
+	if engine.webUI && !engine.OnBackendInit() {
+		engine.shutdown()
+		return ErrStoppedByHook
 	}
 
-	// Start the stats recollection.
-	log.Info().Msg("Starting the stats loop...")
-	go stats.StartLoop(stopSignal)
+	if engine.stats != nil {
+		// Start the stats recollection.
+		engine.logger.Info().Msg("Starting the stats loop...")
+		stopSignal := make(chan struct{})
+		go engine.stats.StartLoop(stopSignal)
+		go func() {
+			<-engine.done
+			close(stopSignal)
+		}()
+
+		// Hook
+		if !engine.OnStatsLoopInit() {
+			engine.shutdown()
+			return ErrStoppedByHook
+		}
+	}
+
+	return nil
+}
+
+// Stop stops the engine started with `Start`, interrupting the executions in progress, and waits until all the task
+// loops and the server of the WebUI have finished.
+func (engine *Engine) Stop() error {
+	engine.lifecycleMutex.Lock()
+	defer engine.lifecycleMutex.Unlock()
+
+	if engine.cancel == nil {
+		return ErrNotStarted
+	}
+
+	engine.shutdown()
 
 	// Hook
-	if !engine.OnStatsLoopInit() {
-		return
+	engine.OnShutdown()
+
+	return nil
+}
+
+// Done returns a channel closed once the engine has been stopped completely, either by `Stop` or by the cancellation
+// of the context given to `Start`. Nil if the engine hasn't been started.
+func (engine *Engine) Done() <-chan struct{} {
+	engine.lifecycleMutex.Lock()
+	defer engine.lifecycleMutex.Unlock()
+
+	return engine.done
+}
+
+// shutdown stops the running engine and waits for it. Must be called with `lifecycleMutex` locked.
+func (engine *Engine) shutdown() {
+	engine.cancel()
+	<-engine.done
+
+	engine.cancel = nil
+}
+
+// manage responds to the events of the database and to the restarts of the failed tasks until `ctx` is canceled,
+// then stops all the loops.
+func (engine *Engine) manage(ctx context.Context, loops *taskLoops, failedTasks []data.UserTask) {
+	// Receives the IDs of the failed tasks that must be restarted, once their cooldown has passed.
+	var restarts = make(chan string)
+
+	// The tasks that failed before the start of the engine are restarted too, if their policy still allows it.
+	for i := range failedTasks {
+		t := &failedTasks[i]
+		if !shouldRestart(t.Settings.Restart, t.FailureCount) {
+			continue
+		}
+
+		engine.logger.Warn().
+			Str("taskID", t.ID).
+			Uint16("failureCount", t.FailureCount).
+			Int64("cooldown", t.Settings.Restart.Cooldown).
+			Msg("Task failed before the start, restart scheduled")
+
+		engine.scheduleRestart(ctx, t, restarts)
 	}
 
-	go func() {
-		for {
-			event := <-data.EventBus
+	for {
+		var event data.Event
+
+		select {
+		case <-ctx.Done():
+			// Stop each loop when the engine is going to shutdown. This is with the intention of handle some post
+			// execution operations.
+			loops.stopAll()
+
+			return
+		case event = <-engine.userdataDB.EventBus:
+		case taskID := <-restarts:
+			{
+				t, err := engine.userdataDB.GetTaskByID(taskID)
+				if err != nil {
+					engine.logger.Error().Err(err).Str("taskID", taskID).Msg("Error when trying to restart the task")
+					continue
+				}
+
+				// The task could have been modified (or already restarted by the user) during the cooldown.
+				if loops.running(t.ID) || t.State != data.StateTaskFailed {
+					continue
+				}
+
+				err = engine.userdataDB.UpdateTaskState(t.ID, data.StateTaskActive)
+				if err != nil {
+					engine.logger.Error().Err(err).Str("taskID", t.ID).Msg("Error when trying to restart the task")
+					continue
+				}
+				t.State = data.StateTaskActive
+
+				engine.logger.Warn().
+					Str("taskID", t.ID).
+					Uint16("failureCount", t.FailureCount).
+					Msg("Restarting the failed task")
+
+				loops.start(*t, 0)
+
+				engine.updateStats(func(s *stats.TasksStats) {
+					s.ActiveTasks++
+					s.FailedTasks--
+				})
+				engine.storeStats()
 
-			if !engine.OnEvent(&event) {
 				continue
 			}
+		}
 
-			switch event.Type {
-			case data.Added:
-				{
-					// Get the recently added task by it ID.
-					t, err := engine.userdataDB.GetTaskByID(event.TaskID)
-					if err != nil {
-						log.Panic().Err(err).Msg("Error when responding to an event of type Added")
-					}
+		if !engine.OnEvent(&event) {
+			continue
+		}
 
-					// Only add the new task if the state is 'active'.
-					if t.State != data.StateTaskActive {
-						stats.Current.Lock()
-						stats.Current.TasksStats.InactiveTasks++
-						stats.Current.Unlock()
-						updateTStatsDB()
+		switch event.Type {
+		case data.Added:
+			{
+				// Get the recently added task by it ID.
+				t, err := engine.userdataDB.GetTaskByID(event.TaskID)
+				if err != nil {
+					engine.logger.Error().Err(err).Str("taskID", event.TaskID).Msg("Error when responding to an event of type Added")
+					continue
+				}
 
-						continue
-					}
+				// Only add the new task if the state is 'active'.
+				if t.State != data.StateTaskActive {
+					engine.updateStats(func(s *stats.TasksStats) {
+						s.InactiveTasks++
+					})
+					engine.storeStats()
 
-					// Because the task is new, the proper channel and loop must be initialized.
-					tasksGoroutines[t.ID] = make(chan data.UserTask)
-					managementChannels[t.ID] = make(chan uint8)
-					go engine.runTaskLoop(t.ID, tasksGoroutines[t.ID], managementChannels[t.ID], actionsQ)
+					continue
+				}
 
-					// Once the loop and the channels are initialized is time to send the new task.
-					tasksGoroutines[t.ID] <- *t
+				// Because the task is new, the proper channel and loop must be initialized.
+				loops.start(*t, 0)
 
-					stats.Current.Lock()
-					stats.Current.TasksStats.ActiveTasks++
-					stats.Current.Unlock()
-					updateTStatsDB()
+				engine.updateStats(func(s *stats.TasksStats) {
+					s.ActiveTasks++
+				})
+				engine.storeStats()
+			}
+		case data.Modified:
+			{
+				// Get the recently modified task by it ID.
+				t, err := engine.userdataDB.GetTaskByID(event.TaskID)
+				if err != nil {
+					engine.logger.Error().Err(err).Str("taskID", event.TaskID).Msg("Error when responding to an event of type Modified")
+					continue
 				}
-			case data.Modified:
-				{
-					// Get the recently modified task by it ID.
-					t, err := engine.userdataDB.GetTaskByID(event.TaskID)
-					if err != nil {
-						log.Panic().Err(err).Msg("Error when responding to an event of type Modified")
-					}
 
-					if t.State != data.StateTaskActive {
-						// Check if the task has been running before the event.
-						if _, ok := tasksGoroutines[event.TaskID]; ok {
-							// Send the signal to indicate the change of the state, and thus, the detention of the task loop.
-							// Note: here we haven't checked if the management channel for this task exists, this
-							// is unnecessary because both channels are initialized simultaneously, so if one exists, the other too.
-							managementChannels[event.TaskID] <- 1
-
-							// Close the channels and delete them from their maps.
-							close(tasksGoroutines[event.TaskID])
-							close(managementChannels[event.TaskID])
-							delete(tasksGoroutines, event.TaskID)
-							delete(managementChannels, event.TaskID)
-
-							stats.Current.Lock()
-							stats.Current.TasksStats.ActiveTasks--
-							stats.Current.TasksStats.InactiveTasks++
-							stats.Current.Unlock()
-							updateTStatsDB()
-						}
-						// If the task was not running, there is nothing to do.
+				if t.State != data.StateTaskActive {
+					// Check if the task has been running before the event.
+					if loops.running(event.TaskID) {
+						// Send the signal to indicate the change of the state, and thus, the detention of the task loop.
+						loops.stop(event.TaskID, 1)
+
+						engine.updateStats(func(s *stats.TasksStats) {
+							s.ActiveTasks--
+							s.InactiveTasks++
+						})
+						engine.storeStats()
+					}
+					// If the task was not running, there is nothing to do.
 
+				} else {
+					// If the loop already exists, the previous state of the task was the same (active), so there is
+					// no necessity to send a signal thought the management channel, just send the updated data.
+					if loops.running(event.TaskID) {
+						loops.update(*t)
 					} else {
-						// If the channel already exists, the previous state of the task was the same (active), so
-						// there is no necessity to send a signal thought the management channel, just send the updated
-						// data.
-						if _, ok := tasksGoroutines[event.TaskID]; ok {
-							tasksGoroutines[t.ID] <- *t
-						} else {
-							// If the channel doesn't exists, the previously state of the task was another than 'active',
-							// so the task must be managed as a new one.
-							tasksGoroutines[t.ID] = make(chan data.UserTask)
-							managementChannels[t.ID] = make(chan uint8)
-							go engine.runTaskLoop(t.ID, tasksGoroutines[t.ID], managementChannels[t.ID], actionsQ)
-
-							// Once the loop and the channels are initialized is time to send the new task.
-							tasksGoroutines[t.ID] <- *t
-
-							stats.Current.Lock()
-							stats.Current.TasksStats.ActiveTasks++
-							stats.Current.TasksStats.InactiveTasks--
-							stats.Current.Unlock()
-							updateTStatsDB()
-						}
+						// If the loop doesn't exists, the previously state of the task was another than 'active',
+						// so the task must be managed as a new one.
+						loops.start(*t, 0)
+
+						engine.updateStats(func(s *stats.TasksStats) {
+							s.ActiveTasks++
+							s.InactiveTasks--
+						})
+						engine.storeStats()
 					}
 				}
-			case data.Deleted:
-				{
-					// If the task is not running (state != 'active'), skip the iteration.
-					if _, ok := tasksGoroutines[event.TaskID]; !ok {
-						stats.Current.Lock()
-						stats.Current.TasksStats.InactiveTasks--
-						stats.Current.Unlock()
-						updateTStatsDB()
-						continue
-					}
+			}
+		case data.Deleted:
+			{
+				loops.actionsQueue.RemoveTask(event.TaskID)
+
+				// If the task is not running (state != 'active'), skip the iteration.
+				if !loops.running(event.TaskID) {
+					// The runs requested by the user (if any) are interrupted.
+					loops.discard(event.TaskID)
+
+					engine.updateStats(func(s *stats.TasksStats) {
+						s.InactiveTasks--
+					})
+					engine.storeStats()
+					continue
+				}
 
-					// Send a signal of detention (2 = task deleted).
-					managementChannels[event.TaskID] <- 2
+				// Send a signal of detention (2 = task deleted).
+				loops.stop(event.TaskID, 2)
 
-					// And finally close the channels and delete them from the maps.
-					close(tasksGoroutines[event.TaskID])
-					close(managementChannels[event.TaskID])
-					delete(tasksGoroutines, event.TaskID)
-					delete(managementChannels, event.TaskID)
+				engine.updateStats(func(s *stats.TasksStats) {
+					s.ActiveTasks--
+				})
+				engine.storeStats()
+			}
+		case data.Failed:
+			{
+				// The loop has already finished, there is no one to receive a signal.
+				loops.release(event.TaskID)
+
+				// Decrease the active tasks counter.
+				engine.updateStats(func(s *stats.TasksStats) {
+					s.ActiveTasks--
+					s.FailedTasks++
+				})
+				engine.storeStats()
+
+				t, err := engine.userdataDB.GetTaskByID(event.TaskID)
+				if err != nil {
+					engine.logger.Error().Err(err).Str("taskID", event.TaskID).Msg("Error when responding to an event of type Failed")
+					continue
+				}
 
-					stats.Current.Lock()
-					stats.Current.TasksStats.ActiveTasks--
-					stats.Current.Unlock()
-					updateTStatsDB()
+				if shouldRestart(t.Settings.Restart, t.FailureCount) {
+					engine.logger.Warn().
+						Str("taskID", t.ID).
+						Uint16("failureCount", t.FailureCount).
+						Int64("cooldown", t.Settings.Restart.Cooldown).
+						Msg("Task failed, restart scheduled")
+
+					engine.scheduleRestart(ctx, t, restarts)
+				} else if t.Settings.Restart != nil && t.Settings.Restart.Mode == data.RestartOnFailure {
+					engine.logger.Error().
+						Str("taskID", t.ID).
+						Uint16("failureCount", t.FailureCount).
+						Msg("Task failed too many times, it won't be restarted")
+				}
+			}
+		case data.RunRequested:
+			{
+				t, err := engine.userdataDB.GetTaskByID(event.TaskID)
+				if err != nil {
+					engine.logger.Error().Err(err).Str("taskID", event.TaskID).Msg("Error when responding to an event of type RunRequested")
+					continue
 				}
-			case data.Failed:
-				{
-					// Close the channels of the failed task and delete them of the maps.
-					close(tasksGoroutines[event.TaskID])
-					close(managementChannels[event.TaskID])
-					delete(tasksGoroutines, event.TaskID)
-					delete(managementChannels, event.TaskID)
-
-					// Decrease the active tasks counter.
-					stats.Current.Lock()
-					stats.Current.TasksStats.ActiveTasks--
-					stats.Current.TasksStats.FailedTasks++
-					stats.Current.Unlock()
-					updateTStatsDB()
+
+				// The state could have changed since the request.
+				if t.State == data.StateTaskOnExecution {
+					engine.logger.Warn().Str("taskID", t.ID).Msg("Run requested for a task already on execution, ignoring it")
+					continue
 				}
+
+				// The run shares the runner of the loop of the task (if any), so its overlap policy is respected.
+				loops.runner(t.ID).runNow(*t)
 			}
 		}
+	}
+}
+
+// taskLoops keeps the loops of the active tasks of a running engine. It's only used by the goroutine that manages the
+// engine once it's started.
+type taskLoops struct {
+	engine *Engine
+	// ctx is canceled when the engine is stopped.
+	ctx          context.Context
+	actionsQueue *queue.Queue
+	// tasks are the channels used to send the updated data to the loop of each task.
+	tasks map[string]chan data.UserTask
+	// management are the channels used to stop the loop of each task:
+	// 0 = stopped by the system. For example, on a system shutdown.
+	// 1 = stopped by the user. For example, changing the state of the task.
+	// 2 = task deleted by the user.
+	management map[string]chan uint8
+	// done are closed once the loop of each task has finished, which can happen before receiving a signal (for
+	// example, if a hook requires it).
+	done map[string]chan struct{}
+	// cancelFuncs are used to interrupt the loop of a task when it's stopped.
+	cancelFuncs map[string]context.CancelFunc
+	// runners execute the actions of the tasks with a loop, and of the tasks without one whose run has been requested
+	// by the user.
+	runners map[string]*taskRunner
+	// wg waits for the loops and the runners discarded.
+	wg sync.WaitGroup
+}
+
+// start starts the loop of the task and sends the task to it.
+func (l *taskLoops) start(task data.UserTask, catchUp int) {
+	tasks := make(chan data.UserTask)
+	management := make(chan uint8)
+	done := make(chan struct{})
+	ctx, cancel := context.WithCancel(l.ctx)
+	runner := l.runner(task.ID)
+
+	l.tasks[task.ID] = tasks
+	l.management[task.ID] = management
+	l.done[task.ID] = done
+	l.cancelFuncs[task.ID] = cancel
+
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+		defer close(done)
+		l.engine.runTaskLoop(ctx, task.ID, tasks, management, runner, catchUp)
 	}()
 
-	<-signals.Shutdown
+	// Once the loop and the channels are initialized is time to send the task.
+	tasks <- task
+}
 
-	// Hook
-	engine.OnShutdown()
+// runner returns the runner of the task, created if the task doesn't have one. The runner of a task without loop is
+// kept until the task is deleted, or until a loop is started for the task (which takes it).
+func (l *taskLoops) runner(taskID string) *taskRunner {
+	r, ok := l.runners[taskID]
+	if !ok {
+		r = l.engine.newTaskRunner(l.ctx, l.actionsQueue)
+		l.runners[taskID] = r
+	}
+
+	return r
 }
 
-func updateTStatsDB() {
-	stats.Current.RLock()
-	err := stats.StoreTStats(&stats.Current.TasksStats)
-	stats.Current.RUnlock()
+// discard stops the runner of a task without loop (if any), without waiting for it.
+func (l *taskLoops) discard(taskID string) {
+	r, ok := l.runners[taskID]
+	if !ok {
+		return
+	}
+	delete(l.runners, taskID)
 
-	if err != nil {
-		log.Error().Err(err).Msg("Error when storing tasks stats")
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+		r.stop()
+	}()
+}
+
+// running reports whether the loop of the task is running.
+func (l *taskLoops) running(taskID string) bool {
+	_, ok := l.tasks[taskID]
+	return ok
+}
+
+// update sends the updated data of the task to its loop, unless the loop has already finished.
+func (l *taskLoops) update(task data.UserTask) {
+	select {
+	case l.tasks[task.ID] <- task:
+	case <-l.done[task.ID]:
 	}
 }
 
-func checkTempDir() error {
-	// Create temp dir if not exists
-	err := os.MkdirAll(TempDir, 0755)
-	if err != nil {
-		return err
+// stop interrupts the loop of the task and sends the signal `code` to it, unless the loop has already finished.
+func (l *taskLoops) stop(taskID string, code uint8) {
+	l.cancelFuncs[taskID]()
+
+	select {
+	case l.management[taskID] <- code:
+	case <-l.done[taskID]:
 	}
 
-	return nil
+	l.release(taskID)
+}
+
+// release closes the channels of the loop of the task and deletes them from the maps.
+func (l *taskLoops) release(taskID string) {
+	// If the loop has already finished, the cancellation only releases the context.
+	l.cancelFuncs[taskID]()
+
+	close(l.tasks[taskID])
+	close(l.management[taskID])
+	delete(l.tasks, taskID)
+	delete(l.management, taskID)
+	delete(l.done, taskID)
+	delete(l.cancelFuncs, taskID)
+	// The runner is stopped by the loop.
+	delete(l.runners, taskID)
+}
+
+// stopAll stops all the loops (a closed management channel means stopped by the system) and the runners, and waits
+// for them. Then the workers of the queue of actions are stopped too.
+func (l *taskLoops) stopAll() {
+	for id := range l.management {
+		l.cancelFuncs[id]()
+		close(l.management[id])
+	}
+
+	l.wg.Wait()
+
+	for _, r := range l.runners {
+		r.stop()
+	}
+
+	// Nothing else can use the workers of the queue, they are released for the next start.
+	l.actionsQueue.Close()
+}
+
+// updateStats applies `update` to the current statistics of the tasks, unless the statistics are disabled.
+func (engine *Engine) updateStats(update func(s *stats.TasksStats)) {
+	if engine.stats == nil {
+		return
+	}
+
+	engine.stats.Lock()
+	update(&engine.stats.TasksStats)
+	engine.stats.Unlock()
+}
+
+// storeStats stores the current statistics of the tasks, unless the statistics are disabled.
+func (engine *Engine) storeStats() {
+	if engine.stats == nil {
+		return
+	}
+
+	engine.stats.RLock()
+	err := engine.stats.StoreTStats(&engine.stats.TasksStats)
+	engine.stats.RUnlock()
+
+	if err != nil {
+		engine.logger.Error().Err(err).Msg("Error when storing tasks stats")
+	}
 }
@@ -0,0 +1,374 @@
+package engine
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Pegasus8/piworker/core/configs"
+	"github.com/Pegasus8/piworker/core/data"
+	"github.com/Pegasus8/piworker/core/elements"
+	actionsModel "github.com/Pegasus8/piworker/core/elements/actions/shared"
+	triggersModel "github.com/Pegasus8/piworker/core/elements/triggers/shared"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEngineLifecycle(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "lifecycle")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// A trigger always activated and an action that notifies its executions, only known by the engines of the test.
+	always := triggersModel.Trigger{
+		ID: "T-always",
+		Run: func(_ *[]data.UserArg, _ string, _ time.Time) (bool, error) {
+			return true, nil
+		},
+	}
+
+	// newEngine returns an engine with its own database, which notifies the executions of its tasks through the
+	// returned channel.
+	newEngine := func(name string) (*Engine, *data.DatabaseInstance, chan string) {
+		db, err := data.NewDB(filepath.Join(dir, name), "tasks.db")
+		if err != nil {
+			panic(err)
+		}
+
+		executed := make(chan string, 10)
+		notify := actionsModel.Action{
+			ID: "A-notify",
+			Run: func(_ context.Context, _ *actionsModel.ChainedResult, _ *data.UserAction, parentTaskID string) (bool, *actionsModel.ChainedResult, error) {
+				executed <- parentTaskID
+				return true, &actionsModel.ChainedResult{}, nil
+			},
+		}
+
+		registry := elements.NewRegistry()
+		if err := registry.RegisterTrigger(always); err != nil {
+			panic(err)
+		}
+		if err := registry.RegisterAction(notify); err != nil {
+			panic(err)
+		}
+
+		e := NewEngine(db, &configs.Configs{Behavior: configs.Behavior{LoopSleep: 10}},
+			WithoutWebUI(),
+			WithRegistry(registry),
+			WithLogger(zerolog.Nop()),
+		)
+
+		return e, db, executed
+	}
+
+	addTask := func(db *data.DatabaseInstance) string {
+		task := data.UserTask{
+			Name:    "Embedded",
+			State:   data.StateTaskActive,
+			Trigger: data.UserTrigger{ID: always.ID},
+			Actions: []data.UserAction{{ID: "A-notify"}},
+		}
+
+		// The engine receives the event emitted by the database.
+		err := db.NewTask(&task)
+		if err != nil {
+			panic(err)
+		}
+
+		return task.ID
+	}
+
+	waitExecution := func(executed chan string, taskID, msg string) {
+		select {
+		case id := <-executed:
+			assert.Equal(taskID, id, msg)
+		case <-time.After(5 * time.Second):
+			assert.Fail(msg)
+		}
+	}
+
+	// Two engines in the same process.
+	e1, db1, executed1 := newEngine("e1")
+	defer db1.Close()
+	e2, db2, executed2 := newEngine("e2")
+	defer db2.Close()
+
+	assert.Equal(ErrNotStarted, e1.Stop(), "an engine not started can't be stopped")
+
+	assert.NoError(e1.Start(context.Background()))
+	assert.Equal(ErrAlreadyStarted, e1.Start(context.Background()), "the engine can't be started twice")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	assert.NoError(e2.Start(ctx))
+
+	id1 := addTask(db1)
+	waitExecution(executed1, id1, "the task must be executed by its engine")
+	id2 := addTask(db2)
+	waitExecution(executed2, id2, "the task must be executed by its engine")
+
+	assert.NoError(e1.Stop())
+	select {
+	case <-e1.Done():
+	default:
+		assert.Fail("the engine must be stopped once Stop returns")
+	}
+
+	task, err := db1.GetTaskByID(id1)
+	if assert.NoError(err) {
+		assert.Equal(data.StateTaskActive, task.State, "the task must remain active to be run on the next start")
+	}
+
+	// The loops of the active tasks are started again.
+	assert.NoError(e1.Start(context.Background()))
+	waitExecution(executed1, id1, "the task must be executed again after a restart of the engine")
+	assert.NoError(e1.Stop())
+
+	// The cancellation of the context stops the engine too.
+	cancel()
+	select {
+	case <-e2.Done():
+	case <-time.After(5 * time.Second):
+		assert.Fail("the engine must be stopped when its context is canceled")
+	}
+	assert.NoError(e2.Stop(), "the resources of the engine stopped by its context must be released")
+
+	// A hook can interrupt the start.
+	e3, db3, _ := newEngine("e3")
+	defer db3.Close()
+	e3.OnStart = func() bool {
+		return false
+	}
+	assert.Equal(ErrStoppedByHook, e3.Start(context.Background()))
+}
+
+func TestEngineStopDuringExecution(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "stopexec")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// An action that runs until it's interrupted.
+	started := make(chan struct{}, 10)
+	blocking := actionsModel.Action{
+		ID: "A-blocking",
+		Run: func(ctx context.Context, _ *actionsModel.ChainedResult, _ *data.UserAction, _ string) (bool, *actionsModel.ChainedResult, error) {
+			started <- struct{}{}
+			<-ctx.Done()
+			return false, &actionsModel.ChainedResult{}, ctx.Err()
+		},
+	}
+
+	e, db := newTestEngine(dir, blocking)
+	defer db.Close()
+
+	assert.NoError(e.Start(context.Background()))
+	taskID := addTestTask(db, blocking.ID)
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		assert.Fail("the task must be executed")
+	}
+
+	assert.NoError(e.Stop())
+
+	task, err := db.GetTaskByID(taskID)
+	if assert.NoError(err) {
+		assert.Equal(data.StateTaskActive, task.State, "the state of the task interrupted by the engine must be restored")
+	}
+
+	active, err := db.GetActiveTasks()
+	if assert.NoError(err) {
+		assert.Len(*active, 1, "the task must be run on the next start")
+	}
+}
+
+func TestEngineRestartFailedBeforeStart(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "restartstart")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	executed := make(chan string, 10)
+	notify := actionsModel.Action{
+		ID: "A-notify",
+		Run: func(_ context.Context, _ *actionsModel.ChainedResult, _ *data.UserAction, parentTaskID string) (bool, *actionsModel.ChainedResult, error) {
+			select {
+			case executed <- parentTaskID:
+			default:
+			}
+			return true, &actionsModel.ChainedResult{}, nil
+		},
+	}
+
+	e, db := newTestEngine(dir, notify)
+	defer db.Close()
+
+	newTask := func(policy data.RestartPolicy) string {
+		task := data.UserTask{
+			Name:     "Restart " + string(policy.Mode),
+			State:    data.StateTaskInactive,
+			Trigger:  data.UserTrigger{ID: "T-always"},
+			Actions:  []data.UserAction{{ID: notify.ID}},
+			Settings: data.TaskSettings{Restart: &policy},
+		}
+
+		err := db.NewTask(&task)
+		if err != nil {
+			panic(err)
+		}
+
+		return task.ID
+	}
+
+	// The tasks are added (and failed) while the engine is running, so their events are received.
+	assert.NoError(e.Start(context.Background()))
+	always := newTask(data.RestartPolicy{Mode: data.RestartAlways})
+	exhausted := newTask(data.RestartPolicy{Mode: data.RestartOnFailure, MaxRestarts: 1})
+	assert.NoError(e.Stop())
+
+	for _, failure := range []struct {
+		taskID string
+		times  int
+	}{{always, 1}, {exhausted, 2}} {
+		for i := 0; i < failure.times; i++ {
+			assert.NoError(db.RegisterTaskFailure(failure.taskID, time.Now()))
+		}
+	}
+
+	assert.NoError(e.Start(context.Background()))
+	defer e.Stop()
+
+	select {
+	case id := <-executed:
+		assert.Equal(always, id, "only the task whose policy allows it must be restarted")
+	case <-time.After(5 * time.Second):
+		assert.Fail("the task failed before the start must be restarted")
+	}
+
+	task, err := db.GetTaskByID(exhausted)
+	if assert.NoError(err) {
+		assert.Equal(data.StateTaskFailed, task.State, "the task without restarts left must remain failed")
+	}
+}
+
+func TestEngineStopFinishedLoop(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "finishedloop")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	executed := make(chan struct{}, 10)
+	notify := actionsModel.Action{
+		ID: "A-notify",
+		Run: func(_ context.Context, _ *actionsModel.ChainedResult, _ *data.UserAction, _ string) (bool, *actionsModel.ChainedResult, error) {
+			executed <- struct{}{}
+			return true, &actionsModel.ChainedResult{}, nil
+		},
+	}
+
+	e, db := newTestEngine(dir, notify)
+	defer db.Close()
+
+	// The hook finishes the loop of the task after its first execution.
+	hookCalled := make(chan struct{})
+	var once sync.Once
+	e.OnTaskExecutionSuccess = func(_ TaskID, _ time.Duration) bool {
+		once.Do(func() {
+			close(hookCalled)
+		})
+		return false
+	}
+
+	assert.NoError(e.Start(context.Background()))
+	taskID := addTestTask(db, notify.ID)
+
+	select {
+	case <-hookCalled:
+	case <-time.After(5 * time.Second):
+		assert.Fail("the task must be executed")
+	}
+
+	// The task is deleted once its loop has finished.
+	assert.NoError(db.DeleteTask(taskID))
+
+	stopped := make(chan error)
+	go func() {
+		stopped <- e.Stop()
+	}()
+
+	select {
+	case err := <-stopped:
+		assert.NoError(err)
+	case <-time.After(5 * time.Second):
+		assert.Fail("the engine must be stopped even if the loop of the task has already finished")
+	}
+}
+
+// newTestEngine returns an engine, without WebUI and stats, with a database on `dir`. Its registry contains `action`
+// and the trigger "T-always", activated every time that it's evaluated.
+func newTestEngine(dir string, action actionsModel.Action) (*Engine, *data.DatabaseInstance) {
+	db, err := data.NewDB(dir, "tasks.db")
+	if err != nil {
+		panic(err)
+	}
+
+	always := triggersModel.Trigger{
+		ID: "T-always",
+		Run: func(_ *[]data.UserArg, _ string, _ time.Time) (bool, error) {
+			return true, nil
+		},
+	}
+
+	registry := elements.NewRegistry()
+	if err := registry.RegisterTrigger(always); err != nil {
+		panic(err)
+	}
+	if err := registry.RegisterAction(action); err != nil {
+		panic(err)
+	}
+
+	e := NewEngine(db, &configs.Configs{Behavior: configs.Behavior{LoopSleep: 10}},
+		WithoutWebUI(),
+		WithRegistry(registry),
+		WithLogger(zerolog.Nop()),
+	)
+
+	return e, db
+}
+
+// addTestTask adds an active task to the database, with the trigger "T-always" and the given action.
+func addTestTask(db *data.DatabaseInstance, actionID string) string {
+	task := data.UserTask{
+		Name:    "Test",
+		State:   data.StateTaskActive,
+		Trigger: data.UserTrigger{ID: "T-always"},
+		Actions: []data.UserAction{{ID: actionID}},
+	}
+
+	err := db.NewTask(&task)
+	if err != nil {
+		panic(err)
+	}
+
+	return task.ID
+}
@@ -0,0 +1,12 @@
+package engine
+
+import "errors"
+
+// ErrAlreadyStarted is the error used when the engine is started while it's already running.
+var ErrAlreadyStarted = errors.New("the engine is already running")
+
+// ErrNotStarted is the error used when the engine is stopped without being running.
+var ErrNotStarted = errors.New("the engine is not running")
+
+// ErrStoppedByHook is the error used when the start of the engine is interrupted by one of its hooks.
+var ErrStoppedByHook = errors.New("the start of the engine was interrupted by a hook")
@@ -0,0 +1,97 @@
+package engine
+
+import (
+	"time"
+
+	"github.com/Pegasus8/piworker/core/data"
+)
+
+// maxMissedActivations limits the executions made by the policy `data.MisfireFireAll`, so a frequent schedule doesn't
+// flood the engine after a long downtime.
+const maxMissedActivations = 100
+
+// missedActivations returns how many times the task must be executed to recover the activations of its trigger missed
+// while PiWorker was stopped, according to its misfire policy. Only the schedule triggers (the ones that implement
+// `Missed`) can miss activations. The activations previous to the last modification of the task are not considered,
+// they belong to a different configuration, neither the ones suppressed by the calendar of the task.
+func (engine *Engine) missedActivations(task *data.UserTask, now time.Time) (int, error) {
+	pwTrigger := engine.trigger(task.Trigger.ID)
+	if pwTrigger.Missed == nil {
+		return 0, nil
+	}
+
+	from := task.LastFired
+	if task.LastTimeModified.After(from) {
+		from = task.LastTimeModified
+	}
+
+	// Work on a copy of the args to keep the references to the user variables on the task itself.
+	args := make([]data.UserArg, len(task.Trigger.Args))
+	copy(args, task.Trigger.Args)
+
+	for i := range args {
+		err := searchAndReplaceVariable(&args[i], task.ID)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	missed, err := pwTrigger.Missed(&args, from, now, maxMissedActivations)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(missed) == 0 {
+		return 0, nil
+	}
+
+	policy := task.Settings.Misfire
+	if policy == "" {
+		policy = data.MisfireSkip
+	}
+
+	engine.logger.Warn().
+		Str("taskID", task.ID).
+		Int("missed", len(missed)).
+		Time("lastFired", task.LastFired).
+		Str("policy", string(policy)).
+		Msg("Activations of the trigger missed while PiWorker was stopped")
+
+	// Whatever the policy is, the missed activations are considered handled.
+	err = engine.userdataDB.UpdateLastFired(task.ID, missed[len(missed)-1])
+	if err != nil {
+		return 0, err
+	}
+
+	// The activations suppressed by the calendar of the task are not recovered. Neither the rest of them if the
+	// calendar doesn't allow the current moment, when they would be executed.
+	allowed := engine.allowedActivations(task, missed)
+	if len(allowed) == 0 || !engine.allowedByCalendar(task, now) {
+		return 0, nil
+	}
+
+	switch policy {
+	case data.MisfireFireOnce:
+		return 1, nil
+	case data.MisfireFireAll:
+		return len(allowed), nil
+	default:
+		return 0, nil
+	}
+}
+
+// registerActivation stores the moment of the activation of the trigger of the task, used to detect the activations
+// missed while PiWorker is stopped. Only done for schedule triggers.
+func (engine *Engine) registerActivation(task *data.UserTask, firedAt time.Time) {
+	if engine.trigger(task.Trigger.ID).Missed == nil {
+		return
+	}
+
+	err := engine.userdataDB.UpdateLastFired(task.ID, firedAt)
+	if err != nil {
+		engine.logger.Error().
+			Err(err).
+			Str("taskID", task.ID).
+			Msg("Error when trying to store the time of the activation of the trigger")
+	}
+}
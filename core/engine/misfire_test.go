@@ -0,0 +1,200 @@
+package engine
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Pegasus8/piworker/core/configs"
+	"github.com/Pegasus8/piworker/core/data"
+	"github.com/Pegasus8/piworker/core/elements"
+	actionsModel "github.com/Pegasus8/piworker/core/elements/actions/shared"
+	triggersModel "github.com/Pegasus8/piworker/core/elements/triggers/shared"
+	"github.com/Pegasus8/piworker/core/engine/queue"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMissedActivations(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "misfire")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := data.NewDB(dir, "misfire.db")
+	if err != nil {
+		panic(err)
+	}
+	defer db.Close()
+
+	// The events emitted by the database are not used.
+	go func() {
+		for range db.EventBus {
+		}
+	}()
+
+	// A schedule trigger activated every hour o'clock.
+	hourly := triggersModel.Trigger{
+		ID: "T98",
+		Run: func(_ *[]data.UserArg, _ string, _ time.Time) (bool, error) {
+			return false, nil
+		},
+		Missed: func(_ *[]data.UserArg, from, to time.Time, limit int) ([]time.Time, error) {
+			var missed []time.Time
+			for t := from.Truncate(time.Hour).Add(time.Hour); !t.After(to) && len(missed) < limit; t = t.Add(time.Hour) {
+				missed = append(missed, t)
+			}
+
+			return missed, nil
+		},
+	}
+	registry := elements.NewRegistry()
+	if err := registry.RegisterTrigger(hourly); err != nil {
+		panic(err)
+	}
+
+	e := NewEngine(db, nil, WithRegistry(registry))
+	now := time.Now().Truncate(time.Hour).Add(30 * time.Minute)
+
+	newTask := func(triggerID string, misfire data.MisfirePolicy) *data.UserTask {
+		task := data.UserTask{
+			Name:     "Misfire " + string(misfire),
+			State:    data.StateTaskActive,
+			Trigger:  data.UserTrigger{ID: triggerID},
+			Actions:  []data.UserAction{{ID: "A1"}},
+			Settings: data.TaskSettings{Misfire: misfire},
+		}
+
+		err := db.NewTask(&task)
+		if err != nil {
+			panic(err)
+		}
+
+		// PiWorker was stopped three activations ago.
+		task.LastTimeModified = now.Add(-5 * time.Hour)
+		task.LastFired = now.Add(-3 * time.Hour)
+
+		return &task
+	}
+
+	policies := []struct {
+		policy   data.MisfirePolicy
+		expected int
+	}{
+		{"", 0},
+		{data.MisfireSkip, 0},
+		{data.MisfireFireOnce, 1},
+		{data.MisfireFireAll, 3},
+	}
+
+	for _, p := range policies {
+		task := newTask(hourly.ID, p.policy)
+
+		n, err := e.missedActivations(task, now)
+		assert.NoErrorf(err, "[%s] the missed activations should be obtained without errors", p.policy)
+		assert.Equalf(p.expected, n, "[%s] wrong number of executions", p.policy)
+
+		stored, err := db.GetTaskByID(task.ID)
+		if assert.NoError(err) {
+			assert.Truef(now.Truncate(time.Hour).Equal(stored.LastFired), "[%s] the last missed activation must be "+
+				"stored", p.policy)
+		}
+	}
+
+	// The activations previous to the last modification of the task are ignored.
+	task := newTask(hourly.ID, data.MisfireFireAll)
+	task.LastTimeModified = now.Add(-time.Hour)
+	n, err := e.missedActivations(task, now)
+	assert.NoError(err)
+	assert.Equal(1, n, "only the activations after the modification of the task must be recovered")
+
+	// The activations suppressed by the calendar of the task are not recovered.
+	e.configs = &configs.Configs{Calendars: []configs.Calendar{
+		{Name: "maintenance", Exclude: []configs.Window{{
+			From: now.Add(-90 * time.Minute).Format("15:04"),
+			To:   now.Add(-30 * time.Minute).Format("15:04"),
+		}}},
+		{Name: "night", Exclude: []configs.Window{{
+			From: now.Add(-10 * time.Minute).Format("15:04"),
+			To:   now.Add(10 * time.Minute).Format("15:04"),
+		}}},
+	}}
+
+	task = newTask(hourly.ID, data.MisfireFireAll)
+	task.Settings.Calendar = "maintenance"
+	n, err = e.missedActivations(task, now)
+	assert.NoError(err)
+	assert.Equal(2, n, "the activations outside the windows of the calendar must not be recovered")
+
+	task = newTask(hourly.ID, data.MisfireFireAll)
+	task.Settings.Calendar = "night"
+	n, err = e.missedActivations(task, now)
+	assert.NoError(err)
+	assert.Equal(0, n, "the activations must not be recovered while the calendar doesn't allow it")
+	e.configs = nil
+
+	// The rest of the triggers can't miss activations.
+	n, err = e.missedActivations(newTask("T1000", data.MisfireFireAll), now)
+	assert.NoError(err)
+	assert.Equal(0, n)
+}
+
+func TestCatchUp(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "catchup")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := data.NewDB(dir, "catchup.db")
+	if err != nil {
+		panic(err)
+	}
+	defer db.Close()
+
+	var mutex sync.Mutex
+	var running, maxRunning, executions int
+	counter := actionsModel.Action{
+		ID: "A97",
+		Run: func(_ context.Context, _ *actionsModel.ChainedResult, _ *data.UserAction, _ string) (bool, *actionsModel.ChainedResult, error) {
+			mutex.Lock()
+			running++
+			executions++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			mutex.Unlock()
+
+			time.Sleep(5 * time.Millisecond)
+
+			mutex.Lock()
+			running--
+			mutex.Unlock()
+
+			return true, &actionsModel.ChainedResult{}, nil
+		},
+	}
+	registry := elements.NewRegistry()
+	if err := registry.RegisterAction(counter); err != nil {
+		panic(err)
+	}
+
+	task := data.UserTask{ID: "catch-up", Actions: []data.UserAction{{ID: counter.ID}}}
+
+	r := NewEngine(db, nil, WithRegistry(registry)).newTaskRunner(context.Background(), queue.NewQueue(2, nil))
+	r.catchUp(task, 3)
+	// The task is running, so the activation is skipped.
+	r.activate(task, &actionsModel.ChainedResult{})
+	r.wg.Wait()
+
+	assert.Equal(3, executions, "the task must be executed once for each missed activation")
+	assert.Equal(1, maxRunning, "the missed activations must be recovered one after the other")
+}
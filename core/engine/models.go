@@ -1,8 +1,15 @@
 package engine
 
 import (
+	"context"
 	"github.com/Pegasus8/piworker/core/configs"
 	"github.com/Pegasus8/piworker/core/data"
+	"github.com/Pegasus8/piworker/core/elements"
+	"github.com/Pegasus8/piworker/core/stats"
+	"github.com/Pegasus8/piworker/utilities/clock"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"sync"
 	"time"
 )
 
@@ -20,16 +27,42 @@ type Engine struct {
 	OnTaskExecutionSuccess func(id TaskID, executionDuration time.Duration) bool
 	OnEvent                func(event *data.Event) bool
 
+	// Clock is used to get the time and to wait for it (ticks of the task loops, cooldowns, delays, etc). The
+	// triggers receive the current time from it too. By default it's the clock of the system.
+	Clock clock.Clock
+
 	userdataDB *data.DatabaseInstance
 	configs    *configs.Configs
+	// executionsMutex protects the executions of the tasks, updated concurrently by the actions of parallel groups.
+	executionsMutex sync.Mutex
+	// runningExecutions counts the executions in progress of each task, protected by `executionsMutex`.
+	runningExecutions map[string]int
+	// executionSlots limits the number of tasks executed at the same time, nil if there is no limit.
+	executionSlots chan struct{}
+
+	// webUI indicates if the server of the WebUI is started with the engine.
+	webUI bool
+	// stats are the statistics collected and stored by the engine, nil if they are disabled.
+	stats *stats.Stats
+	// registry contains the triggers and the actions available to the tasks.
+	registry *elements.Registry
+	logger   zerolog.Logger
+
+	// lifecycleMutex protects the fields used to start and stop the engine.
+	lifecycleMutex sync.Mutex
+	// cancel stops the engine started, nil if it isn't running.
+	cancel context.CancelFunc
+	// done is closed once the engine started has been stopped completely.
+	done chan struct{}
 }
 
 type Run interface {
-	Start()
+	Start(ctx context.Context) error
+	Stop() error
 }
 
-// NewEngine returns a new instance of the `Engine` struct.
-func NewEngine(userdataDB *data.DatabaseInstance, configs *configs.Configs) *Engine {
+// NewEngine returns a new instance of the `Engine` struct, customized by the given options.
+func NewEngine(userdataDB *data.DatabaseInstance, configs *configs.Configs, options ...Option) *Engine {
 	e := &Engine{}
 
 	/*
@@ -59,6 +92,10 @@ func NewEngine(userdataDB *data.DatabaseInstance, configs *configs.Configs) *Eng
 		return true
 	}
 
+	e.OnActionRun = func(_ TaskID, _ *data.UserAction, _ time.Duration) bool {
+		return true
+	}
+
 	e.OnTaskExecutionFail = func(_ TaskID, _ error) bool {
 		return true
 	}
@@ -71,8 +108,26 @@ func NewEngine(userdataDB *data.DatabaseInstance, configs *configs.Configs) *Eng
 		return true
 	}
 
+	e.Clock = clock.Real
+	e.webUI = true
+	e.logger = log.Logger
+
 	e.userdataDB = userdataDB
 	e.configs = configs
 
+	if configs != nil && configs.Behavior.MaxConcurrentTasks > 0 {
+		e.executionSlots = make(chan struct{}, configs.Behavior.MaxConcurrentTasks)
+	}
+
+	for _, option := range options {
+		option(e)
+	}
+
+	if e.registry == nil && configs != nil {
+		e.registry = elements.NewDefaultRegistry(configs.MQTTBrokers)
+	} else if e.registry == nil {
+		e.registry = elements.NewDefaultRegistry(nil)
+	}
+
 	return e
 }
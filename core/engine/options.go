@@ -0,0 +1,49 @@
+package engine
+
+import (
+	"github.com/Pegasus8/piworker/core/elements"
+	"github.com/Pegasus8/piworker/core/stats"
+	"github.com/Pegasus8/piworker/utilities/clock"
+	"github.com/rs/zerolog"
+)
+
+// Option customizes the engine created by `NewEngine`.
+type Option func(e *Engine)
+
+// WithoutWebUI disables the server of the WebUI. Since the server uses global state, only one engine per process
+// can have it enabled.
+func WithoutWebUI() Option {
+	return func(e *Engine) {
+		e.webUI = false
+	}
+}
+
+// WithStats enables the recollection and the storage of statistics on `s`, which are disabled by default. The
+// statistics are shown by the WebUI too.
+func WithStats(s *stats.Stats) Option {
+	return func(e *Engine) {
+		e.stats = s
+	}
+}
+
+// WithRegistry sets the registry of the triggers and the actions available to the tasks, which by default is a new
+// `elements.NewDefaultRegistry` with the MQTT brokers of the configurations.
+func WithRegistry(registry *elements.Registry) Option {
+	return func(e *Engine) {
+		e.registry = registry
+	}
+}
+
+// WithLogger sets the logger used by the engine, which by default is the global one.
+func WithLogger(logger zerolog.Logger) Option {
+	return func(e *Engine) {
+		e.logger = logger
+	}
+}
+
+// WithClock sets the clock used by the engine and its triggers (see `Engine.Clock`).
+func WithClock(c clock.Clock) Option {
+	return func(e *Engine) {
+		e.Clock = c
+	}
+}
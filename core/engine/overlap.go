@@ -0,0 +1,354 @@
+package engine
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Pegasus8/piworker/core/data"
+	actionsModel "github.com/Pegasus8/piworker/core/elements/actions/shared"
+	"github.com/Pegasus8/piworker/core/engine/queue"
+	"github.com/Pegasus8/piworker/core/stats"
+)
+
+// runOutcome is reported by the executions of a task to its loop when the loop must be finished: because of a failure
+// (`err`) or because a hook requires it (`stop`).
+type runOutcome struct {
+	err  error
+	stop bool
+}
+
+type pendingRun struct {
+	task          data.UserTask
+	chainedResult *actionsModel.ChainedResult
+	// triggeredAt is the moment of the activation (or of the request of the user), registered on the execution even
+	// if the run waits for its turn.
+	triggeredAt time.Time
+	// manual indicates that the run has been requested by the user, see `runNow`.
+	manual bool
+}
+
+// taskRunner executes the actions of a task every time that its trigger is activated, applying the overlap policy of
+// the task. It's used by the loop of the task, which receives through `outcomes` the reason to finish. The runs
+// requested by the user go through the same runner, so they are subject to the overlap policy too.
+type taskRunner struct {
+	engine       *Engine
+	ctx          context.Context
+	cancel       context.CancelFunc
+	actionsQueue *queue.Queue
+	outcomes     chan runOutcome
+	// freed is signaled every time that an execution finishes, see `canRun`.
+	freed chan struct{}
+
+	mutex   sync.Mutex
+	wg      sync.WaitGroup
+	running int
+	pending *pendingRun
+	// failuresReset avoids resetting the counter of failures of the task after each successful execution.
+	failuresReset bool
+}
+
+func (engine *Engine) newTaskRunner(ctx context.Context, actionsQueue *queue.Queue) *taskRunner {
+	ctx, cancel := context.WithCancel(ctx)
+
+	return &taskRunner{
+		engine:       engine,
+		ctx:          ctx,
+		cancel:       cancel,
+		actionsQueue: actionsQueue,
+		outcomes:     make(chan runOutcome),
+		freed:        make(chan struct{}, 1),
+	}
+}
+
+// activate handles an activation of the trigger of the task. `chainedResult` is given to the first action.
+func (r *taskRunner) activate(task data.UserTask, chainedResult *actionsModel.ChainedResult) {
+	r.schedule(pendingRun{task: task, chainedResult: chainedResult, triggeredAt: r.engine.Clock.Now()})
+}
+
+// runNow handles a run of the task requested by the user, see `Engine.runTaskNow`.
+func (r *taskRunner) runNow(task data.UserTask) {
+	r.schedule(pendingRun{
+		task:          task,
+		chainedResult: &actionsModel.ChainedResult{},
+		triggeredAt:   r.engine.Clock.Now(),
+		manual:        true,
+	})
+}
+
+// schedule starts, queues or skips the run according to the overlap policy of the task.
+func (r *taskRunner) schedule(p pendingRun) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.running < concurrencyLimit(&p.task) {
+		r.start(p)
+		return
+	}
+
+	if p.task.Settings.Overlap == data.OverlapQueue {
+		r.engine.logger.Info().Str("taskID", p.task.ID).Bool("manual", p.manual).Msg("The task is still running, execution queued")
+		r.pending = &p
+
+		return
+	}
+
+	r.engine.logger.Info().
+		Str("taskID", p.task.ID).
+		Bool("manual", p.manual).
+		Int("running", r.running).
+		Msg("The task is still running, activation skipped")
+}
+
+// canRun reports whether a new run of the task would be started right now, without being queued or skipped. Once it
+// returns false, `freed` is signaled when an execution finishes.
+func (r *taskRunner) canRun(task *data.UserTask) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.running < concurrencyLimit(task)
+}
+
+// concurrencyLimit returns the maximum number of simultaneous executions of the task.
+func concurrencyLimit(task *data.UserTask) int {
+	if task.Settings.Overlap == data.OverlapParallel {
+		return int(task.Settings.MaxConcurrentRuns)
+	}
+
+	return 1
+}
+
+// start runs the task on a new goroutine. Must be called with the mutex locked.
+func (r *taskRunner) start(p pendingRun) {
+	r.running++
+	r.wg.Add(1)
+
+	go func() {
+		defer r.wg.Done()
+
+		if p.manual {
+			r.engine.runTaskNow(r.ctx, &p.task, r.actionsQueue, p.triggeredAt)
+		} else {
+			r.run(&p.task, p.chainedResult, p.triggeredAt)
+		}
+		r.finish()
+	}()
+}
+
+// catchUp runs the task `times` times, one after the other, to recover the activations of its trigger missed while
+// PiWorker was stopped. Meanwhile, the activations of the trigger are handled as if the task were running once.
+func (r *taskRunner) catchUp(task data.UserTask, times int) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.running++
+	r.wg.Add(1)
+	triggeredAt := r.engine.Clock.Now()
+
+	go func() {
+		defer r.wg.Done()
+
+		for i := 0; i < times && r.ctx.Err() == nil; i++ {
+			r.engine.logger.Info().
+				Str("taskID", task.ID).
+				Int("execution", i+1).
+				Int("executions", times).
+				Msg("Recovering missed activation of the trigger, running actions...")
+
+			if !r.run(&task, &actionsModel.ChainedResult{}, triggeredAt) {
+				break
+			}
+		}
+
+		r.finish()
+	}()
+}
+
+// finish unregisters an execution once it ends, starting the queued one (if any).
+func (r *taskRunner) finish() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.running--
+
+	select {
+	case r.freed <- struct{}{}:
+	default:
+	}
+
+	if r.pending != nil && r.ctx.Err() == nil {
+		p := r.pending
+		r.pending = nil
+		r.start(*p)
+	}
+}
+
+// run executes the actions of the task once, activated at `triggeredAt`. Returns false if the task must not be
+// executed again.
+func (r *taskRunner) run(task *data.UserTask, chainedResult *actionsModel.ChainedResult, triggeredAt time.Time) bool {
+	engine := r.engine
+
+	err := engine.acquireExecution(r.ctx, task)
+	if err != nil {
+		// The task has been stopped while waiting for its turn.
+		return false
+	}
+
+	execution := engine.startExecution(task.ID, triggeredAt)
+
+	beforeRunActions := engine.Clock.Now()
+	result, err := engine.runActions(r.ctx, task, chainedResult, r.actionsQueue, &execution)
+	actionsExecutionDuration := engine.Clock.Since(beforeRunActions)
+
+	engine.finishExecution(&execution, err)
+
+	// After a failure the state of the task is changed by the loop. Otherwise (a success or a cancellation) it's
+	// restored, unless the user has changed it meanwhile.
+	engine.releaseExecution(task, err == nil || r.ctx.Err() != nil)
+
+	if err != nil && r.ctx.Err() != nil {
+		// The task has been stopped while running, it isn't a failure.
+		r.engine.logger.Info().
+			Str("taskID", task.ID).
+			Msg("Execution of the actions canceled")
+
+		return false
+	}
+
+	if err != nil {
+		r.engine.logger.Error().
+			Str("taskID", task.ID).
+			Err(err).
+			Msg("Error when running the actions of the task")
+
+		// Hook
+		engine.OnTaskExecutionFail(task.ID, err)
+		engine.notifyChainedTasks(task.ID, nil, err)
+
+		// The loop is going to finish, the queued execution is discarded.
+		r.mutex.Lock()
+		r.pending = nil
+		r.mutex.Unlock()
+
+		r.report(runOutcome{err: err})
+
+		return false
+	}
+
+	engine.notifyChainedTasks(task.ID, result, nil)
+
+	// The task works again, so the previous failures are not consecutive anymore.
+	r.mutex.Lock()
+	resetFailures := task.FailureCount > 0 && !r.failuresReset
+	r.failuresReset = r.failuresReset || resetFailures
+	r.mutex.Unlock()
+
+	if resetFailures {
+		err = engine.userdataDB.ResetFailureCount(task.ID)
+		if err != nil {
+			r.engine.logger.Error().
+				Err(err).
+				Str("taskID", task.ID).
+				Msg("Error when trying to reset the counter of failures of the task")
+		}
+	}
+
+	// Hook
+	if !engine.OnTaskExecutionSuccess(task.ID, actionsExecutionDuration) {
+		r.report(runOutcome{stop: true})
+		return false
+	}
+
+	return true
+}
+
+// report sends the outcome to the loop of the task, unless the runner has already been stopped.
+func (r *taskRunner) report(outcome runOutcome) {
+	select {
+	case r.outcomes <- outcome:
+	case <-r.ctx.Done():
+	}
+}
+
+// stop cancels the executions in progress (and the queued one) and waits until all of them finish.
+func (r *taskRunner) stop() {
+	r.cancel()
+	r.wg.Wait()
+}
+
+// acquireExecution waits until the number of executions in progress allows a new one (see the config
+// `Behavior.MaxConcurrentTasks`) and registers it, changing the state of the task to on-execution if it's the only
+// execution of the task. An error is returned if `ctx` is canceled while waiting.
+func (engine *Engine) acquireExecution(ctx context.Context, task *data.UserTask) error {
+	if engine.executionSlots != nil {
+		select {
+		case engine.executionSlots <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	engine.updateStats(func(s *stats.TasksStats) {
+		s.OnExecutionTasks++
+	})
+
+	engine.executionsMutex.Lock()
+	defer engine.executionsMutex.Unlock()
+
+	if engine.runningExecutions == nil {
+		engine.runningExecutions = make(map[string]int)
+	}
+
+	engine.runningExecutions[task.ID]++
+	if engine.runningExecutions[task.ID] > 1 {
+		return nil
+	}
+
+	engine.logger.Info().Str("taskID", task.ID).Msgf("Changing task state to '%s'\n", data.StateTaskOnExecution)
+
+	// The history and the engine don't depend on the state, so a failure here is only logged.
+	err := engine.userdataDB.UpdateTaskState(task.ID, data.StateTaskOnExecution)
+	if err != nil {
+		engine.logger.Error().
+			Err(err).
+			Str("taskID", task.ID).
+			Msgf("Error when trying to update the task state to '%s'\n", data.StateTaskOnExecution)
+	}
+
+	return nil
+}
+
+// releaseExecution unregisters an execution of the task. If it was the last one and `restoreState` is true, the state
+// of the task is restored to its value before the execution, unless it has been changed meanwhile (see
+// `data.DatabaseInstance.RestoreTaskState`).
+func (engine *Engine) releaseExecution(task *data.UserTask, restoreState bool) {
+	if engine.executionSlots != nil {
+		<-engine.executionSlots
+	}
+
+	engine.updateStats(func(s *stats.TasksStats) {
+		s.OnExecutionTasks--
+	})
+
+	engine.executionsMutex.Lock()
+	defer engine.executionsMutex.Unlock()
+
+	engine.runningExecutions[task.ID]--
+	if engine.runningExecutions[task.ID] > 0 {
+		return
+	}
+	delete(engine.runningExecutions, task.ID)
+
+	if !restoreState {
+		return
+	}
+
+	err := engine.userdataDB.RestoreTaskState(task.ID, task.State)
+	if err != nil {
+		engine.logger.Error().
+			Err(err).
+			Str("taskID", task.ID).
+			Str("state", string(task.State)).
+			Msg("Error when trying to restore the state of the task")
+	}
+}
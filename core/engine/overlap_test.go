@@ -0,0 +1,226 @@
+package engine
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Pegasus8/piworker/core/configs"
+	"github.com/Pegasus8/piworker/core/data"
+	"github.com/Pegasus8/piworker/core/elements"
+	actionsModel "github.com/Pegasus8/piworker/core/elements/actions/shared"
+	"github.com/Pegasus8/piworker/core/engine/queue"
+	"github.com/Pegasus8/piworker/utilities/clock"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaskRunner(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "overlap")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := data.NewDB(dir, "overlap.db")
+	if err != nil {
+		panic(err)
+	}
+	defer db.Close()
+
+	// The events emitted by the database are not used.
+	go func() {
+		for range db.EventBus {
+		}
+	}()
+
+	// An action that blocks until it receives a value through `release`. The number of executions in progress is
+	// stored on `running`, and the maximum reached on `maxRunning`.
+	var (
+		mutex      sync.Mutex
+		running    int
+		maxRunning int
+		executions int
+	)
+	started := make(chan struct{}, 10)
+	release := make(chan struct{})
+	blocking := actionsModel.Action{
+		ID: "A97",
+		Run: func(ctx context.Context, _ *actionsModel.ChainedResult, _ *data.UserAction, _ string) (bool, *actionsModel.ChainedResult, error) {
+			mutex.Lock()
+			running++
+			executions++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			mutex.Unlock()
+
+			defer func() {
+				mutex.Lock()
+				running--
+				mutex.Unlock()
+			}()
+
+			started <- struct{}{}
+
+			select {
+			case <-release:
+				return true, &actionsModel.ChainedResult{}, nil
+			case <-ctx.Done():
+				return false, &actionsModel.ChainedResult{}, ctx.Err()
+			}
+		},
+	}
+	registry := elements.NewRegistry()
+	if err := registry.RegisterAction(blocking); err != nil {
+		panic(err)
+	}
+
+	newTask := func(overlap data.OverlapPolicy, maxConcurrentRuns uint8) data.UserTask {
+		task := data.UserTask{
+			Name:    "Overlap " + string(overlap),
+			State:   data.StateTaskActive,
+			Trigger: data.UserTrigger{ID: "T1"},
+			Actions: []data.UserAction{{ID: blocking.ID}},
+			Settings: data.TaskSettings{
+				Overlap:           overlap,
+				MaxConcurrentRuns: maxConcurrentRuns,
+			},
+		}
+
+		err := db.NewTask(&task)
+		if err != nil {
+			panic(err)
+		}
+
+		return task
+	}
+
+	waitStarted := func(msg string) {
+		select {
+		case <-started:
+		case <-time.After(time.Second):
+			assert.Fail(msg)
+		}
+	}
+
+	// activate activates the trigger of the task three times and returns the number of executions done.
+	activate := func(e *Engine, tasks ...data.UserTask) int {
+		mutex.Lock()
+		executions, maxRunning = 0, 0
+		mutex.Unlock()
+
+		var runners []*taskRunner
+		for _, task := range tasks {
+			r := e.newTaskRunner(context.Background(), queue.NewQueue(2, nil))
+			runners = append(runners, r)
+
+			for i := 0; i < 3; i++ {
+				r.activate(task, &actionsModel.ChainedResult{})
+			}
+		}
+
+		waitStarted("the task must be executed")
+		// Give time to the rest of the executions (if any) to start.
+		time.Sleep(50 * time.Millisecond)
+
+		// Release the executions until all of them finish.
+		done := make(chan struct{})
+		go func() {
+			for _, r := range runners {
+				r.wg.Wait()
+			}
+			close(done)
+		}()
+
+		for {
+			select {
+			case release <- struct{}{}:
+			case <-started:
+			case <-done:
+				mutex.Lock()
+				defer mutex.Unlock()
+
+				return executions
+			}
+		}
+	}
+
+	e := NewEngine(db, nil, WithRegistry(registry))
+
+	skip := newTask("", 0)
+	assert.Equal(1, activate(e, skip), "the activations during the execution must be skipped")
+	assert.Equal(1, maxRunning)
+
+	task, err := db.GetTaskByID(skip.ID)
+	assert.NoError(err)
+	assert.Equal(data.StateTaskActive, task.State, "the state of the task must be restored after the execution")
+
+	assert.Equal(2, activate(e, newTask(data.OverlapQueue, 0)), "only the last activation must be queued")
+	assert.Equal(1, maxRunning, "the queued execution must wait for the current one")
+
+	assert.Equal(2, activate(e, newTask(data.OverlapParallel, 2)), "the activations over the limit must be skipped")
+	assert.Equal(2, maxRunning, "the task must be executed concurrently")
+
+	// The runs requested by the user are subject to the overlap policy too.
+	mutex.Lock()
+	executions = 0
+	mutex.Unlock()
+	manual := e.newTaskRunner(context.Background(), queue.NewQueue(2, nil))
+	manual.activate(skip, &actionsModel.ChainedResult{})
+	waitStarted("the task must be executed")
+	manual.runNow(skip)
+	manual.runNow(skip)
+	time.Sleep(50 * time.Millisecond)
+	release <- struct{}{}
+	manual.wg.Wait()
+	mutex.Lock()
+	assert.Equal(1, executions, "the runs requested during the execution must be skipped")
+	mutex.Unlock()
+
+	// The queued run registers the moment of its activation, not the one of its start.
+	fake := clock.NewFake(time.Date(2021, 3, 5, 12, 0, 0, 0, time.Local))
+	queued := newTask(data.OverlapQueue, 0)
+	r := NewEngine(db, nil, WithRegistry(registry), WithClock(fake)).newTaskRunner(context.Background(), queue.NewQueue(2, nil))
+	r.activate(queued, &actionsModel.ChainedResult{})
+	waitStarted("the task must be executed")
+	fake.Advance(time.Minute)
+	r.activate(queued, &actionsModel.ChainedResult{})
+	fake.Advance(time.Minute)
+	release <- struct{}{}
+	waitStarted("the queued run must be executed")
+	release <- struct{}{}
+	r.wg.Wait()
+
+	stored, err := db.GetExecutions(queued.ID, 10, 0)
+	if assert.NoError(err) && assert.Len(*stored, 2) {
+		assert.True((*stored)[0].TriggeredAt.Equal(fake.Now().Add(-time.Minute)), "the queued run must keep the moment "+
+			"of its activation")
+		assert.True((*stored)[1].TriggeredAt.Equal(fake.Now().Add(-2 * time.Minute)))
+	}
+
+	// Two tasks activated at the same time with a global limit of one execution.
+	limited := NewEngine(db, &configs.Configs{Behavior: configs.Behavior{MaxConcurrentTasks: 1}}, WithRegistry(registry))
+	assert.Equal(2, activate(limited, newTask("", 0), newTask("", 0)), "the tasks must wait for their turn")
+	assert.Equal(1, maxRunning, "the global limit must be respected")
+
+	// The state of the task changes while it's running.
+	r = e.newTaskRunner(context.Background(), queue.NewQueue(0, nil))
+	r.activate(skip, &actionsModel.ChainedResult{})
+	waitStarted("the task must be executed")
+
+	task, err = db.GetTaskByID(skip.ID)
+	assert.NoError(err)
+	assert.Equal(data.StateTaskOnExecution, task.State)
+
+	// A stopped runner interrupts its executions.
+	r.stop()
+	mutex.Lock()
+	assert.Equal(0, running, "the executions must be interrupted")
+	mutex.Unlock()
+}
@@ -0,0 +1,137 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/Pegasus8/piworker/core/data"
+	actionsModel "github.com/Pegasus8/piworker/core/elements/actions/shared"
+	"github.com/Pegasus8/piworker/core/engine/queue"
+	"github.com/Pegasus8/piworker/core/types"
+)
+
+// groupResult is the result of one of the actions of a parallel group, as given to the next action.
+type groupResult struct {
+	ID         string       `json:"ID"`
+	Result     string       `json:"result"`
+	ResultType types.PWType `json:"resultType"`
+	Successful bool         `json:"successful"`
+	Error      string       `json:"error,omitempty"`
+}
+
+// runParallelGroup executes at the same time all the actions of the group (the ones that share the same order), all of
+// them receiving `chainedResult`. The result of the group is determined by its join policy and its chained result is
+// a JSON array with the result of each action, in the same order in which they are declared on the task. The returned
+// boolean is false if the execution must be stopped by the hook `OnActionRun`.
+func (engine *Engine) runParallelGroup(ctx context.Context, taskID string, group []data.UserAction, chainedResult *actionsModel.ChainedResult, actionsQueue *queue.Queue, execution *data.TaskExecution, onFailure bool) (queue.ExecResult, bool) {
+	join := group[0].Parallel.Join
+	if join == "" {
+		join = data.JoinAll
+	}
+
+	engine.logger.Info().
+		Str("taskID", taskID).
+		Uint8("actionOrder", group[0].Order).
+		Int("actions", len(group)).
+		Str("join", string(join)).
+		Msg("Running parallel group of actions")
+
+	// Canceled when an action fails and the policy requires all of them to succeed, it's useless to wait for the rest.
+	groupCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mutex    sync.Mutex
+		stopped  bool
+		failure  *queue.ExecResult
+		failures int
+	)
+	results := make([]queue.ExecResult, len(group))
+
+	for i := range group {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			userAction := &group[i]
+
+			var r queue.ExecResult
+			continueExecution := true
+
+			action := engine.action(userAction.ID)
+			if action.ID == "" {
+				r = queue.ExecResult{Err: fmt.Errorf("the action with the ID '%s' cannot be found", userAction.ID)}
+			} else {
+				r, continueExecution = engine.runUserAction(groupCtx, taskID, *action, userAction, chainedResult,
+					actionsQueue, execution, onFailure)
+			}
+
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			results[i] = r
+			if !continueExecution {
+				stopped = true
+			}
+
+			if r.Err == nil && r.Successful {
+				return
+			}
+
+			failures++
+			// Keep the first failure, the actions canceled because of it fail later.
+			if failure == nil {
+				failure = &r
+			}
+
+			if join == data.JoinAll {
+				cancel()
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	if stopped {
+		return queue.ExecResult{}, false
+	}
+
+	// The task has been stopped or has exceeded its timeout.
+	if ctx.Err() != nil {
+		return queue.ExecResult{Err: ctx.Err()}, true
+	}
+
+	if failure != nil && (join == data.JoinAll || failures == len(group)) {
+		return *failure, true
+	}
+
+	joined := make([]groupResult, len(group))
+	for i, r := range results {
+		joined[i] = groupResult{
+			ID:         group[i].ID,
+			Result:     r.RetournedCR.Result,
+			ResultType: r.RetournedCR.ResultType,
+			Successful: r.Successful && r.Err == nil,
+		}
+		if r.Err != nil {
+			joined[i].Error = r.Err.Error()
+		}
+	}
+
+	content, err := json.Marshal(joined)
+	if err != nil {
+		return queue.ExecResult{Err: err}, true
+	}
+
+	return queue.ExecResult{
+		Successful: true,
+		RetournedCR: actionsModel.ChainedResult{
+			Result:     string(content),
+			ResultType: types.JSON,
+		},
+	}, true
+}
@@ -0,0 +1,116 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/Pegasus8/piworker/core/data"
+	"github.com/Pegasus8/piworker/core/elements"
+	actionsModel "github.com/Pegasus8/piworker/core/elements/actions/shared"
+	"github.com/Pegasus8/piworker/core/engine/queue"
+	"github.com/Pegasus8/piworker/core/types"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunParallelGroup(t *testing.T) {
+	assert := assert.New(t)
+	registry := elements.NewRegistry()
+	e := NewEngine(nil, nil, WithRegistry(registry))
+	taskID := uuid.New().String()
+
+	// An action that returns its argument as result, or fails if the argument is "fail". The result received by the
+	// actions that aren't part of the first group is stored on `received`.
+	var mutex sync.Mutex
+	var received *actionsModel.ChainedResult
+	echo := actionsModel.Action{
+		ID:   "A98",
+		Args: []actionsModel.Arg{{ID: "A98-1", ContentType: types.Any}},
+		Run: func(_ context.Context, previousResult *actionsModel.ChainedResult, parentAction *data.UserAction, _ string) (bool, *actionsModel.ChainedResult, error) {
+			if parentAction.Order > 0 {
+				mutex.Lock()
+				received = previousResult
+				mutex.Unlock()
+			}
+
+			if parentAction.Args[0].Content == "fail" {
+				return false, &actionsModel.ChainedResult{}, errors.New("failed on purpose")
+			}
+
+			return true, &actionsModel.ChainedResult{Result: parentAction.Args[0].Content, ResultType: types.Text}, nil
+		},
+	}
+	if err := registry.RegisterAction(echo); err != nil {
+		panic(err)
+	}
+
+	action := func(order uint8, content string, join data.JoinPolicy) data.UserAction {
+		a := data.UserAction{
+			ID:    echo.ID,
+			Args:  []data.UserArg{{ID: "A98-1", Content: content}},
+			Order: order,
+		}
+		if join != "" {
+			a.Parallel = &data.ParallelGroup{Join: join}
+		}
+
+		return a
+	}
+
+	run := func(join data.JoinPolicy, contents ...string) (*data.TaskExecution, error) {
+		received = nil
+
+		var actions []data.UserAction
+		for _, c := range contents {
+			actions = append(actions, action(0, c, join))
+		}
+		actions = append(actions, action(1, "next", ""))
+
+		execution := &data.TaskExecution{TaskID: taskID}
+		_, err := e.runActionList(context.Background(), taskID, actions, &actionsModel.ChainedResult{}, queue.NewQueue(0, nil), execution, false)
+
+		return execution, err
+	}
+
+	// All the actions succeed, the next one receives all their results.
+	execution, err := run(data.JoinAll, "a", "b", "c")
+	assert.NoError(err, "the group should be executed without errors")
+	assert.Len(execution.Actions, 4, "all the actions must be registered")
+	if assert.NotNil(received, "the action after the group must be executed") {
+		assert.Equal(types.JSON, received.ResultType, "the results of the group must be given as JSON")
+
+		var results []groupResult
+		assert.NoError(json.Unmarshal([]byte(received.Result), &results))
+		if assert.Len(results, 3) {
+			for i, content := range []string{"a", "b", "c"} {
+				assert.Equalf(content, results[i].Result, "[%d] the results must keep the order of the actions", i)
+				assert.Truef(results[i].Successful, "[%d] the action must be registered as successful", i)
+			}
+		}
+	}
+
+	// With the policy `all` a single failure makes the group fail.
+	_, err = run(data.JoinAll, "a", "fail", "c")
+	assert.EqualError(err, "failed on purpose", "the group must fail with the error of the action")
+	assert.Nil(received, "the action after a failed group must not be executed")
+
+	// With the policy `any` the group fails only if all the actions fail.
+	_, err = run(data.JoinAny, "a", "fail")
+	assert.NoError(err, "a partial failure must be admitted")
+	if assert.NotNil(received, "the action after the group must be executed") {
+		var results []groupResult
+		assert.NoError(json.Unmarshal([]byte(received.Result), &results))
+		if assert.Len(results, 2) {
+			assert.True(results[0].Successful)
+			assert.False(results[1].Successful, "the failed action must be reported")
+			assert.Equal("failed on purpose", results[1].Error)
+		}
+	}
+
+	_, err = run(data.JoinAny, "fail", "fail")
+	assert.EqualError(err, "failed on purpose", "the group must fail if all the actions fail")
+}
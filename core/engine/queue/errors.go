@@ -0,0 +1,6 @@
+package queue
+
+import "errors"
+
+// ErrClosed is the error used when a job can't be executed because the queue has been closed.
+var ErrClosed = errors.New("the queue of actions is closed")
@@ -1,22 +1,51 @@
 package queue
 
 import (
+	"context"
+	"sync"
+	"time"
+
 	"github.com/Pegasus8/piworker/core/data"
 	actions "github.com/Pegasus8/piworker/core/elements/actions/shared"
 )
 
 // Job represents a task to be executed on the pool.
 type Job struct {
+	// Ctx is given to the action, once canceled the job is discarded (if it's still waiting) or the wait
+	// for its result is abandoned.
+	Ctx        context.Context
 	TaskID     string
 	Action     actions.Action
 	UserAction *data.UserAction
 	PreviousCR actions.ChainedResult
 	OutputChan chan ExecResult
+
+	priority uint8
+	queuedAt time.Time
+	// taken is closed once a worker takes the job from the queue.
+	taken chan struct{}
 }
 
-// Queue is where the jobs are executed.
+// Queue is where the jobs are executed. The waiting jobs are dispatched by the priority of their tasks, and the tasks
+// with the same priority take turns, so a task with many actions can't monopolize the workers.
 type Queue struct {
-	q chan Job
+	mutex sync.Mutex
+	// ready is signaled every time a job is added, and broadcasted once the queue is closed.
+	ready      *sync.Cond
+	levels     map[uint8]*level
+	priorities map[string]uint8
+	// updateStats is nil if the statistics aren't collected.
+	updateStats StatsUpdater
+	// closed indicates that the queue doesn't accept more jobs, see `Queue.Close`.
+	closed bool
+	// workers waits for the workers, which finish once the queue is closed.
+	workers sync.WaitGroup
+}
+
+// level groups the waiting jobs with the same priority by task. `turns` is the order in which the tasks are served.
+type level struct {
+	turns []string
+	jobs  map[string][]*Job
 }
 
 // ExecResult represents the returned result of a executed action.
@@ -1,65 +1,320 @@
 package queue
 
 import (
+	"context"
 	"runtime"
+	"sync"
+	"time"
 
 	"github.com/Pegasus8/piworker/core/data"
 	actions "github.com/Pegasus8/piworker/core/elements/actions/shared"
+	"github.com/Pegasus8/piworker/core/stats"
 
 	"github.com/rs/zerolog/log"
 )
 
-// NewQueue initializes the pool of actions execution.
-func NewQueue() *Queue {
-	var maxWorkers = runtime.NumCPU()
+// StatsUpdater applies the given update to the statistics of the tasks, used to count the jobs waiting and to measure
+// their wait (see `Engine.updateStats`).
+type StatsUpdater func(update func(s *stats.TasksStats))
+
+// NewQueue initializes the pool of actions execution with the given number of workers. If it's zero, one worker per
+// CPU is used. The statistics of the queue are updated through `updateStats`, or not collected if it's nil.
+func NewQueue(workers int, updateStats StatsUpdater) *Queue {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
 
 	// Is this possible?
-	if maxWorkers <= 0 {
-		maxWorkers = 2
+	if workers <= 0 {
+		workers = 2
 	}
 
 	queue := &Queue{
-		q: make(chan Job),
+		levels:      make(map[uint8]*level),
+		priorities:  make(map[string]uint8),
+		updateStats: updateStats,
 	}
+	queue.ready = sync.NewCond(&queue.mutex)
 
-	for i := 1; i <= maxWorkers; i++ {
-		go worker(i, queue.q)
+	queue.workers.Add(workers)
+	for i := 1; i <= workers; i++ {
+		go worker(i, queue)
 	}
 
 	return queue
 }
 
-// AddJob adds a new job to be processed by the workers.
-func (q *Queue) AddJob(taskID string, action actions.Action, userAction *data.UserAction, previousCR actions.ChainedResult) (result chan ExecResult) {
-	j := Job{
+// SetPriority sets the priority of the jobs added from now on by the given task. The tasks without priority use zero.
+func (q *Queue) SetPriority(taskID string, priority uint8) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.priorities[taskID] = priority
+}
+
+// RemoveTask forgets the priority of the task.
+func (q *Queue) RemoveTask(taskID string) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	delete(q.priorities, taskID)
+}
+
+// Close stops the workers once they finish the jobs in progress and waits for them. The jobs still waiting, and the
+// ones added from now on, are reported with `ErrClosed`.
+func (q *Queue) Close() {
+	q.mutex.Lock()
+	q.closed = true
+
+	for priority, l := range q.levels {
+		for _, jobs := range l.jobs {
+			for _, j := range jobs {
+				close(j.taken)
+				j.OutputChan <- ExecResult{Err: ErrClosed}
+
+				q.stats(func(s *stats.TasksStats) {
+					s.QueuedActions--
+				})
+			}
+		}
+		delete(q.levels, priority)
+	}
+
+	q.ready.Broadcast()
+	q.mutex.Unlock()
+
+	q.workers.Wait()
+}
+
+// AddJob adds a new job to be processed by the workers. If `ctx` is canceled before a worker takes the job, or
+// while the action is running, the result is reported with the error of the context.
+func (q *Queue) AddJob(ctx context.Context, taskID string, action actions.Action, userAction *data.UserAction, previousCR actions.ChainedResult) (result chan ExecResult) {
+	j := &Job{
+		Ctx:        ctx,
 		TaskID:     taskID,
 		Action:     action,
 		UserAction: userAction,
 		PreviousCR: previousCR,
-		OutputChan: make(chan ExecResult),
+		// Buffered, so the worker never gets blocked by a receiver that isn't waiting anymore.
+		OutputChan: make(chan ExecResult, 1),
+		queuedAt:   time.Now(),
+		taken:      make(chan struct{}),
 	}
 
-	q.q <- j
+	if err := ctx.Err(); err != nil {
+		j.OutputChan <- ExecResult{Err: err}
+		return j.OutputChan
+	}
+
+	if !q.push(j) {
+		j.OutputChan <- ExecResult{Err: ErrClosed}
+		return j.OutputChan
+	}
+
+	go func() {
+		select {
+		case <-j.taken:
+		case <-ctx.Done():
+			if q.remove(j) {
+				j.OutputChan <- ExecResult{Err: ctx.Err()}
+			}
+		}
+	}()
 
 	return j.OutputChan
 }
 
-func worker(id int, inputs chan Job) {
+// push adds the job to the queue. Returns false if the queue is closed.
+func (q *Queue) push(j *Job) bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.closed {
+		return false
+	}
+
+	j.priority = q.priorities[j.TaskID]
+
+	l, ok := q.levels[j.priority]
+	if !ok {
+		l = &level{jobs: make(map[string][]*Job)}
+		q.levels[j.priority] = l
+	}
+
+	if len(l.jobs[j.TaskID]) == 0 {
+		l.turns = append(l.turns, j.TaskID)
+	}
+	l.jobs[j.TaskID] = append(l.jobs[j.TaskID], j)
+
+	q.stats(func(s *stats.TasksStats) {
+		s.QueuedActions++
+	})
+
+	q.ready.Signal()
+
+	return true
+}
+
+// next waits until there is a job in the queue and takes the one with the highest priority. Between the tasks with
+// that priority, the job is taken from the first one in turn, which goes to the end of the turns if it still has
+// jobs waiting. Returns false once the queue is closed.
+func (q *Queue) next() (*Job, bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for len(q.levels) == 0 && !q.closed {
+		q.ready.Wait()
+	}
+
+	if q.closed {
+		return nil, false
+	}
+
+	var priority uint8
+	for p := range q.levels {
+		if p > priority {
+			priority = p
+		}
+	}
+
+	l := q.levels[priority]
+	taskID := l.turns[0]
+	j := l.jobs[taskID][0]
+
+	l.jobs[taskID] = l.jobs[taskID][1:]
+	l.turns = l.turns[1:]
+	if len(l.jobs[taskID]) > 0 {
+		l.turns = append(l.turns, taskID)
+	} else {
+		delete(l.jobs, taskID)
+	}
+
+	if len(l.turns) == 0 {
+		delete(q.levels, priority)
+	}
+
+	close(j.taken)
+
+	q.stats(func(s *stats.TasksStats) {
+		s.QueuedActions--
+		s.NewQueueWaitObs(time.Since(j.queuedAt))
+	})
+
+	return j, true
+}
+
+// remove discards the job if it's still waiting. Returns false if a worker has already taken it.
+func (q *Queue) remove(j *Job) bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	l, ok := q.levels[j.priority]
+	if !ok {
+		return false
+	}
+
+	jobs := l.jobs[j.TaskID]
+	for i := range jobs {
+		if jobs[i] != j {
+			continue
+		}
+
+		l.jobs[j.TaskID] = append(jobs[:i], jobs[i+1:]...)
+		if len(l.jobs[j.TaskID]) == 0 {
+			delete(l.jobs, j.TaskID)
+
+			for t := range l.turns {
+				if l.turns[t] == j.TaskID {
+					l.turns = append(l.turns[:t], l.turns[t+1:]...)
+					break
+				}
+			}
+		}
+
+		if len(l.turns) == 0 {
+			delete(q.levels, j.priority)
+		}
+
+		q.stats(func(s *stats.TasksStats) {
+			s.QueuedActions--
+		})
+
+		return true
+	}
+
+	return false
+}
+
+// stats applies the update to the statistics, if they are collected.
+func (q *Queue) stats(update func(s *stats.TasksStats)) {
+	if q.updateStats != nil {
+		q.updateStats(update)
+	}
+}
+
+func worker(id int, q *Queue) {
+	defer q.workers.Done()
+
 	log.Info().Int("workerID", id).Msg("Starting worker")
 
 	for {
-		job := <-inputs
+		job, ok := q.next()
+		if !ok {
+			log.Info().Int("workerID", id).Msg("Queue closed, stopping worker")
+			return
+		}
 		log.Info().Int("workerID", id).Str("taskID", job.TaskID).Msg("New job received!")
 
-		// Action execution
-		r, cr, err := job.Action.Run(&job.PreviousCR, job.UserAction, job.TaskID)
-		execResult := ExecResult{
+		// The job could have been canceled while it was waiting for a worker.
+		if err := job.Ctx.Err(); err != nil {
+			log.Info().Int("workerID", id).Str("taskID", job.TaskID).Msg("Job canceled before its execution")
+			job.OutputChan <- ExecResult{Err: err}
+			continue
+		}
+
+		execResult := runJob(job)
+		if execResult.Err != nil && job.Ctx.Err() != nil {
+			log.Warn().
+				Int("workerID", id).
+				Str("taskID", job.TaskID).
+				Err(execResult.Err).
+				Msg("Job interrupted, the worker is released")
+		}
+
+		log.Info().Int("workerID", id).Str("taskID", job.TaskID).Msg("Job executed!, reporting result...")
+		job.OutputChan <- execResult
+	}
+}
+
+// runJob executes the action of the job and waits until it finishes or until the context of the job is canceled,
+// whichever happens first. In the last case the action is abandoned, so an action that doesn't watch its context
+// can't keep the worker busy forever.
+func runJob(job *Job) ExecResult {
+	done := make(chan ExecResult, 1)
+
+	go func() {
+		r, cr, err := job.Action.Run(job.Ctx, &job.PreviousCR, job.UserAction, job.TaskID)
+		if cr == nil {
+			cr = &actions.ChainedResult{}
+		}
+
+		done <- ExecResult{
 			Successful:  r,
 			RetournedCR: *cr,
 			Err:         err,
 		}
+	}()
 
-		log.Info().Int("workerID", id).Str("taskID", job.TaskID).Msg("Job executed!, reporting result...")
-		job.OutputChan <- execResult
+	select {
+	case r := <-done:
+		return r
+	case <-job.Ctx.Done():
+		// Give priority to the result if the action finished at the same time.
+		select {
+		case r := <-done:
+			return r
+		default:
+			return ExecResult{Err: job.Ctx.Err()}
+		}
 	}
 }
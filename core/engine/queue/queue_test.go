@@ -0,0 +1,156 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Pegasus8/piworker/core/data"
+	actions "github.com/Pegasus8/piworker/core/elements/actions/shared"
+	"github.com/Pegasus8/piworker/core/stats"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueue(t *testing.T) {
+	assert := assert.New(t)
+
+	// The statistics of the queue, protected by `statsMutex`.
+	var statsMutex sync.Mutex
+	var s stats.TasksStats
+	updateStats := func(update func(s *stats.TasksStats)) {
+		statsMutex.Lock()
+		defer statsMutex.Unlock()
+
+		update(&s)
+	}
+
+	// Only one worker, so the jobs are executed one by one in the order chosen by the queue.
+	q := NewQueue(1, updateStats)
+
+	var mutex sync.Mutex
+	var order []string
+	record := actions.Action{
+		ID: "A96",
+		Run: func(_ context.Context, _ *actions.ChainedResult, parentAction *data.UserAction, _ string) (bool, *actions.ChainedResult, error) {
+			mutex.Lock()
+			order = append(order, parentAction.ID)
+			mutex.Unlock()
+
+			return true, &actions.ChainedResult{}, nil
+		},
+	}
+
+	// The worker is kept busy until all the jobs are added.
+	started := make(chan struct{})
+	release := make(chan struct{})
+	blocking := actions.Action{
+		ID: "A97",
+		Run: func(_ context.Context, _ *actions.ChainedResult, _ *data.UserAction, _ string) (bool, *actions.ChainedResult, error) {
+			close(started)
+			<-release
+
+			return true, &actions.ChainedResult{}, nil
+		},
+	}
+	blocked := q.AddJob(context.Background(), "blocking", blocking, &data.UserAction{}, actions.ChainedResult{})
+	<-started
+
+	q.SetPriority("high", 5)
+
+	var results []chan ExecResult
+	for _, j := range []struct{ taskID, label string }{
+		{"low-1", "L1-1"},
+		{"low-1", "L1-2"},
+		{"low-2", "L2-1"},
+		{"low-2", "L2-2"},
+		{"high", "H-1"},
+	} {
+		results = append(results, q.AddJob(context.Background(), j.taskID, record, &data.UserAction{ID: j.label},
+			actions.ChainedResult{}))
+	}
+
+	// A job canceled while it's waiting is discarded without waiting for a worker.
+	ctx, cancel := context.WithCancel(context.Background())
+	canceled := q.AddJob(ctx, "low-1", record, &data.UserAction{ID: "canceled"}, actions.ChainedResult{})
+
+	statsMutex.Lock()
+	assert.Equal(uint32(6), s.QueuedActions, "the waiting jobs must be counted")
+	statsMutex.Unlock()
+
+	cancel()
+	select {
+	case r := <-canceled:
+		assert.Equal(context.Canceled, r.Err)
+	case <-time.After(time.Second):
+		assert.Fail("the canceled job must be discarded")
+	}
+
+	close(release)
+	<-blocked
+	for _, r := range results {
+		assert.NoError((<-r).Err)
+	}
+
+	assert.Equal([]string{"H-1", "L1-1", "L2-1", "L1-2", "L2-2"}, order,
+		"the jobs must be executed by priority, taking turns between the tasks")
+
+	statsMutex.Lock()
+	assert.Equal(uint32(0), s.QueuedActions, "the queue must be empty")
+	assert.NotZero(s.AverageQueueWait, "the wait of the jobs must be measured")
+	statsMutex.Unlock()
+}
+
+func TestQueueClose(t *testing.T) {
+	assert := assert.New(t)
+
+	q := NewQueue(1, nil)
+
+	// The worker is kept busy until the queue is closed.
+	started := make(chan struct{})
+	release := make(chan struct{})
+	blocking := actions.Action{
+		ID: "A97",
+		Run: func(_ context.Context, _ *actions.ChainedResult, _ *data.UserAction, _ string) (bool, *actions.ChainedResult, error) {
+			close(started)
+			<-release
+
+			return true, &actions.ChainedResult{}, nil
+		},
+	}
+	running := q.AddJob(context.Background(), "task-1", blocking, &data.UserAction{}, actions.ChainedResult{})
+	<-started
+	waiting := q.AddJob(context.Background(), "task-1", blocking, &data.UserAction{}, actions.ChainedResult{})
+
+	closed := make(chan struct{})
+	go func() {
+		q.Close()
+		close(closed)
+	}()
+
+	select {
+	case r := <-waiting:
+		assert.Equal(ErrClosed, r.Err, "the jobs waiting must be discarded")
+	case <-time.After(time.Second):
+		assert.Fail("the jobs waiting must be discarded")
+	}
+
+	select {
+	case <-closed:
+		assert.Fail("the jobs in progress must be waited")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(release)
+	assert.NoError((<-running).Err)
+
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		assert.Fail("the workers must be stopped once the queue is closed")
+	}
+
+	r := <-q.AddJob(context.Background(), "task-1", blocking, &data.UserAction{}, actions.ChainedResult{})
+	assert.Equal(ErrClosed, r.Err, "a closed queue must not accept more jobs")
+}
@@ -0,0 +1,48 @@
+package engine
+
+import (
+	"context"
+	"time"
+
+	"github.com/Pegasus8/piworker/core/data"
+)
+
+// shouldRestart reports whether a failed task must be restarted according to its policy. `failureCount` is the number
+// of consecutive failures of the task, including the last one.
+func shouldRestart(policy *data.RestartPolicy, failureCount uint16) bool {
+	if policy == nil {
+		return false
+	}
+
+	switch policy.Mode {
+	case data.RestartAlways:
+		return true
+	case data.RestartOnFailure:
+		return failureCount <= policy.MaxRestarts
+	default:
+		return false
+	}
+}
+
+// scheduleRestart sends the ID of the task through `restarts` once the cooldown of its policy has passed since its last
+// failure, unless `ctx` is canceled before.
+func (engine *Engine) scheduleRestart(ctx context.Context, task *data.UserTask, restarts chan<- string) {
+	cooldown := time.Millisecond * time.Duration(task.Settings.Restart.Cooldown)
+	if !task.LastFailure.IsZero() {
+		// The task could have failed long ago, for example before the start of the engine.
+		cooldown -= engine.Clock.Since(task.LastFailure)
+	}
+
+	go func() {
+		select {
+		case <-engine.Clock.After(cooldown):
+		case <-ctx.Done():
+			return
+		}
+
+		select {
+		case restarts <- task.ID:
+		case <-ctx.Done():
+		}
+	}()
+}
@@ -0,0 +1,83 @@
+package engine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Pegasus8/piworker/core/data"
+	"github.com/Pegasus8/piworker/utilities/clock"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShouldRestart(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.False(shouldRestart(nil, 1), "a task without policy must not be restarted")
+	assert.False(shouldRestart(&data.RestartPolicy{Mode: data.RestartNever}, 1))
+	assert.True(shouldRestart(&data.RestartPolicy{Mode: data.RestartAlways}, 1000), "'always' has no limit")
+
+	onFailure := &data.RestartPolicy{Mode: data.RestartOnFailure, MaxRestarts: 2}
+	assert.True(shouldRestart(onFailure, 1))
+	assert.True(shouldRestart(onFailure, 2))
+	assert.False(shouldRestart(onFailure, 3), "the task must not be restarted after the maximum of restarts")
+}
+
+func TestScheduleRestart(t *testing.T) {
+	assert := assert.New(t)
+
+	e := NewEngine(nil, nil)
+	fake := clock.NewFake(time.Now())
+	e.Clock = fake
+
+	task := &data.UserTask{ID: "task-1", Settings: data.TaskSettings{Restart: &data.RestartPolicy{
+		Mode:     data.RestartAlways,
+		Cooldown: 20,
+	}}}
+	restarts := make(chan string)
+
+	e.scheduleRestart(context.Background(), task, restarts)
+	fake.BlockUntil(1)
+
+	fake.Advance(19 * time.Millisecond)
+	select {
+	case <-restarts:
+		assert.Fail("the cooldown must be respected")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	fake.Advance(time.Millisecond)
+	select {
+	case id := <-restarts:
+		assert.Equal(task.ID, id)
+	case <-time.After(time.Second):
+		assert.Fail("the restart must be scheduled")
+	}
+
+	// The cooldown is counted from the last failure of the task.
+	failed := *task
+	failed.LastFailure = fake.Now().Add(-15 * time.Millisecond)
+	e.scheduleRestart(context.Background(), &failed, restarts)
+	fake.BlockUntil(1)
+
+	fake.Advance(5 * time.Millisecond)
+	select {
+	case id := <-restarts:
+		assert.Equal(task.ID, id)
+	case <-time.After(time.Second):
+		assert.Fail("the time passed since the last failure must be discounted from the cooldown")
+	}
+
+	// A canceled restart is never sent.
+	ctx, cancel := context.WithCancel(context.Background())
+	e.scheduleRestart(ctx, task, restarts)
+	cancel()
+	fake.Advance(time.Second)
+
+	select {
+	case <-restarts:
+		assert.Fail("a canceled restart must not be sent")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,125 @@
+package engine
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"github.com/Pegasus8/piworker/core/data"
+	actionsModel "github.com/Pegasus8/piworker/core/elements/actions/shared"
+	"github.com/Pegasus8/piworker/core/engine/queue"
+	"github.com/Pegasus8/piworker/core/stats"
+)
+
+// runAction sends the action to the queue and waits for its result. If the execution fails and the action has a
+// retry policy, it's sent again until it succeeds or the policy doesn't admit more attempts. Each attempt is
+// registered on the execution of the task (marked as executed on failure if `onFailure` is true). Each attempt
+// is limited by the timeout of the action, and no more attempts are made once `ctx` (the context of the whole
+// execution of the task) is done.
+func (engine *Engine) runAction(ctx context.Context, taskID string, action actionsModel.Action, userAction *data.UserAction, previousCR *actionsModel.ChainedResult, actionsQueue *queue.Queue, execution *data.TaskExecution, onFailure bool) queue.ExecResult {
+	var attempt uint8 = 1
+
+	for {
+		started := engine.Clock.Now()
+
+		actionCtx, cancel := withTimeout(ctx, userAction.Timeout)
+		execResult := actionsQueue.AddJob(actionCtx, taskID, action, userAction, *previousCR)
+		r := <-execResult
+		cancel()
+
+		engine.registerActionExecution(execution, userAction, started, &r, attempt, onFailure)
+
+		// If the task has been stopped or has exceeded its own timeout, there is no reason to retry.
+		if ctx.Err() != nil || !shouldRetry(userAction.Retry, &r, attempt) {
+			return r
+		}
+
+		delay := retryDelay(userAction.Retry, attempt)
+
+		l := engine.logger.Warn().
+			Str("taskID", taskID).
+			Str("actionID", userAction.ID).
+			Uint8("actionOrder", userAction.Order).
+			Uint8("attempt", attempt).
+			Uint8("maxAttempts", userAction.Retry.MaxAttempts).
+			Dur("delay", delay)
+		if r.Err != nil {
+			l = l.Err(r.Err)
+		}
+		l.Msg("Action wasn't executed correctly, retrying...")
+
+		engine.updateStats(func(s *stats.TasksStats) {
+			s.RetriedActions++
+		})
+
+		select {
+		case <-engine.Clock.After(delay):
+		case <-ctx.Done():
+			return queue.ExecResult{Err: ctx.Err()}
+		}
+		attempt++
+	}
+}
+
+// shouldRetry checks if the result of the given attempt must be retried according to the policy.
+func shouldRetry(policy *data.RetryPolicy, r *queue.ExecResult, attempt uint8) bool {
+	if policy == nil || attempt >= policy.MaxAttempts {
+		return false
+	}
+
+	if r.Err == nil {
+		return !r.Successful && policy.RetryUnsuccessful
+	}
+
+	if policy.RetryOn == "" {
+		return true
+	}
+
+	rgx, err := regexp.Compile(policy.RetryOn)
+	if err != nil {
+		// Shouldn't happen, the expression is checked when the task is stored.
+		return false
+	}
+
+	return rgx.MatchString(r.Err.Error())
+}
+
+// maxRetryDelay is the longest time to wait between two attempts of an action. It saturates the exponential backoff
+// of the policies without `MaxDelay`, which would overflow `time.Duration` after a few dozens of attempts.
+const maxRetryDelay = 24 * time.Hour
+
+// retryDelay returns the time to wait after the given attempt.
+func retryDelay(policy *data.RetryPolicy, attempt uint8) time.Duration {
+	maxDelay := maxRetryDelay
+	if policy.MaxDelay > 0 && policy.MaxDelay < int64(maxRetryDelay/time.Millisecond) {
+		maxDelay = time.Duration(policy.MaxDelay) * time.Millisecond
+	}
+
+	if policy.Delay >= int64(maxDelay/time.Millisecond) {
+		return maxDelay
+	}
+
+	delay := time.Duration(policy.Delay) * time.Millisecond
+
+	if policy.Backoff == data.BackoffExponential {
+		for i := uint8(1); i < attempt && delay < maxDelay; i++ {
+			delay *= 2
+		}
+	}
+
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+
+	return delay
+}
+
+// withTimeout returns a cancelable copy of `parent` which, if `timeout` (in milliseconds) is greater than zero,
+// expires once that time has elapsed.
+func withTimeout(parent context.Context, timeout int64) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(parent)
+	}
+
+	return context.WithTimeout(parent, time.Duration(timeout)*time.Millisecond)
+}
@@ -0,0 +1,185 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Pegasus8/piworker/core/data"
+	actionsModel "github.com/Pegasus8/piworker/core/elements/actions/shared"
+	"github.com/Pegasus8/piworker/core/engine/queue"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShouldRetry(t *testing.T) {
+	assert := assert.New(t)
+
+	policy := &data.RetryPolicy{
+		MaxAttempts: 3,
+		RetryOn:     "timeout|connection refused",
+	}
+
+	assert.False(shouldRetry(nil, &queue.ExecResult{Err: errors.New("timeout")}, 1),
+		"an action without policy must not be retried")
+	assert.True(shouldRetry(policy, &queue.ExecResult{Err: errors.New("dial tcp: connection refused")}, 1),
+		"an error matching the expression must be retried")
+	assert.False(shouldRetry(policy, &queue.ExecResult{Err: errors.New("permission denied")}, 1),
+		"an error not matching the expression must not be retried")
+	assert.False(shouldRetry(policy, &queue.ExecResult{Err: errors.New("timeout")}, 3),
+		"the action must not be retried once the max amount of attempts is reached")
+	assert.False(shouldRetry(policy, &queue.ExecResult{Successful: false}, 1),
+		"an unsuccessful result without error must not be retried by default")
+	assert.False(shouldRetry(policy, &queue.ExecResult{Successful: true}, 1),
+		"a successful result must not be retried")
+
+	policy.RetryUnsuccessful = true
+	assert.True(shouldRetry(policy, &queue.ExecResult{Successful: false}, 1),
+		"an unsuccessful result must be retried if the policy requires it")
+
+	policy.RetryOn = ""
+	assert.True(shouldRetry(policy, &queue.ExecResult{Err: errors.New("permission denied")}, 2),
+		"any error must be retried if there is no expression")
+}
+
+func TestRetryDelay(t *testing.T) {
+	assert := assert.New(t)
+
+	fixed := &data.RetryPolicy{MaxAttempts: 5, Backoff: data.BackoffFixed, Delay: 100}
+	for attempt := uint8(1); attempt < 5; attempt++ {
+		assert.Equalf(100*time.Millisecond, retryDelay(fixed, attempt), "[attempt %d] wrong delay", attempt)
+	}
+
+	exponential := &data.RetryPolicy{MaxAttempts: 10, Backoff: data.BackoffExponential, Delay: 100, MaxDelay: 500}
+	expected := []time.Duration{100, 200, 400, 500, 500, 500}
+	for i, e := range expected {
+		attempt := uint8(i + 1)
+		assert.Equalf(e*time.Millisecond, retryDelay(exponential, attempt), "[attempt %d] wrong delay", attempt)
+	}
+
+	// Without a limit, the delay saturates instead of overflowing.
+	unlimited := &data.RetryPolicy{MaxAttempts: 255, Backoff: data.BackoffExponential, Delay: 100}
+	for attempt := uint8(30); attempt < 255; attempt++ {
+		assert.Equalf(maxRetryDelay, retryDelay(unlimited, attempt), "[attempt %d] wrong delay", attempt)
+	}
+}
+
+func TestRunAction(t *testing.T) {
+	assert := assert.New(t)
+	e := NewEngine(nil, nil)
+	taskID := uuid.New().String()
+
+	var calls int
+	flaky := actionsModel.Action{
+		ID: "A99",
+		Run: func(_ context.Context, _ *actionsModel.ChainedResult, _ *data.UserAction, _ string) (bool, *actionsModel.ChainedResult, error) {
+			calls++
+			if calls < 3 {
+				return false, &actionsModel.ChainedResult{}, errors.New("temporary failure")
+			}
+
+			return true, &actionsModel.ChainedResult{Result: "ok"}, nil
+		},
+	}
+	userAction := &data.UserAction{
+		ID: flaky.ID,
+		Retry: &data.RetryPolicy{
+			MaxAttempts: 3,
+			Backoff:     data.BackoffExponential,
+			Delay:       1,
+		},
+	}
+	execution := &data.TaskExecution{TaskID: taskID}
+
+	r := e.runAction(context.Background(), taskID, flaky, userAction, &actionsModel.ChainedResult{}, queue.NewQueue(0, nil), execution, false)
+	assert.NoError(r.Err, "the last attempt must be the returned one")
+	assert.True(r.Successful)
+	assert.Equal(3, calls, "the action must be executed until it succeeds")
+	if assert.Len(execution.Actions, 3, "each attempt must be registered") {
+		for i, a := range execution.Actions {
+			assert.Equalf(uint8(i+1), a.Attempt, "[attempt %d] wrong attempt number", i)
+		}
+		assert.Equal("temporary failure", execution.Actions[0].Error)
+		assert.True(execution.Actions[2].Successful)
+	}
+
+	// Without more attempts the failure must be returned.
+	calls = 0
+	userAction.Retry.MaxAttempts = 2
+	execution = &data.TaskExecution{TaskID: taskID}
+	r = e.runAction(context.Background(), taskID, flaky, userAction, &actionsModel.ChainedResult{}, queue.NewQueue(0, nil), execution, false)
+	assert.Error(r.Err, "the error of the last attempt must be returned")
+	assert.Equal(2, calls, "the action must not be executed more times than the allowed by the policy")
+}
+
+func TestRunActionTimeout(t *testing.T) {
+	assert := assert.New(t)
+	e := NewEngine(nil, nil)
+	taskID := uuid.New().String()
+
+	// An action that doesn't finish until its context is done.
+	var calls int
+	hung := actionsModel.Action{
+		ID: "A99",
+		Run: func(ctx context.Context, _ *actionsModel.ChainedResult, _ *data.UserAction, _ string) (bool, *actionsModel.ChainedResult, error) {
+			calls++
+			<-ctx.Done()
+
+			return false, &actionsModel.ChainedResult{}, ctx.Err()
+		},
+	}
+	userAction := &data.UserAction{
+		ID:      hung.ID,
+		Timeout: 10,
+		Retry:   &data.RetryPolicy{MaxAttempts: 2, Delay: 1},
+	}
+	execution := &data.TaskExecution{TaskID: taskID}
+
+	r := e.runAction(context.Background(), taskID, hung, userAction, &actionsModel.ChainedResult{}, queue.NewQueue(0, nil), execution, false)
+	assert.True(errors.Is(r.Err, context.DeadlineExceeded), "the action must be interrupted by its timeout")
+	assert.Equal(2, calls, "each attempt must have its own timeout")
+	assert.Len(execution.Actions, 2, "each attempt must be registered")
+
+	// Once the context of the task is done no more attempts must be made.
+	calls = 0
+	userAction.Timeout = 0
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	execution = &data.TaskExecution{TaskID: taskID}
+
+	r = e.runAction(ctx, taskID, hung, userAction, &actionsModel.ChainedResult{}, queue.NewQueue(0, nil), execution, false)
+	assert.True(errors.Is(r.Err, context.DeadlineExceeded), "the action must be interrupted by the timeout of the task")
+	assert.Equal(1, calls, "the action must not be retried once the task has been interrupted")
+
+	// A canceled context must discard the job.
+	calls = 0
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	r = e.runAction(canceled, taskID, hung, userAction, &actionsModel.ChainedResult{}, queue.NewQueue(0, nil), execution, false)
+	assert.True(errors.Is(r.Err, context.Canceled), "the job of a canceled task must report the cancellation")
+	assert.Equal(0, calls, "the action of a canceled task must not be executed")
+}
+
+func TestFinishExecution(t *testing.T) {
+	assert := assert.New(t)
+	e := NewEngine(nil, nil)
+
+	cases := []struct {
+		err      error
+		expected data.ExecutionOutcome
+	}{
+		{nil, data.OutcomeSuccess},
+		{errors.New("exit status 1"), data.OutcomeFailure},
+		{context.DeadlineExceeded, data.OutcomeTimedOut},
+		{context.Canceled, data.OutcomeCanceled},
+	}
+
+	for i, c := range cases {
+		execution := &data.TaskExecution{}
+		e.finishExecution(execution, c.err)
+		assert.Equalf(c.expected, execution.Outcome, "[case %d] wrong outcome", i)
+	}
+}
@@ -1,48 +1,95 @@
 package engine
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"io/ioutil"
-	"os"
-	"path/filepath"
 	"time"
 
 	"github.com/Pegasus8/piworker/core/engine/queue"
 
 	"github.com/Pegasus8/piworker/core/data"
-	actionsList "github.com/Pegasus8/piworker/core/elements/actions/models"
 	actionsModel "github.com/Pegasus8/piworker/core/elements/actions/shared"
-	triggersList "github.com/Pegasus8/piworker/core/elements/triggers/models"
+	"github.com/Pegasus8/piworker/core/elements/triggers/models/composite"
+	triggersModel "github.com/Pegasus8/piworker/core/elements/triggers/shared"
 	"github.com/Pegasus8/piworker/core/stats"
+	"github.com/Pegasus8/piworker/core/types"
 	"github.com/Pegasus8/piworker/core/uservariables"
-
-	"github.com/rs/zerolog/log"
 )
 
-func (engine *Engine) runTaskLoop(taskID string, taskChannel chan data.UserTask, managementChannel chan uint8, actionsQueue *queue.Queue) {
-	log.Info().Str("taskID", taskID).Msg("Task running, waiting for data...")
+// runTaskLoop executes the task received through `taskChannel` with `runner` until a signal is received through
+// `managementChannel`. `ctx` is canceled by the engine before sending the signal. The runner is stopped once the loop
+// finishes, interrupting the actions that could be running at that moment. Before waiting for its trigger, the task
+// is executed `catchUp` times to recover the activations missed while PiWorker was stopped.
+func (engine *Engine) runTaskLoop(ctx context.Context, taskID string, taskChannel chan data.UserTask, managementChannel chan uint8, runner *taskRunner, catchUp int) {
+	// The executions run apart from the loop, so the trigger keeps being watched while the task is running.
+	defer runner.stop()
+
+	engine.logger.Info().Str("taskID", taskID).Msg("Task running, waiting for data...")
 
 	// Receive the task for first time.
 	taskReceived := <-taskChannel
 
-	log.Info().Str("taskID", taskID).Msg("Data received, getting tick duration config before start the loop...")
+	engine.logger.Info().Str("taskID", taskID).Msg("Data received, getting tick duration config before start the loop...")
 
 	// Load configs
 	d := engine.configs.Behavior.LoopSleep
-	ticker := time.NewTicker(time.Millisecond * time.Duration(d))
-	defer ticker.Stop()
+	tick := time.Millisecond * time.Duration(d)
 
-	log.Info().Str("taskID", taskID).Int64("tickDuration", d).Msg("Tick duration obtained, starting task loop")
+	engine.logger.Info().Str("taskID", taskID).Int64("tickDuration", d).Msg("Tick duration obtained, starting task loop")
 
 	// Hook
 	if !engine.OnTaskLoopInit(taskID) {
 		return
 	}
 
-	for range ticker.C {
+	// The polled triggers are evaluated on each tick, while the push ones notify their activations. The source is
+	// created again every time the task is updated, because its trigger could have changed.
+	var source *triggerSource
+	defer func() {
+		if source != nil {
+			source.stop()
+		}
+	}()
+
+	if catchUp > 0 {
+		runner.catchUp(taskReceived, catchUp)
+	}
+
+	// The polled triggers remain activated during a while, the task must not be executed again until they are
+	// deactivated.
+	activated := false
+
+loop:
+	for {
+		if source == nil {
+			var err error
+
+			source, err = engine.watchTrigger(&taskReceived, tick)
+			if err != nil {
+				engine.logger.Error().
+					Err(err).
+					Str("taskID", taskReceived.ID).
+					Msg("Error while trying to watch the trigger of the task, stopping the task execution...")
+				break loop
+			}
+		}
+
+		chainedResult := &actionsModel.ChainedResult{}
+
+		// The activations of a queued trigger wait on the trigger until the task can run them.
+		var activations <-chan triggersModel.Activation
+		if !source.queued || runner.canRun(&taskReceived) {
+			activations = source.activations
+		}
+
 		select {
 		// Update the data.
 		case taskReceived = <-taskChannel:
+			source.stop()
+			source = nil
+
+			continue
 		// Stop signal received.
 		case code := <-managementChannel:
 			{
@@ -50,7 +97,7 @@ func (engine *Engine) runTaskLoop(taskID string, taskChannel chan data.UserTask,
 				// Stopped by the system.
 				case 0:
 					{
-						log.Info().
+						engine.logger.Info().
 							Str("taskID", taskReceived.ID).
 							Msg("Task execution stopped by the system")
 						return
@@ -58,7 +105,7 @@ func (engine *Engine) runTaskLoop(taskID string, taskChannel chan data.UserTask,
 				// Stopped by the user.
 				case 1:
 					{
-						log.Info().
+						engine.logger.Info().
 							Str("taskID", taskReceived.ID).
 							Msg("Task execution stopped by the user due to a state change")
 						return
@@ -66,7 +113,7 @@ func (engine *Engine) runTaskLoop(taskID string, taskChannel chan data.UserTask,
 				// Task deleted by the user.
 				case 2:
 					{
-						log.Info().
+						engine.logger.Info().
 							Str("taskID", taskReceived.ID).
 							Msg("Task deleted by the user, execution stopped")
 						return
@@ -74,320 +121,519 @@ func (engine *Engine) runTaskLoop(taskID string, taskChannel chan data.UserTask,
 				}
 			}
 
-		default:
-			// Keep using the same data unless a new event (related with
-			// the task running the loop) is received on the engine.
-		}
-
-		var beforeRunActions time.Time
-		var actionsExecutionDuration time.Duration
-
-		triggered, err := engine.runTrigger(taskReceived.Trigger, taskReceived.ID)
-		if err != nil {
-			log.Error().
-				Err(err).
-				Str("taskID", taskReceived.ID).
-				Msg("Error while trying to run the trigger of the task, stopping the task execution...")
-			break
-		}
-
-		if triggered {
-			if wasRecentlyExecuted(taskReceived.ID) {
-				goto skipTaskExecution
-			}
-
-			// Hook
-			if !engine.OnTriggerActivation(taskReceived.ID, &taskReceived.Trigger) {
+		// An execution of the task has failed, or a hook requires to stop.
+		case outcome := <-runner.outcomes:
+			if outcome.stop {
 				return
 			}
 
-			log.Info().
-				Str("taskID", taskReceived.ID).
-				Str("triggerID", taskReceived.Trigger.ID).
-				Msg("[%s] Trigger with the ID '%s' activated, running actions...")
-
-			beforeRunActions = time.Now()
-			err = engine.runActions(&taskReceived, actionsQueue)
-			actionsExecutionDuration = time.Since(beforeRunActions)
+			break loop
 
+		case <-source.ticks:
+			triggered, err := engine.runTrigger(taskReceived.Trigger, taskReceived.ID)
 			if err != nil {
-				log.Error().
-					Str("taskID", taskReceived.ID).
+				engine.logger.Error().
 					Err(err).
-					Msg("Error when running the actions of the task")
-
-				// Hook
-				engine.OnTaskExecutionFail(taskReceived.ID, err)
-
-				break
+					Str("taskID", taskReceived.ID).
+					Msg("Error while trying to run the trigger of the task, stopping the task execution...")
+				break loop
 			}
 
-			// Hook
-			if !engine.OnTaskExecutionSuccess(taskReceived.ID, actionsExecutionDuration) {
-				return
+			if !triggered || activated {
+				activated = triggered
+				continue
 			}
 
-			err = setAsRecentlyExecuted(taskReceived.ID)
-			if err != nil {
-				log.Error().
-					Err(err).
+			activated = true
+			engine.registerActivation(&taskReceived, engine.Clock.Now())
+
+		// An execution has finished, the activations of a queued trigger can be received again.
+		case <-runner.freed:
+			continue
+
+		case activation, ok := <-activations:
+			if !ok {
+				engine.logger.Error().
 					Str("taskID", taskReceived.ID).
-					Msg("Error when trying to set a task as recently executed")
-				break
+					Msg("The subscription to the trigger of the task has finished, stopping the task execution...")
+				break loop
 			}
 
-		skipTaskExecution:
-			// Skip the execution of the task but not skip the entire iteration
-			// in case of have to do something else with the task.
-		} else {
-			if wasRecentlyExecuted(taskReceived.ID) {
-				err = setAsReadyToExecuteAgain(taskReceived.ID)
-				if err != nil {
-					log.Error().
-						Err(err).
-						Str("taskID", taskReceived.ID).
-						Msg("Error when trying to set a task as ready to execute again")
-					break
-				}
+			chainedResult = &actionsModel.ChainedResult{
+				Result:     activation.Result,
+				ResultType: activation.ResultType,
 			}
 		}
+
+		if !engine.allowedByCalendar(&taskReceived, engine.Clock.Now()) {
+			continue
+		}
+
+		// Hook
+		if !engine.OnTriggerActivation(taskReceived.ID, &taskReceived.Trigger) {
+			return
+		}
+
+		engine.logger.Info().
+			Str("taskID", taskReceived.ID).
+			Str("triggerID", taskReceived.Trigger.ID).
+			Msg("Trigger activated, running actions...")
+
+		runner.activate(taskReceived, chainedResult)
+	}
+
+	// The rest of the executions are interrupted before marking the task as failed.
+	runner.stop()
+
+	// If the loop breaks (by a 'break' statement), there was a failure. The state of the task is updated on the
+	// database before emitting the event of type `Failed`, because the engine reads the failures of the task to apply
+	// its restart policy. The event is emitted anyway, the loop must be released.
+	err := engine.userdataDB.RegisterTaskFailure(taskReceived.ID, engine.Clock.Now())
+	if err != nil {
+		engine.logger.Error().Err(err).Str("taskID", taskReceived.ID).Msg("Error when trying to update the state of the task to 'failed'")
 	}
 
-	// If the loop breaks (by a 'break' statement), there was a failure, so an event of type `Failed` must
-	// be emitted.
 	event := data.Event{
 		Type:   data.Failed,
 		TaskID: taskReceived.ID,
 	}
-	data.EventBus <- event
-	// And finally, update the state of the task on the database.
-	err := engine.userdataDB.UpdateTaskState(taskReceived.ID, data.StateTaskFailed)
+
+	// Nobody would receive the event if the engine is being stopped.
+	select {
+	case engine.userdataDB.EventBus <- event:
+	case <-ctx.Done():
+	}
+}
+
+// runTaskNow executes the actions of the task, requested at `requestedAt`, without waiting for the activation of its
+// trigger. Unlike the executions of the task loop, a failure here doesn't change the state of the task to failed, its
+// previous state is always restored.
+func (engine *Engine) runTaskNow(ctx context.Context, task *data.UserTask, actionsQueue *queue.Queue, requestedAt time.Time) {
+	engine.logger.Info().Str("taskID", task.ID).Msg("Run requested by the user, running actions...")
+
+	err := engine.acquireExecution(ctx, task)
+	if err != nil {
+		return
+	}
+
+	execution := engine.startExecution(task.ID, requestedAt)
+
+	result, err := engine.runActions(ctx, task, &actionsModel.ChainedResult{}, actionsQueue, &execution)
+	engine.finishExecution(&execution, err)
+
+	engine.releaseExecution(task, true)
+
+	// An execution interrupted by the engine isn't a failure of the task.
+	if ctx.Err() == nil {
+		engine.notifyChainedTasks(task.ID, result, err)
+	}
+
+	if err != nil {
+		engine.logger.Error().
+			Str("taskID", task.ID).
+			Err(err).
+			Msg("Error when running the actions of the task requested by the user")
+	}
+}
+
+// notifyChainedTasks activates the tasks chained to the given one (see the trigger `taskchain`) with the outcome of
+// its execution. If it failed, the error is given as result.
+func (engine *Engine) notifyChainedTasks(taskID string, result *actionsModel.ChainedResult, err error) {
+	chains := engine.registry.Chains()
+	if chains == nil {
+		return
+	}
+
 	if err != nil {
-		log.Panic().Err(err).Str("taskID", taskReceived.ID).Msg("Error when trying to update the state of the task to 'failed'")
+		chains.TaskFinished(taskID, false, err.Error(), types.Text)
+		return
 	}
+
+	chains.TaskFinished(taskID, true, result.Result, result.ResultType)
 }
 
+// runTrigger runs the trigger of the task at the current moment according to the clock of the engine.
 func (engine *Engine) runTrigger(trigger data.UserTrigger, parentTaskID string) (bool, error) {
-	for _, pwTrigger := range triggersList.TRIGGERS {
-		if trigger.ID == pwTrigger.ID {
-			for _, arg := range trigger.Args {
-				// Check if the arg contains a user global variable
-				err := searchAndReplaceVariable(&arg, parentTaskID)
-				if err != nil {
-					return false, err
-				}
-			}
-			result, err := pwTrigger.Run(&trigger.Args, parentTaskID)
+	return engine.evalTrigger(&trigger, parentTaskID, parentTaskID, engine.Clock.Now())
+}
+
+// evalTrigger runs the given trigger at the moment `now`. `stateKey` is the identifier given to the trigger to keep
+// its state between executions, on the root trigger it's the ID of the task. The children of composite triggers
+// receive their own key (based on their position in the tree) so stateful triggers of the same type don't share
+// state inside a task.
+func (engine *Engine) evalTrigger(trigger *data.UserTrigger, parentTaskID, stateKey string, now time.Time) (bool, error) {
+	if trigger.ID == composite.Composite.ID {
+		operator, err := composite.ParseOperator(&trigger.Args)
+		if err != nil {
+			return false, err
+		}
+
+		// All the children are evaluated on each iteration (without short-circuit) so the state of each one of
+		// them keeps updated regardless the result of its siblings.
+		results := make([]bool, len(trigger.Children))
+		for i := range trigger.Children {
+			results[i], err = engine.evalTrigger(&trigger.Children[i], parentTaskID, fmt.Sprintf("%s/%d", stateKey, i), now)
 			if err != nil {
 				return false, err
 			}
-			if result {
-				return true, nil
-			}
-			return false, nil
+		}
+
+		return composite.Combine(operator, results)
+	}
+
+	pwTrigger := engine.trigger(trigger.ID)
+	if pwTrigger.ID == "" {
+		return false, fmt.Errorf("the trigger with the ID '%s' cannot be found", trigger.ID)
+	}
+
+	if pwTrigger.IsPush() {
+		return false, fmt.Errorf("the trigger with the ID '%s' notifies its activations, it can't be evaluated", trigger.ID)
+	}
+
+	// Work on a copy of the args to keep the references to the user variables on the task itself.
+	args := make([]data.UserArg, len(trigger.Args))
+	copy(args, trigger.Args)
+
+	for i := range args {
+		// Check if the arg contains a user global variable
+		err := searchAndReplaceVariable(&args[i], parentTaskID)
+		if err != nil {
+			return false, err
 		}
 	}
 
-	return false, fmt.Errorf("the trigger with the ID '%s' cannot be found", trigger.ID)
+	return pwTrigger.Run(&args, stateKey, now)
 }
 
-func (engine *Engine) runActions(task *data.UserTask, actionsQueue *queue.Queue) error {
-	log.Info().Str("taskID", task.ID).Msg("Running actions...")
-	startTime := time.Now()
+// runActions executes the actions of the given task, registering the result of each one of them on `execution`.
+// `chainedResult` is given to the first action and the result of the last one is returned. The execution is
+// interrupted if `ctx` is canceled or if the timeout of the task is exceeded. The state of the task is managed by
+// the caller, see `acquireExecution`.
+func (engine *Engine) runActions(ctx context.Context, task *data.UserTask, chainedResult *actionsModel.ChainedResult, actionsQueue *queue.Queue, execution *data.TaskExecution) (*actionsModel.ChainedResult, error) {
+	engine.logger.Info().Str("taskID", task.ID).Msg("Running actions...")
+	startTime := engine.Clock.Now()
 
-	userActions := &task.Actions
+	// The priority could have been changed since the last execution.
+	actionsQueue.SetPriority(task.ID, task.Settings.Priority)
 
-	log.Info().Str("taskID", task.ID).Msgf("Changing task state to '%s'\n", data.StateTaskOnExecution)
+	// The actions executed on failure must run even if the failure is the timeout of the task, so they use
+	// the context without it.
+	parentCtx := ctx
+	ctx, cancel := withTimeout(ctx, task.Settings.Timeout)
+	defer cancel()
 
-	// Set task state to on-execution
-	err := engine.userdataDB.UpdateTaskState(task.ID, data.StateTaskOnExecution)
+	chainedResult, err := engine.runActionList(ctx, task.ID, task.Actions, chainedResult, actionsQueue, execution, false)
 	if err != nil {
-		log.Error().
+		if len(task.OnFailure) > 0 {
+			engine.runOnFailure(parentCtx, task, err, actionsQueue, execution)
+		}
+
+		return nil, err
+	}
+
+	executionTime := engine.Clock.Since(startTime)
+	engine.logger.Info().
+		Str("taskID", task.ID).
+		Str("executionTime", executionTime.String()).
+		Msg("Actions executed")
+
+	// Add the execution time to the calculation of the field `stats.TasksStats.AverageExecutionTime`.
+	engine.updateStats(func(s *stats.TasksStats) {
+		s.NewAvgObs(executionTime) // TODO elaborate a new way to calculate the average.
+	})
+
+	return chainedResult, nil
+}
+
+// runOnFailure executes the actions defined by the task to be run when its execution fails. The error is given to
+// the first of them as chained result.
+func (engine *Engine) runOnFailure(ctx context.Context, task *data.UserTask, failure error, actionsQueue *queue.Queue, execution *data.TaskExecution) {
+	engine.logger.Warn().
+		Str("taskID", task.ID).
+		Int("actions", len(task.OnFailure)).
+		Msg("Running the actions defined for the failure of the task...")
+
+	chainedResult := &actionsModel.ChainedResult{Result: failure.Error(), ResultType: types.Text}
+
+	_, err := engine.runActionList(ctx, task.ID, task.OnFailure, chainedResult, actionsQueue, execution, true)
+	if err != nil {
+		engine.logger.Error().
 			Str("taskID", task.ID).
-			Msgf("Error when trying to update the task state to '%s'\n", data.StateTaskOnExecution)
-		return err
+			Err(err).
+			Msg("Error when running the actions defined for the failure of the task")
 	}
+}
 
-	var chainedResult = &actionsModel.ChainedResult{}
+// runActionList executes the given actions following their order, starting with the one with the order 0. Before
+// each action its condition (if any) is checked against the result of the previous one, which can skip the
+// execution to a later action or finish it quietly. The actions marked as parallel that share the same order are
+// executed at the same time, see `runParallelGroup`.
+func (engine *Engine) runActionList(ctx context.Context, taskID string, userActions []data.UserAction, chainedResult *actionsModel.ChainedResult, actionsQueue *queue.Queue, execution *data.TaskExecution, onFailure bool) (*actionsModel.ChainedResult, error) {
 	var orderN uint8 = 0
-	for range *userActions {
-
-		for _, userAction := range *userActions {
-			if userAction.Order == orderN {
-
-				// Run the action
-				for _, action := range actionsList.ACTIONS {
-					if userAction.ID == action.ID {
-						log.Info().
-							Str("taskID", task.ID).
-							Str("actionID", userAction.ID).
-							Bool("chained", userAction.Chained).
-							Uint8("actionOrder", userAction.Order).
-							Str("previousResultType", string(chainedResult.ResultType)).
-							Str("previousResultContent", chainedResult.Result).
-							Msg("Running action")
-
-						for _, arg := range userAction.Args {
-							err := searchAndReplaceVariable(&arg, task.ID)
-							if err != nil {
-								log.Error().
-									Str("taskID", task.ID).
-									Str("actionID", userAction.ID).
-									Str("argID", arg.ID).
-									Err(err).
-									Uint8("actionOrder", orderN).
-									Msg("Error when searching for a variable on the argument")
-								return err
-							}
-						}
-
-						beforeActionExecution := time.Now()
-
-						// Send the action execution to the queue
-						execResult := actionsQueue.AddJob(task.ID, action, &userAction, *chainedResult)
-						r := <-execResult
-
-						actionExecutionDuration := time.Since(beforeActionExecution)
-
-						// Hook
-						if !engine.OnActionRun(task.ID, &userAction, actionExecutionDuration) {
-							return nil
-						}
-
-						// Set the returned chr (chained result) to our main instance of the ChainedResult struct (`chainedResult`).
-						// This will be given to the next action (if exists).
-						chainedResult = &r.RetournedCR
-						if r.Err != nil {
-							log.Error().
-								Str("taskID", task.ID).
-								Str("actionID", userAction.ID).
-								Err(r.Err).
-								Uint8("actionOrder", userAction.Order).
-								Msg("Error when running the action")
-							return err
-						}
-						if r.Successful {
-							log.Info().
-								Str("taskID", task.ID).
-								Str("actionID", userAction.ID).
-								Uint8("actionOrder", userAction.Order).
-								Msg("Action finished correctly")
-						} else {
-							log.Warn().
-								Str("taskID", task.ID).
-								Str("actionID", userAction.ID).
-								Uint8("actionOrder", userAction.Order).
-								Msg("Action wasn't executed correctly. Aborting task for prevention of future errors...")
-							return fmt.Errorf("action returned an unsuccessful result")
-						}
-
-						// No need to keep iterating
-						break
-					}
+
+	// The conditions can only jump forward, so the order always increases and the loop always ends.
+	for {
+		group := getActionsByOrder(userActions, orderN)
+		if len(group) == 0 {
+			return chainedResult, nil
+		}
+
+		// The condition of the first action of a group applies to the whole group.
+		userAction := &group[0]
+
+		if userAction.Condition != nil {
+			satisfied, err := evalCondition(userAction.Condition, chainedResult, taskID)
+			if err != nil {
+				engine.logger.Error().
+					Str("taskID", taskID).
+					Str("actionID", userAction.ID).
+					Err(err).
+					Uint8("actionOrder", userAction.Order).
+					Msg("Error when evaluating the condition of the action")
+				return nil, err
+			}
+
+			if !satisfied {
+				if userAction.Condition.Else == data.ElseGoto {
+					engine.logger.Info().
+						Str("taskID", taskID).
+						Str("actionID", userAction.ID).
+						Uint8("actionOrder", userAction.Order).
+						Uint8("goto", userAction.Condition.Goto).
+						Msg("Condition not satisfied, skipping actions")
+
+					orderN = userAction.Condition.Goto
+					continue
 				}
 
+				engine.logger.Info().
+					Str("taskID", taskID).
+					Str("actionID", userAction.ID).
+					Uint8("actionOrder", userAction.Order).
+					Msg("Condition not satisfied, stopping the execution")
+
+				return chainedResult, nil
+			}
+		}
+
+		var r queue.ExecResult
+		var continueExecution bool
+
+		if len(group) > 1 && userAction.Parallel != nil {
+			r, continueExecution = engine.runParallelGroup(ctx, taskID, group, chainedResult, actionsQueue, execution, onFailure)
+		} else {
+			action := engine.action(userAction.ID)
+			if action.ID == "" {
+				engine.logger.Warn().
+					Str("taskID", taskID).
+					Str("actionID", userAction.ID).
+					Uint8("actionOrder", userAction.Order).
+					Msg("The action cannot be found, skipping it")
+
 				orderN++
-				break
+				continue
 			}
+
+			r, continueExecution = engine.runUserAction(ctx, taskID, *action, userAction, chainedResult, actionsQueue, execution, onFailure)
 		}
 
+		if !continueExecution {
+			return chainedResult, nil
+		}
+
+		// Set the returned chr (chained result) to our main instance of the ChainedResult struct (`chainedResult`).
+		// This will be given to the next action (if exists).
+		chainedResult = &r.RetournedCR
+		if r.Err != nil {
+			engine.logger.Error().
+				Str("taskID", taskID).
+				Str("actionID", userAction.ID).
+				Err(r.Err).
+				Uint8("actionOrder", userAction.Order).
+				Msg("Error when running the action")
+			return nil, r.Err
+		}
+		if r.Successful {
+			engine.logger.Info().
+				Str("taskID", taskID).
+				Str("actionID", userAction.ID).
+				Uint8("actionOrder", userAction.Order).
+				Msg("Action finished correctly")
+		} else {
+			engine.logger.Warn().
+				Str("taskID", taskID).
+				Str("actionID", userAction.ID).
+				Uint8("actionOrder", userAction.Order).
+				Msg("Action wasn't executed correctly. Aborting task for prevention of future errors...")
+			return nil, fmt.Errorf("action returned an unsuccessful result")
+		}
+
+		orderN++
 	}
+}
 
-	// Before the begin of actions' execution, the only possible state of the task is 'active' (otherwise the task won't be here).
-	// So now, after the execution of all the actions, let's restore the state to its previous value (remember that while the task is
-	// being executed the state will be 'on-execution').
-	err = engine.userdataDB.UpdateTaskState(task.ID, data.StateTaskActive)
-	if err != nil {
-		log.Fatal().
-			Str("taskID", task.ID).
-			Str("previousState", string(data.StateTaskOnExecution)).
-			Err(err).
-			Msg("Error when trying to update the task's state")
+// runUserAction resolves the user variables of the arguments of the action and executes it. The returned boolean is
+// false if the execution must be stopped by the hook `OnActionRun`.
+func (engine *Engine) runUserAction(ctx context.Context, taskID string, action actionsModel.Action, userAction *data.UserAction, chainedResult *actionsModel.ChainedResult, actionsQueue *queue.Queue, execution *data.TaskExecution, onFailure bool) (queue.ExecResult, bool) {
+	engine.logger.Info().
+		Str("taskID", taskID).
+		Str("actionID", userAction.ID).
+		Bool("chained", userAction.Chained).
+		Uint8("actionOrder", userAction.Order).
+		Str("previousResultType", string(chainedResult.ResultType)).
+		Str("previousResultContent", chainedResult.Result).
+		Msg("Running action")
+
+	// Work on a copy of the args to keep the references to the user variables on the task itself.
+	args := make([]data.UserArg, len(userAction.Args))
+	copy(args, userAction.Args)
+	userAction.Args = args
+
+	for i := range userAction.Args {
+		arg := &userAction.Args[i]
+		err := searchAndReplaceVariable(arg, taskID)
+		if err != nil {
+			engine.logger.Error().
+				Str("taskID", taskID).
+				Str("actionID", userAction.ID).
+				Str("argID", arg.ID).
+				Err(err).
+				Uint8("actionOrder", userAction.Order).
+				Msg("Error when searching for a variable on the argument")
+			return queue.ExecResult{Err: err}, true
+		}
 	}
 
-	executionTime := time.Since(startTime)
-	log.Info().
-		Str("taskID", task.ID).
-		Str("executionTime", executionTime.String()).
-		Msg("Actions executed")
+	beforeActionExecution := engine.Clock.Now()
 
-	// Add the execution time to the calculation of the field `stats.Current.AverageExecutionTime`.
-	stats.Current.Lock()
-	stats.Current.TasksStats.NewAvgObs(executionTime) // TODO elaborate a new way to calculate the average.
-	stats.Current.Unlock()
+	// Send the action execution to the queue (more than once if the retry policy requires it).
+	r := engine.runAction(ctx, taskID, action, userAction, chainedResult, actionsQueue, execution, onFailure)
 
-	return nil
+	actionExecutionDuration := engine.Clock.Since(beforeActionExecution)
+
+	// Hook
+	return r, engine.OnActionRun(taskID, userAction, actionExecutionDuration)
 }
 
-func setAsRecentlyExecuted(ID string) error {
-	err := ioutil.WriteFile(filepath.Join(TempDir, ID), []byte{}, 0644)
-	if err != nil {
-		return err
+// getActionsByOrder returns a copy of the actions with the given order, or an empty slice if there is no one.
+func getActionsByOrder(userActions []data.UserAction, order uint8) []data.UserAction {
+	var actions []data.UserAction
+
+	for i := range userActions {
+		if userActions[i].Order == order {
+			actions = append(actions, userActions[i])
+		}
 	}
 
-	return nil
+	return actions
 }
 
-func wasRecentlyExecuted(ID string) bool {
-	_, err := os.Stat(filepath.Join(TempDir, ID))
-	if err != nil {
-		if os.IsNotExist(err) {
-			return false
-		} else if os.IsExist(err) {
-			return true
+// startExecution registers the beginning of a new execution of the task, activated at `triggeredAt`. The oldest
+// executions are removed from the history if it exceeds `Behavior.MaxExecutionsPerTask`.
+func (engine *Engine) startExecution(taskID string, triggeredAt time.Time) data.TaskExecution {
+	execution := data.TaskExecution{
+		TaskID:      taskID,
+		TriggeredAt: triggeredAt,
+	}
+
+	// The history is not critical for the execution of the task, so a failure here is only logged.
+	if err := engine.userdataDB.NewExecution(&execution); err != nil {
+		engine.logger.Error().
+			Err(err).
+			Str("taskID", taskID).
+			Msg("Error when trying to register the execution of the task")
+	}
+
+	if engine.configs != nil && engine.configs.Behavior.MaxExecutionsPerTask > 0 {
+		err := engine.userdataDB.PruneExecutions(taskID, engine.configs.Behavior.MaxExecutionsPerTask)
+		if err != nil {
+			engine.logger.Error().
+				Err(err).
+				Str("taskID", taskID).
+				Msg("Error when trying to remove the oldest executions of the task")
 		}
-		log.Fatal().Err(err).Str("taskID", ID).Msg("Error when trying to get the the execution file's info")
 	}
 
-	return true
+	return execution
 }
 
-func setAsReadyToExecuteAgain(ID string) error {
-	path := filepath.Join(TempDir, ID)
-	err := os.Remove(path)
-	if err != nil {
-		return err
+// registerActionExecution appends the result of an action to the execution of its task and updates the stored
+// record, so the progress of a running execution can be consulted too.
+func (engine *Engine) registerActionExecution(execution *data.TaskExecution, userAction *data.UserAction, started time.Time, r *queue.ExecResult, attempt uint8, onFailure bool) {
+	actionExecution := data.ActionExecution{
+		Attempt:    attempt,
+		OnFailure:  onFailure,
+		ActionID:   userAction.ID,
+		Order:      userAction.Order,
+		Started:    started,
+		Finished:   engine.Clock.Now(),
+		Result:     r.RetournedCR.Result,
+		ResultType: r.RetournedCR.ResultType,
+		Successful: r.Successful && r.Err == nil,
+	}
+	if r.Err != nil {
+		actionExecution.Error = r.Err.Error()
 	}
 
-	return nil
-}
+	// The actions of a parallel group are registered concurrently.
+	engine.executionsMutex.Lock()
+	defer engine.executionsMutex.Unlock()
 
-func searchAndReplaceVariable(arg *data.UserArg, parentTaskID string) error {
-	// Check if the arg contains a user global variable
-	if uservariables.ContainGlobalVariable(&arg.Content) {
-		// If yes, then get the name of the variable by using regex
-		varName := uservariables.GetGlobalVariableName(arg.Content)
+	execution.Actions = append(execution.Actions, actionExecution)
 
-		// Get the variable from the name
-		globalVar, err := uservariables.GetGlobalVariable(varName)
-		if err != nil {
-			log.Error().Err(err).Str("taskID", parentTaskID).Str("varName", varName).Msg("Error when trying to read the user global variable")
-			return err
-		}
+	if execution.ID == "" {
+		// The execution couldn't be registered, there is nothing to update.
+		return
+	}
 
-		// If all it's ok, replace the content of the argument (which is the variable name basically)
-		// with the content of the desired user global variable.
-		globalVar.RLock()
-		arg.Content = globalVar.Content
-		globalVar.RUnlock()
+	err := engine.userdataDB.UpdateExecution(execution)
+	if err != nil {
+		engine.logger.Error().
+			Err(err).
+			Str("taskID", execution.TaskID).
+			Str("executionID", execution.ID).
+			Msg("Error when trying to update the execution of the task")
+	}
+}
 
-		// If the arg not contains a user global variable, then check if contains a user local variable instead.
-	} else if uservariables.ContainLocalVariable(&arg.Content) {
-		// If yes, then get the name of the variable by using regex
-		varName := uservariables.GetLocalVariableName(arg.Content)
+// finishExecution sets the final outcome of an execution and stores it.
+func (engine *Engine) finishExecution(execution *data.TaskExecution, err error) {
+	execution.Finished = engine.Clock.Now()
+
+	switch {
+	case err == nil:
+		execution.Outcome = data.OutcomeSuccess
+	case errors.Is(err, context.DeadlineExceeded):
+		execution.Outcome = data.OutcomeTimedOut
+		execution.Error = err.Error()
+	case errors.Is(err, context.Canceled):
+		execution.Outcome = data.OutcomeCanceled
+		execution.Error = err.Error()
+	default:
+		execution.Outcome = data.OutcomeFailure
+		execution.Error = err.Error()
+	}
 
-		// Get the variable from the name
-		localVariable, err := uservariables.GetLocalVariable(varName, parentTaskID)
-		if err != nil {
-			log.Error().Err(err).Str("taskID", parentTaskID).Str("varName", varName).Msg("Error when trying to read the user local variable")
-			return err
-		}
+	if execution.ID == "" {
+		return
+	}
 
-		// If all it's ok, replace the content of the argument (which is the variable name basically)
-		// with the content of the desired user local variable.
-		localVariable.RLock()
-		arg.Content = localVariable.Content
-		localVariable.RUnlock()
+	err = engine.userdataDB.UpdateExecution(execution)
+	if err != nil {
+		engine.logger.Error().
+			Err(err).
+			Str("taskID", execution.TaskID).
+			Str("executionID", execution.ID).
+			Msg("Error when trying to store the result of the execution")
+	}
+}
+
+func searchAndReplaceVariable(arg *data.UserArg, parentTaskID string) error {
+	content := arg.Content
+
+	err := uservariables.ReplaceVariable(arg, parentTaskID)
+	if err != nil {
+		return fmt.Errorf("error when trying to read the user variable of the arg '%s': %w", content, err)
 	}
 
 	return nil
@@ -1,16 +1,24 @@
 package engine
 
 import (
+	"context"
 	"io/ioutil"
 	"os"
-	"path"
-	"path/filepath"
 	"sync"
 	"testing"
+	"time"
 
+	"github.com/Pegasus8/piworker/core/configs"
 	"github.com/Pegasus8/piworker/core/data"
+	"github.com/Pegasus8/piworker/core/elements"
+	actionsModel "github.com/Pegasus8/piworker/core/elements/actions/shared"
+	"github.com/Pegasus8/piworker/core/elements/triggers/models/composite"
+	"github.com/Pegasus8/piworker/core/elements/triggers/models/everyxtime"
+	triggersModel "github.com/Pegasus8/piworker/core/elements/triggers/shared"
+	"github.com/Pegasus8/piworker/core/engine/queue"
 	"github.com/Pegasus8/piworker/core/types"
 	"github.com/Pegasus8/piworker/core/uservariables"
+	"github.com/Pegasus8/piworker/utilities/clock"
 
 	"github.com/google/uuid"
 	assert2 "github.com/stretchr/testify/assert"
@@ -21,67 +29,300 @@ type TETestSuite struct {
 	suite.Suite
 }
 
-func (suite *TETestSuite) SetupTest() {
-	err := os.MkdirAll(TempDir, 0755)
+func (suite *TETestSuite) BeforeTest(_, _ string) {
+
+}
+
+func (suite *TETestSuite) TestRunTaskLoop() {
+	assert := assert2.New(suite.T())
+
+	dir, err := ioutil.TempDir("", "taskloop")
 	if err != nil {
 		panic(err)
 	}
-}
+	defer os.RemoveAll(dir)
 
-func (suite *TETestSuite) BeforeTest(_, _ string) {
+	db, err := data.NewDB(dir, "taskloop.db")
+	if err != nil {
+		panic(err)
+	}
+	defer db.Close()
+
+	// The events emitted by the database are not used.
+	go func() {
+		for range db.EventBus {
+		}
+	}()
+
+	// The whole life of the task is simulated with a fake clock, used by the engine and given by it to the triggers.
+	fake := clock.NewFake(time.Date(2021, 3, 5, 12, 0, 0, 0, time.Local))
+
+	registry := elements.NewRegistry()
+	e := NewEngine(db, &configs.Configs{Behavior: configs.Behavior{LoopSleep: int64(time.Minute / time.Millisecond)}},
+		WithRegistry(registry),
+		WithClock(fake),
+	)
+	start := fake.Now()
+
+	// The trigger `EveryXTime`, reporting each one of its evaluations so the clock is advanced only once the loop has
+	// evaluated the trigger. The moments of the activations are stored on `activations`.
+	var activations []time.Duration
+	evaluated := make(chan struct{})
+	everyXTime := triggersModel.Trigger{
+		ID: "T98",
+		Run: func(args *[]data.UserArg, parentTaskID string, now time.Time) (bool, error) {
+			defer func() {
+				evaluated <- struct{}{}
+			}()
+
+			r, err := everyxtime.EveryXTime.Run(args, parentTaskID, now)
+			if r {
+				activations = append(activations, fake.Since(start))
+			}
+
+			return r, err
+		},
+	}
+	if err := registry.RegisterTrigger(everyXTime); err != nil {
+		panic(err)
+	}
 
-}
+	executed := make(chan struct{}, 10)
+	notify := actionsModel.Action{
+		ID: "A97",
+		Run: func(_ context.Context, _ *actionsModel.ChainedResult, _ *data.UserAction, _ string) (bool, *actionsModel.ChainedResult, error) {
+			executed <- struct{}{}
+			return true, &actionsModel.ChainedResult{}, nil
+		},
+	}
+	if err := registry.RegisterAction(notify); err != nil {
+		panic(err)
+	}
 
-func (suite *TETestSuite) TestRunTaskLoop() {
+	task := data.UserTask{
+		Name:    "Every ten minutes",
+		State:   data.StateTaskActive,
+		Trigger: data.UserTrigger{ID: everyXTime.ID, Args: []data.UserArg{{ID: "T4-1", Content: "10m"}}},
+		Actions: []data.UserAction{{ID: notify.ID}},
+		// The executions take real time while the simulated minutes pass in microseconds, so the first execution
+		// could still be running on the second activation.
+		Settings: data.TaskSettings{Overlap: data.OverlapQueue},
+	}
+	err = db.NewTask(&task)
+	if err != nil {
+		panic(err)
+	}
 
-}
+	taskChannel := make(chan data.UserTask)
+	managementChannel := make(chan uint8)
+	ctx, cancel := context.WithCancel(context.Background())
+	finished := make(chan struct{})
+
+	go func() {
+		e.runTaskLoop(ctx, task.ID, taskChannel, managementChannel, e.newTaskRunner(ctx, queue.NewQueue(1, nil)), 0)
+		close(finished)
+	}()
+	taskChannel <- task
+
+	// Wait for the ticker of the loop and simulate 25 minutes.
+	fake.BlockUntil(1)
+	for i := 0; i < 25; i++ {
+		fake.Advance(time.Minute)
+		<-evaluated
+	}
 
-func (suite *TETestSuite) TestRunTrigger() {
+	for i := 0; i < 2; i++ {
+		select {
+		case <-executed:
+		case <-time.After(time.Second):
+			assert.Failf("the task must be executed", "execution %d", i)
+		}
+	}
 
-}
+	// The first evaluation (on the first tick) starts the count of the ten minutes.
+	assert.Equal([]time.Duration{11 * time.Minute, 21 * time.Minute}, activations)
 
-func (suite *TETestSuite) TestRunActions() {
+	cancel()
+	managementChannel <- 0
+	<-finished
 
+	executions, err := db.GetExecutions(task.ID, 10, 0)
+	if assert.NoError(err) && assert.Len(*executions, 2, "the executions must be registered") {
+		for _, execution := range *executions {
+			assert.Equal(data.OutcomeSuccess, execution.Outcome)
+		}
+	}
 }
 
-func (suite *TETestSuite) TestSetAsRecentlyExecuted() {
+func (suite *TETestSuite) TestRunTaskLoopQueued() {
 	assert := assert2.New(suite.T())
-	id := uuid.New().String()
 
-	err := setAsRecentlyExecuted(id)
-	assert.NoError(err, "a task should be able to be marked as recently executed")
+	dir, err := ioutil.TempDir("", "taskloop")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
 
-	_, err = os.Stat(path.Join(TempDir, id))
-	assert.False(os.IsNotExist(err), "a file with the ID of the task should be created to mark a task "+
-		"as recently executed")
-}
+	db, err := data.NewDB(dir, "taskloop.db")
+	if err != nil {
+		panic(err)
+	}
+	defer db.Close()
+
+	// The events emitted by the database are not used.
+	go func() {
+		for range db.EventBus {
+		}
+	}()
+
+	registry := elements.NewRegistry()
+	e := NewEngine(db, &configs.Configs{Behavior: configs.Behavior{LoopSleep: 100}}, WithRegistry(registry))
+
+	// A queued push trigger, whose activations are sent by the test.
+	activations := make(chan triggersModel.Activation)
+	queued := triggersModel.Trigger{
+		ID: "T98",
+		Subscribe: func(_ *[]data.UserArg, _ string) (<-chan triggersModel.Activation, error) {
+			return activations, nil
+		},
+		Unsubscribe: func(_ string) {},
+		Queued:      true,
+	}
+	if err := registry.RegisterTrigger(queued); err != nil {
+		panic(err)
+	}
 
-func (suite *TETestSuite) TestWasRecentlyExecuted() {
-	assert := assert2.New(suite.T())
-	id := uuid.New().String()
+	// An action that reports the chained result received and blocks until it receives a value through `release`.
+	received := make(chan string, 10)
+	release := make(chan struct{})
+	blocking := actionsModel.Action{
+		ID: "A97",
+		Run: func(ctx context.Context, previousResult *actionsModel.ChainedResult, _ *data.UserAction, _ string) (bool, *actionsModel.ChainedResult, error) {
+			received <- previousResult.Result
+
+			select {
+			case <-release:
+				return true, &actionsModel.ChainedResult{}, nil
+			case <-ctx.Done():
+				return false, &actionsModel.ChainedResult{}, ctx.Err()
+			}
+		},
+	}
+	if err := registry.RegisterAction(blocking); err != nil {
+		panic(err)
+	}
 
-	generateIDFile(id)
+	task := data.UserTask{
+		Name:    "Queued",
+		State:   data.StateTaskActive,
+		Trigger: data.UserTrigger{ID: queued.ID},
+		Actions: []data.UserAction{{ID: blocking.ID, Chained: true}},
+	}
+	err = db.NewTask(&task)
+	if err != nil {
+		panic(err)
+	}
 
-	r := wasRecentlyExecuted(id)
-	assert.True(r, "if the task has been recently executed a file with its ID should be found")
+	taskChannel := make(chan data.UserTask)
+	managementChannel := make(chan uint8)
+	ctx, cancel := context.WithCancel(context.Background())
+	finished := make(chan struct{})
+
+	go func() {
+		e.runTaskLoop(ctx, task.ID, taskChannel, managementChannel, e.newTaskRunner(ctx, queue.NewQueue(1, nil)), 0)
+		close(finished)
+	}()
+	taskChannel <- task
+
+	for _, path := range []string{"first", "second", "third"} {
+		select {
+		case activations <- triggersModel.Activation{Result: path, ResultType: types.Path}:
+		case <-time.After(time.Second):
+			assert.Failf("the activation must be received once the task can run it", "activation '%s'", path)
+		}
+
+		select {
+		case r := <-received:
+			assert.Equal(path, r, "the activations must be run one by one, in order")
+		case <-time.After(time.Second):
+			assert.Failf("the activation must be run", "activation '%s'", path)
+		}
+
+		// The task is running, so the next activation must wait on the trigger instead of being skipped.
+		select {
+		case activations <- triggersModel.Activation{Result: "skipped", ResultType: types.Path}:
+			assert.Fail("the activations must not be received while the task is running")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		select {
+		case release <- struct{}{}:
+		case <-time.After(time.Second):
+			assert.Failf("the activation must be running", "activation '%s'", path)
+		}
+	}
 
-	id2 := uuid.New().String()
-	r = wasRecentlyExecuted(id2)
-	assert.False(r, "if the ID provided is not one of a recently executed task, false should be returned")
+	cancel()
+	managementChannel <- 0
+	<-finished
 }
 
-func (suite *TETestSuite) TestSetAsReadyToExecuteAgain() {
+func (suite *TETestSuite) TestRunTrigger() {
 	assert := assert2.New(suite.T())
-	id := uuid.New().String()
+	registry := elements.NewRegistry()
+	e := NewEngine(nil, nil, WithRegistry(registry))
+	taskID := uuid.New().String()
+
+	// A stateful trigger that is activated every two executions.
+	var calls = make(map[string]int)
+	toggle := triggersModel.Trigger{
+		ID: "T99",
+		Run: func(args *[]data.UserArg, stateKey string, _ time.Time) (bool, error) {
+			calls[stateKey]++
+			return calls[stateKey]%2 == 0, nil
+		},
+	}
+	if err := registry.RegisterTrigger(toggle); err != nil {
+		panic(err)
+	}
 
-	generateIDFile(id)
+	r, err := e.runTrigger(data.UserTrigger{ID: toggle.ID}, taskID)
+	assert.NoError(err, "the trigger should be run without problems")
+	assert.False(r)
+	r, _ = e.runTrigger(data.UserTrigger{ID: toggle.ID}, taskID)
+	assert.True(r, "the root trigger must use the ID of the task to keep its state")
+
+	tree := data.UserTrigger{
+		ID:   composite.Composite.ID,
+		Args: []data.UserArg{{ID: composite.Composite.Args[0].ID, Content: "AND"}},
+		Children: []data.UserTrigger{
+			{ID: toggle.ID},
+			{
+				ID:       composite.Composite.ID,
+				Args:     []data.UserArg{{ID: composite.Composite.Args[0].ID, Content: "NOT"}},
+				Children: []data.UserTrigger{{ID: toggle.ID}},
+			},
+		},
+	}
 
-	err := setAsReadyToExecuteAgain(id)
-	assert.NoError(err, "a task should be able to be de-marked as recently executed")
+	// Both children have the same state, so `A AND NOT A` can never be activated.
+	for i := 0; i < 4; i++ {
+		r, err = e.runTrigger(tree, taskID)
+		assert.NoError(err, "the composite trigger should be run without problems")
+		assert.False(r, "the composite trigger must not be activated")
+	}
+
+	assert.Equal(4, calls[taskID+"/0"], "each child must be evaluated on every execution")
+	assert.Equal(4, calls[taskID+"/1/0"], "each child must keep its own state")
+	assert.Equal(2, calls[taskID], "the state of the children must not be mixed with the one of the task")
+
+	tree.Children = append(tree.Children, data.UserTrigger{ID: "T100"})
+	_, err = e.runTrigger(tree, taskID)
+	assert.Error(err, "a child with an unknown ID must return an error")
+}
+
+func (suite *TETestSuite) TestRunActions() {
 
-	_, err = os.Stat(path.Join(TempDir, id))
-	assert.True(os.IsNotExist(err), "a file with the ID of the task should be removed to mark a task "+
-		"as ready to be executed again")
 }
 
 func (suite *TETestSuite) TestSearchAndReplaceVariable() {
@@ -138,12 +379,7 @@ func (suite *TETestSuite) TestSearchAndReplaceVariable() {
 }
 
 func (suite *TETestSuite) TearDownTest() {
-	err := os.RemoveAll(TempDir)
-	if err != nil {
-		panic(err)
-	}
-
-	err = os.RemoveAll(data.Path)
+	err := os.RemoveAll(data.Path)
 	if err != nil {
 		panic(err)
 	}
@@ -152,11 +388,3 @@ func (suite *TETestSuite) TearDownTest() {
 func TestTESuite(t *testing.T) {
 	suite.Run(t, new(TETestSuite))
 }
-
-func generateIDFile(id string) {
-	// Emulate a task that has been recently executed.
-	err := ioutil.WriteFile(filepath.Join(TempDir, id), []byte{}, 0644)
-	if err != nil {
-		panic(err)
-	}
-}
@@ -0,0 +1,85 @@
+package engine
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Pegasus8/piworker/core/data"
+	"github.com/Pegasus8/piworker/core/elements/triggers/models/composite"
+	triggersModel "github.com/Pegasus8/piworker/core/elements/triggers/shared"
+)
+
+// triggerSource provides to the task loop the moments in which the trigger of a task must be considered: the ticks
+// on which a polled trigger is evaluated, or the activations notified by a push trigger. Only one of the channels is
+// used, the other one is nil (so it's never selected).
+type triggerSource struct {
+	ticks       <-chan time.Time
+	activations <-chan triggersModel.Activation
+	// queued indicates that the activations must be received only once the task can run them, see
+	// `triggersModel.Trigger.Queued`.
+	queued bool
+
+	stop func()
+}
+
+// watchTrigger starts watching the trigger of the task. Push triggers are subscribed with the arguments of the task
+// (with the user variables already replaced), the rest are polled every `tick`.
+func (engine *Engine) watchTrigger(task *data.UserTask, tick time.Duration) (*triggerSource, error) {
+	pwTrigger := engine.trigger(task.Trigger.ID)
+
+	if !pwTrigger.IsPush() {
+		ticker := engine.Clock.NewTicker(tick)
+		trigger, taskID := task.Trigger, task.ID
+
+		return &triggerSource{
+			ticks: ticker.C(),
+			stop: func() {
+				ticker.Stop()
+				engine.releaseTrigger(&trigger, taskID)
+			},
+		}, nil
+	}
+
+	// Work on a copy of the args to keep the references to the user variables on the task itself.
+	args := make([]data.UserArg, len(task.Trigger.Args))
+	copy(args, task.Trigger.Args)
+
+	for i := range args {
+		err := searchAndReplaceVariable(&args[i], task.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	activations, err := pwTrigger.Subscribe(&args, task.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	taskID := task.ID
+
+	return &triggerSource{
+		activations: activations,
+		queued:      pwTrigger.Queued,
+		stop: func() {
+			pwTrigger.Unsubscribe(taskID)
+		},
+	}, nil
+}
+
+// releaseTrigger lets the polled trigger (and its children, if it's composite) forget the state kept with the key
+// `stateKey`, see `evalTrigger`.
+func (engine *Engine) releaseTrigger(trigger *data.UserTrigger, stateKey string) {
+	if trigger.ID == composite.Composite.ID {
+		for i := range trigger.Children {
+			engine.releaseTrigger(&trigger.Children[i], fmt.Sprintf("%s/%d", stateKey, i))
+		}
+
+		return
+	}
+
+	pwTrigger := engine.trigger(trigger.ID)
+	if !pwTrigger.IsPush() && pwTrigger.Unsubscribe != nil {
+		pwTrigger.Unsubscribe(stateKey)
+	}
+}
@@ -0,0 +1,105 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Pegasus8/piworker/core/data"
+	"github.com/Pegasus8/piworker/core/elements"
+	"github.com/Pegasus8/piworker/core/elements/triggers/models/composite"
+	timeTrigger "github.com/Pegasus8/piworker/core/elements/triggers/models/time"
+	triggersModel "github.com/Pegasus8/piworker/core/elements/triggers/shared"
+	"github.com/Pegasus8/piworker/core/types"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchTrigger(t *testing.T) {
+	assert := assert.New(t)
+	registry := elements.NewRegistry()
+	e := NewEngine(nil, nil, WithRegistry(registry))
+	taskID := uuid.New().String()
+
+	subscriptions := triggersModel.NewSubscriptions()
+	var received []data.UserArg
+	push := triggersModel.Trigger{
+		ID:   "T99",
+		Args: []triggersModel.Arg{{ID: "T99-1", ContentType: types.Text}},
+		Subscribe: func(args *[]data.UserArg, parentTaskID string) (<-chan triggersModel.Activation, error) {
+			received = *args
+			return subscriptions.Add(parentTaskID), nil
+		},
+		Unsubscribe: subscriptions.Remove,
+	}
+	if err := registry.RegisterTrigger(push); err != nil {
+		panic(err)
+	}
+
+	task := &data.UserTask{
+		ID:      taskID,
+		Trigger: data.UserTrigger{ID: push.ID, Args: []data.UserArg{{ID: "T99-1", Content: "hello"}}},
+	}
+
+	source, err := e.watchTrigger(task, time.Millisecond)
+	if !assert.NoError(err, "the push trigger should be subscribed without errors") {
+		return
+	}
+	assert.Nil(source.ticks, "a push trigger must not be polled")
+	assert.Equal(task.Trigger.Args, received, "the trigger must receive the arguments of the task")
+
+	subscriptions.Notify(taskID, triggersModel.Activation{Result: "event", ResultType: types.Text})
+	select {
+	case a := <-source.activations:
+		assert.Equal("event", a.Result, "the activation must be received by the task")
+	case <-time.After(time.Second):
+		assert.Fail("the activation must be received by the task")
+	}
+
+	source.stop()
+	assert.Empty(subscriptions.TaskIDs(), "the task must be unsubscribed once the source is stopped")
+
+	// The triggers without subscription are polled.
+	byTime := timeTrigger.ByTime
+	if err := registry.RegisterTrigger(byTime); err != nil {
+		panic(err)
+	}
+	task.Trigger = data.UserTrigger{ID: byTime.ID}
+	source, err = e.watchTrigger(task, time.Millisecond)
+	if assert.NoError(err) {
+		assert.Nil(source.activations, "a polled trigger must not notify its activations")
+		assert.NotNil(source.ticks)
+		source.stop()
+	}
+
+	_, err = e.evalTrigger(&data.UserTrigger{ID: push.ID}, taskID, taskID, time.Now())
+	assert.Error(err, "a push trigger can't be evaluated")
+
+	// The polled triggers forget the state of the task once they stop being evaluated.
+	var forgotten []string
+	stateful := triggersModel.Trigger{
+		ID: "T98",
+		Run: func(_ *[]data.UserArg, _ string, _ time.Time) (bool, error) {
+			return false, nil
+		},
+		Unsubscribe: func(stateKey string) {
+			forgotten = append(forgotten, stateKey)
+		},
+	}
+	if err := registry.RegisterTrigger(stateful); err != nil {
+		panic(err)
+	}
+	if err := registry.RegisterTrigger(composite.Composite); err != nil {
+		panic(err)
+	}
+	task.Trigger = data.UserTrigger{
+		ID:       composite.Composite.ID,
+		Args:     []data.UserArg{{ID: composite.Composite.Args[0].ID, Content: "OR"}},
+		Children: []data.UserTrigger{{ID: byTime.ID}, {ID: stateful.ID}},
+	}
+	source, err = e.watchTrigger(task, time.Millisecond)
+	if assert.NoError(err) {
+		source.stop()
+		assert.Equal([]string{taskID + "/1"}, forgotten, "the state of the children must be forgotten with their key")
+	}
+}
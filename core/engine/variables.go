@@ -1,6 +0,0 @@
-package engine
-
-var (
-	// TempDir is the directory used to store the temp files of the Dynamic Engine
-	TempDir = ".tmp/"
-)
@@ -12,11 +12,16 @@ type TasksStats struct {
 	OnExecutionTasks     uint8         `json:"onExecutionTasks"`
 	FailedTasks          uint8         `json:"failedTasks"`
 	AverageExecutionTime time.Duration `json:"averageExecutionTime"`
+	RetriedActions       uint32        `json:"retriedActions"`
+	QueuedActions        uint32        `json:"queuedActions"`
+	AverageQueueWait     time.Duration `json:"averageQueueWait"`
 	BackupLoopState      bool          `json:"backupLoopState"`
 	Timestamp            time.Time     `json:"timestamp"`
 
 	sumExecTime time.Duration
 	obs         uint64
+	sumWait     time.Duration
+	waitObs     uint64
 }
 
 // RaspberryStats is the struct that contains the statistics related with the Host (generally it will be a Raspberry Pi).
@@ -59,3 +64,13 @@ func (s *TasksStats) NewAvgObs(duration time.Duration) time.Duration {
 
 	return s.AverageExecutionTime
 }
+
+// NewQueueWaitObs adds the time that an action has waited on the queue to the calculation of the field
+// `Statistic.AverageQueueWait`.
+func (s *TasksStats) NewQueueWaitObs(wait time.Duration) time.Duration {
+	s.sumWait += wait
+	s.waitObs++
+	s.AverageQueueWait = time.Duration(float64(s.sumWait) / float64(s.waitObs))
+
+	return s.AverageQueueWait
+}
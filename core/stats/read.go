@@ -9,36 +9,36 @@ import (
 
 var arch string
 
-// UpdateRPiStats is a function update the statistics related with the host (usually a Raspberry Pi), on the variable `Current`.
-func UpdateRPiStats() error {
-	Current.Lock()
-	defer Current.Unlock()
+// UpdateRPiStats is a function update the statistics related with the host (usually a Raspberry Pi).
+func (s *Stats) UpdateRPiStats() error {
+	s.Lock()
+	defer s.Unlock()
 
 	// CPU stats
 	cpuL, err := cpu.Percent(0, false)
 	if err != nil {
 		return err
 	}
-	Current.RaspberryStats.CPULoad = cpuL[0]
+	s.RaspberryStats.CPULoad = cpuL[0]
 
 	// Storage stats
 	d, err := disk.Usage("/")
 	if err != nil {
 		return err
 	}
-	Current.RaspberryStats.Storage.Total = d.Total
-	Current.RaspberryStats.Storage.Free = d.Free
-	Current.RaspberryStats.Storage.Used = d.Used
-	Current.RaspberryStats.Storage.UsedPercent = d.UsedPercent
+	s.RaspberryStats.Storage.Total = d.Total
+	s.RaspberryStats.Storage.Free = d.Free
+	s.RaspberryStats.Storage.Used = d.Used
+	s.RaspberryStats.Storage.UsedPercent = d.UsedPercent
 
 	// RAM stats
 	vms, err := mem.VirtualMemory()
 	if err != nil {
 		return err
 	}
-	Current.RaspberryStats.RAM.Total = vms.Total
-	Current.RaspberryStats.RAM.Available = vms.Available
-	Current.RaspberryStats.RAM.Used = vms.Used
+	s.RaspberryStats.RAM.Total = vms.Total
+	s.RaspberryStats.RAM.Available = vms.Available
+	s.RaspberryStats.RAM.Used = vms.Used
 
 	// Host stats
 	st, err := host.SensorsTemperatures()
@@ -53,8 +53,8 @@ func UpdateRPiStats() error {
 	if err != nil {
 		return err
 	}
-	Current.RaspberryStats.Host.BootTime = bt
-	Current.RaspberryStats.Host.UpTime = ut
+	s.RaspberryStats.Host.BootTime = bt
+	s.RaspberryStats.Host.UpTime = ut
 	
 	var temperature float64
 	// Architecture of a Raspberry Pi.
@@ -71,7 +71,7 @@ func UpdateRPiStats() error {
 			}
 		}
 	}
-	Current.RaspberryStats.Host.Temperature = temperature
+	s.RaspberryStats.Host.Temperature = temperature
 
 	return nil
 }
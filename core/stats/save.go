@@ -9,31 +9,35 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/Pegasus8/piworker/utilities/clock"
+
 	_ "github.com/mattn/go-sqlite3" // SQLite3 package
 	"github.com/rs/zerolog/log"
 	"github.com/shirou/gopsutil/host"
 )
 
-// Init initializes the directory where the statistics will be stored (if not exists).
-func Init() {
+// Open initializes the directory where the statistics will be stored (if not exists) and its database, and returns
+// the statistics stored on it. Their time is taken from the given clock.
+func Open(c clock.Clock) (*Stats, error) {
 	// Create statistics path if not exists
 	err := os.MkdirAll(StatisticsPath, os.ModePerm)
 	if err != nil {
-		log.Fatal().Err(err).Str("path", StatisticsPath).Msg("Cannot initialize the directory to store statistics")
+		return nil, fmt.Errorf("cannot initialize the directory to store statistics: %w", err)
 	}
 
 	path := filepath.Join(StatisticsPath, DatabaseName)
 
-	DB, err = InitDB(path)
+	db, err := InitDB(path)
 	if err != nil {
-		log.Panic().Err(err).Str("path", path).Msg("Error when initializing the statistics database")
+		return nil, fmt.Errorf("error when initializing the statistics database: %w", err)
 	}
 
-	err = CreateTable()
+	s := &Stats{db: db, clock: c}
+
+	err = s.CreateTable()
 	if err != nil {
-		log.Panic().
-			Err(err).
-			Msg("Error when trying to create the table on the statistics database")
+		db.Close()
+		return nil, fmt.Errorf("error when trying to create the table on the statistics database: %w", err)
 	}
 
 	// Get the architecture of the host to elucidate which key should we use when trying to get the temperature of the sensors.
@@ -44,14 +48,20 @@ func Init() {
 		}
 		arch = hostInfo.KernelArch
 	}
+
+	return s, nil
+}
+
+// Close closes the database of the statistics.
+func (s *Stats) Close() error {
+	return s.db.Close()
 }
 
 /*
 *	Usage order:
-*	1) InitDB
-*	2) defer db.Close()
-*	3) CreateTable
-*	4) StoreRasberryStatistics/ReadRaspberryStatistics
+*	1) Open
+*	2) defer s.Close()
+*	3) StoreRasberryStatistics/ReadRaspberryStatistics
  */
 
 // InitDB is the function used to initialize the sqlite3 database.
@@ -69,7 +79,7 @@ func InitDB(filepath string) (*sql.DB, error) {
 
 // CreateTable is the function used to create the default tables into
 // the SQLite3 database.
-func CreateTable() error {
+func (s *Stats) CreateTable() error {
 	sqlStatement1 := `
 	CREATE TABLE IF NOT EXISTS TasksStats(
 		ActiveTasks INTEGER NOT NULL,
@@ -91,12 +101,12 @@ func CreateTable() error {
 	);
 	`
 
-	_, err := DB.Exec(sqlStatement1)
+	_, err := s.db.Exec(sqlStatement1)
 	if err != nil {
 		return err
 	}
 
-	_, err = DB.Exec(sqlStatement2)
+	_, err = s.db.Exec(sqlStatement2)
 	if err != nil {
 		return err
 	}
@@ -105,7 +115,7 @@ func CreateTable() error {
 }
 
 // StoreTStats stores a instance of the struct `TasksStats` into the table `TasksStats` of the SQLite3 database.
-func StoreTStats(ts *TasksStats) error {
+func (s *Stats) StoreTStats(ts *TasksStats) error {
 	sqlStatement := `
 	INSERT INTO TasksStats(
 		ActiveTasks,
@@ -116,9 +126,9 @@ func StoreTStats(ts *TasksStats) error {
 		Timestamp
 	) values (?,?,?,?,?,?)
 	`
-	now := time.Now()
+	now := s.clock.Now()
 
-	_, err := DB.Exec(sqlStatement,
+	_, err := s.db.Exec(sqlStatement,
 		ts.ActiveTasks,
 		ts.InactiveTasks,
 		ts.OnExecutionTasks,
@@ -134,7 +144,7 @@ func StoreTStats(ts *TasksStats) error {
 }
 
 // StoreRStats stores a instance of the struct `RaspberryStats` into the table `RaspberryStats` of the SQLite3 database.
-func StoreRStats(rs *RaspberryStats) error {
+func (s *Stats) StoreRStats(rs *RaspberryStats) error {
 	// CURRENT_TIMESTAMP
 
 	sqlStatement := `
@@ -147,7 +157,7 @@ func StoreRStats(rs *RaspberryStats) error {
 	) values (?,?,?,?,?)
 	`
 
-	now := time.Now()
+	now := s.clock.Now()
 
 	host, err := json.Marshal(rs.Host)
 	if err != nil {
@@ -162,7 +172,7 @@ func StoreRStats(rs *RaspberryStats) error {
 		return err
 	}
 
-	_, err = DB.Exec(sqlStatement,
+	_, err = s.db.Exec(sqlStatement,
 		string(host),
 		rs.CPULoad,
 		string(storage),
@@ -177,7 +187,7 @@ func StoreRStats(rs *RaspberryStats) error {
 }
 
 // ReadStatsByDate returns the statistics of a specific date.
-func ReadStatsByDate(date string) (*[]TasksStats, *[]RaspberryStats, error) {
+func (s *Stats) ReadStatsByDate(date string) (*[]TasksStats, *[]RaspberryStats, error) {
 	// sqlStatement := `
 	// SELECT * FROM RaspberryStats
 	// ORDER BY datetime(Timestamp) DESC
@@ -208,7 +218,7 @@ func ReadStatsByDate(date string) (*[]TasksStats, *[]RaspberryStats, error) {
 	to := t.Format("2006-01-02")
 
 	// Database query of tasks stats.
-	tsRows, err := DB.Query(sqlStatement1, from, to)
+	tsRows, err := s.db.Query(sqlStatement1, from, to)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -237,7 +247,7 @@ func ReadStatsByDate(date string) (*[]TasksStats, *[]RaspberryStats, error) {
 	}
 
 	// Database query of raspberry stats.
-	rsRows, err := DB.Query(sqlStatement2, from, to)
+	rsRows, err := s.db.Query(sqlStatement2, from, to)
 	if err != nil {
 		return &ts, &rs, err
 	}
@@ -293,7 +303,7 @@ func ReadStatsByDate(date string) (*[]TasksStats, *[]RaspberryStats, error) {
 }
 
 // ReadStatsByHour returns the statistics of a specific date and hour.
-func ReadStatsByHour(date, hour string) (*[]TasksStats, *[]RaspberryStats, error) {
+func (s *Stats) ReadStatsByHour(date, hour string) (*[]TasksStats, *[]RaspberryStats, error) {
 	sqlStatement1 := `
 		SELECT * FROM TasksStats
 		WHERE Timestamp >= ? AND Timestamp <= ?;
@@ -320,7 +330,7 @@ func ReadStatsByHour(date, hour string) (*[]TasksStats, *[]RaspberryStats, error
 	to := t.Format("2006-01-02 15:04")
 
 	// Database query.
-	tsRows, err := DB.Query(sqlStatement1, from, to)
+	tsRows, err := s.db.Query(sqlStatement1, from, to)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -349,7 +359,7 @@ func ReadStatsByHour(date, hour string) (*[]TasksStats, *[]RaspberryStats, error
 	}
 
 	// Database query.
-	rsRows, err := DB.Query(sqlStatement2, from, to)
+	rsRows, err := s.db.Query(sqlStatement2, from, to)
 	if err != nil {
 		return &ts, &rs, err
 	}
@@ -3,6 +3,8 @@ package stats
 import (
 	"database/sql"
 	"sync"
+
+	"github.com/Pegasus8/piworker/utilities/clock"
 )
 
 const (
@@ -13,19 +15,22 @@ const (
 	StatisticsPath = "./statistics/"
 )
 
-// Current is the variable that holds the different statistics of the tasks's execution and the
-// Raspberry Pi running PiWorker.
-var Current = struct {
+// Stats holds the different statistics of the tasks's execution and the Raspberry Pi running PiWorker, and stores
+// them on its database. Each engine uses its own, see `Open`.
+type Stats struct {
 	TasksStats     TasksStats
 	RaspberryStats RaspberryStats
 	sync.RWMutex
-}{}
 
-// WSConns (WebSocket connections) contains a real-time counter of the amount of users with an active websocket connection.
-var WSConns = struct {
-	N uint8
-	sync.RWMutex
-}{}
+	// WSConns (WebSocket connections) contains a real-time counter of the amount of users with an active websocket
+	// connection.
+	WSConns struct {
+		N uint8
+		sync.RWMutex
+	}
 
-// DB is the instance of the stats SQLite3 database.
-var DB *sql.DB
+	// db is the instance of the stats SQLite3 database.
+	db *sql.DB
+	// clock is used to take the time of the statistics and to schedule their storage.
+	clock clock.Clock
+}
@@ -7,10 +7,12 @@ The objective of this is basically have a centralized way of represent different
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/url"
 	"os"
 	"path"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -161,3 +163,114 @@ func GetType(value string) PWType {
 		return Text
 	}
 }
+
+// IsValid reports whether the type is one of the standard types of PiWorker.
+func (t PWType) IsValid() bool {
+	switch t {
+	case Any, Text, Int, Float, Bool, Path, JSON, URL, Date, Time:
+		return true
+	default:
+		return false
+	}
+}
+
+// Accepts checks if the specified `value` (string) has the format required by the type. `Any` and `Text`
+// accept any value.
+func (t PWType) Accepts(value string) bool {
+	switch t {
+	case Any, Text:
+		return true
+	case Int:
+		r, _ := IsInt(value)
+		return r
+	case Float:
+		r, _ := IsFloat(value)
+		return r
+	case Bool:
+		r, _ := IsBool(value)
+		return r
+	case Path:
+		r, _ := IsPath(value)
+		return r
+	case JSON:
+		return IsJSON(value)
+	case URL:
+		r, _ := IsURL(value)
+		return r
+	case Date:
+		r, _ := IsDate(value)
+		return r
+	case Time:
+		r, _ := IsTime(value)
+		return r
+	default:
+		return false
+	}
+}
+
+// Compare compares two values (`a` and `b`) interpreting them as the type `t`. The result will be 0 if a == b, -1
+// if a < b, and +1 if a > b. An error is returned if one of the values doesn't have the format of the type. The
+// types without a natural order (like `Text` or `Path`) are compared lexicographically.
+func Compare(t PWType, a, b string) (int, error) {
+	switch t {
+	case Int, Float:
+		isFloatA, fa := IsFloat(a)
+		isFloatB, fb := IsFloat(b)
+		if !isFloatA || !isFloatB {
+			return 0, fmt.Errorf("the values '%s' and '%s' must be of type '%s'", a, b, t)
+		}
+
+		return compareFloats(fa, fb), nil
+	case Bool:
+		isBoolA, ba := IsBool(a)
+		isBoolB, bb := IsBool(b)
+		if !isBoolA || !isBoolB {
+			return 0, fmt.Errorf("the values '%s' and '%s' must be of type '%s'", a, b, t)
+		}
+
+		// false < true
+		switch {
+		case ba == bb:
+			return 0, nil
+		case bb:
+			return -1, nil
+		default:
+			return 1, nil
+		}
+	case Date, Time:
+		is := IsDate
+		if t == Time {
+			is = IsTime
+		}
+
+		isA, ta := is(a)
+		isB, tb := is(b)
+		if !isA || !isB {
+			return 0, fmt.Errorf("the values '%s' and '%s' must be of type '%s'", a, b, t)
+		}
+
+		switch {
+		case ta.Equal(tb):
+			return 0, nil
+		case ta.Before(tb):
+			return -1, nil
+		default:
+			return 1, nil
+		}
+	case Any, Text, Path, JSON, URL:
+		return strings.Compare(a, b), nil
+	default:
+		return 0, fmt.Errorf("unrecognized type '%s'", t)
+	}
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case a == b:
+		return 0
+	case a < b:
+		return -1
+	default:
+		return 1
+	}
+}
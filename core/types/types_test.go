@@ -341,6 +341,65 @@ func (suite *TypesTestSuite) TestGetType() {
 	}
 }
 
+func (suite *TypesTestSuite) TestAccepts() {
+	assert := assert2.New(suite.T())
+
+	for t, s := range suite.TestCases {
+		for i, st := range s {
+			assert.Truef(t.Accepts(st), "[suite.TestCases[%s][%d]]: the value should be accepted by its own type", string(t), i)
+			assert.Truef(Any.Accepts(st), "[suite.TestCases[%s][%d]]: the type 'Any' should accept any value", string(t), i)
+			assert.Truef(Text.Accepts(st), "[suite.TestCases[%s][%d]]: the type 'Text' should accept any value", string(t), i)
+		}
+	}
+
+	assert.False(Int.Accepts("hello"), "a text should not be accepted by the type 'Int'")
+	assert.False(URL.Accepts("/home/pi"), "a path should not be accepted by the type 'URL'")
+	assert.False(PWType("random-type").Accepts("hello"), "an unrecognized type should not accept any value")
+}
+
+func (suite *TypesTestSuite) TestIsValid() {
+	assert := assert2.New(suite.T())
+
+	for t := range suite.TestCases {
+		assert.Truef(t.IsValid(), "the type '%s' should be valid", string(t))
+	}
+
+	assert.True(Any.IsValid())
+	assert.False(PWType("random-type").IsValid(), "an unrecognized type should not be valid")
+	assert.False(PWType("").IsValid(), "an empty type should not be valid")
+}
+
+func (suite *TypesTestSuite) TestCompare() {
+	assert := assert2.New(suite.T())
+
+	cases := []struct {
+		t        PWType
+		a, b     string
+		expected int
+	}{
+		{Int, "9", "10", -1},
+		{Int, "10", "10.0", 0},
+		{Float, "2.5", "-3", 1},
+		{Bool, "false", "TRUE", -1},
+		{Bool, "True", "true", 0},
+		{Date, "2021-03-05", "04/03/2021", 1},
+		{Time, "07:30", "19:30:00", -1},
+		{Text, "9", "10", 1}, // Lexicographical order.
+		{Path, "/home/pi", "/home/pi", 0},
+	}
+
+	for i, c := range cases {
+		r, err := Compare(c.t, c.a, c.b)
+		assert.NoErrorf(err, "[%d] the values should be compared without errors", i)
+		assert.Equalf(c.expected, r, "[%d] wrong result of the comparison of '%s' and '%s'", i, c.a, c.b)
+	}
+
+	_, err := Compare(Int, "hello", "10")
+	assert.Error(err, "the comparison of a value without the format of the type should return an error")
+	_, err = Compare(PWType("random-type"), "a", "b")
+	assert.Error(err, "the comparison using an unrecognized type should return an error")
+}
+
 func (suite *TypesTestSuite) TearDownTest() {}
 
 func TestTypesSuite(t *testing.T) {
@@ -7,6 +7,8 @@ import (
 	"path/filepath"
 	"regexp"
 	"sync"
+
+	"github.com/Pegasus8/piworker/core/data"
 )
 
 // ReadLocalVariablesFromFiles reads the local variables stored on the files. Useful to restore the contents of the variables
@@ -155,6 +157,47 @@ func GetGlobalVariable(name string) (*GlobalVariable, error) {
 	return &GlobalVariable{}, ErrInvalidVariable
 }
 
+// ReplaceVariable checks if the content of the argument is a reference to a user variable (global or local) and,
+// if it is, replaces the content of the argument with the content of the variable.
+func ReplaceVariable(arg *data.UserArg, parentTaskID string) error {
+	// Check if the arg contains a user global variable
+	if ContainGlobalVariable(&arg.Content) {
+		// If yes, then get the name of the variable by using regex
+		varName := GetGlobalVariableName(arg.Content)
+
+		// Get the variable from the name
+		globalVar, err := GetGlobalVariable(varName)
+		if err != nil {
+			return err
+		}
+
+		// If all it's ok, replace the content of the argument (which is the variable name basically)
+		// with the content of the desired user global variable.
+		globalVar.RLock()
+		arg.Content = globalVar.Content
+		globalVar.RUnlock()
+
+		// If the arg not contains a user global variable, then check if contains a user local variable instead.
+	} else if ContainLocalVariable(&arg.Content) {
+		// If yes, then get the name of the variable by using regex
+		varName := GetLocalVariableName(arg.Content)
+
+		// Get the variable from the name
+		localVariable, err := GetLocalVariable(varName, parentTaskID)
+		if err != nil {
+			return err
+		}
+
+		// If all it's ok, replace the content of the argument (which is the variable name basically)
+		// with the content of the desired user local variable.
+		localVariable.RLock()
+		arg.Content = localVariable.Content
+		localVariable.RUnlock()
+	}
+
+	return nil
+}
+
 func getFiles() ([]os.FileInfo, error) {
 	files, err := ioutil.ReadDir(UserVariablesPath)
 	if err != nil {
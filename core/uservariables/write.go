@@ -3,7 +3,9 @@ package uservariables
 import (
 	"encoding/json"
 	"os"
+	"sync"
 
+	"github.com/Pegasus8/piworker/core/types"
 	"github.com/Pegasus8/piworker/utilities/files"
 	"github.com/rs/zerolog/log"
 )
@@ -54,3 +56,68 @@ func (globalVar *GlobalVariable) WriteToFile() error {
 
 	return nil
 }
+
+// SetGlobalVariable sets the content of the global variable with the given name, creating it if it doesn't exist. The
+// variable is stored on its file and on `GlobalVariablesSlice`.
+func SetGlobalVariable(name, content string) (*GlobalVariable, error) {
+	gv := &GlobalVariable{
+		Name:    name,
+		Content: content,
+		Type:    types.GetType(content),
+		RWMutex: &sync.RWMutex{},
+	}
+	err := gv.WriteToFile()
+	if err != nil {
+		return nil, err
+	}
+
+	globalMutex.Lock()
+	defer globalMutex.Unlock()
+
+	for i, variable := range *GlobalVariablesSlice {
+		if variable.Name == name {
+			// If the variable already exists, replace it.
+			(*GlobalVariablesSlice)[i] = *gv
+			return gv, nil
+		}
+	}
+
+	// We can't append directly to the GVS because the function append doesn't returns a pointer.
+	newGVS := append(*GlobalVariablesSlice, *gv)
+	GlobalVariablesSlice = &newGVS
+
+	return gv, nil
+}
+
+// SetLocalVariable sets the content of the local variable of the task with the given name, creating it if it doesn't
+// exist. The variable is stored on its file and on `LocalVariablesSlice`.
+func SetLocalVariable(name, content, parentTaskID string) (*LocalVariable, error) {
+	lv := &LocalVariable{
+		Name:         name,
+		Content:      content,
+		Type:         types.GetType(content),
+		ParentTaskID: parentTaskID,
+		RWMutex:      &sync.RWMutex{},
+	}
+	err := lv.WriteToFile()
+	if err != nil {
+		return nil, err
+	}
+
+	globalMutex.Lock()
+	defer globalMutex.Unlock()
+
+	for i, variable := range *LocalVariablesSlice {
+		if variable.Name == name && variable.ParentTaskID == parentTaskID {
+			// If the variable already exists, replace it.
+			(*LocalVariablesSlice)[i] = *lv
+			return lv, nil
+		}
+	}
+
+	// We can't append directly to the LVS because the function append doesn't returns a pointer.
+	newLVS := append(*LocalVariablesSlice, *lv)
+	LocalVariablesSlice = &newLVS
+
+	return lv, nil
+}
@@ -1,19 +1,24 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"syscall"
+	"time"
 
 	"github.com/Pegasus8/piworker/core/configs"
 	"github.com/Pegasus8/piworker/core/data"
+	"github.com/Pegasus8/piworker/core/elements"
+	"github.com/Pegasus8/piworker/core/elements/plugins"
 	engine2 "github.com/Pegasus8/piworker/core/engine"
 	"github.com/Pegasus8/piworker/core/logs"
 	"github.com/Pegasus8/piworker/core/signals"
 	"github.com/Pegasus8/piworker/core/stats"
 	"github.com/Pegasus8/piworker/core/uservariables"
+	"github.com/Pegasus8/piworker/utilities/clock"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"gopkg.in/natefinch/lumberjack.v2"
@@ -48,7 +53,18 @@ func start() {
 	log.Info().Msg("Starting PiWorker...")
 
 	uservariables.Init()
-	stats.Init()
+
+	statistics, err := stats.Open(clock.Real)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Error when initializing the statistics")
+	}
+
+	defer func() {
+		err := statistics.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("Error when closing the SQLite3 database of statistics")
+		}
+	}()
 
 	tasksDB, err := data.NewDB(userdataDBPath, userdataDBFilename)
 	if err != nil {
@@ -81,12 +97,34 @@ func start() {
 	log.Info().Int("length", len(*localVariables)).Msg("Local variables read correctly!, saving them on the variable")
 	uservariables.LocalVariablesSlice = localVariables
 
+	registry := elements.NewDefaultRegistry(cfg.MQTTBrokers)
+
+	log.Info().Str("path", cfg.Plugins.Directory).Msg("Loading plugins...")
+	if cfg.Plugins.Directory != "" {
+		loaded, err := plugins.Load(cfg.Plugins.Directory, time.Duration(cfg.Plugins.Timeout)*time.Millisecond,
+			registry)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Error when trying to load the plugins")
+		}
+		log.Info().Int("loaded", loaded).Msg("Plugins loaded correctly")
+	}
+
 	// Initialize the engine.
-	engine := engine2.NewEngine(tasksDB, cfg)
+	engine := engine2.NewEngine(tasksDB, cfg, engine2.WithRegistry(registry), engine2.WithStats(statistics))
 
 	// TODO Use hooks.
 
-	engine.Start()
+	err = engine.Start(context.Background())
+	if err != nil {
+		log.Fatal().Err(err).Msg("Error when starting the engine")
+	}
+
+	<-signals.Shutdown
+
+	err = engine.Stop()
+	if err != nil {
+		log.Error().Err(err).Msg("Error when stopping the engine")
+	}
 }
 
 func prepareLogsDirectory(dir string) error {
@@ -0,0 +1,51 @@
+package clock
+
+import (
+	"time"
+)
+
+// Clock provides the current time and the ways to wait for it. Used instead of the package `time` by the parts of
+// PiWorker that depend on the time, so it can be replaced by a `Fake` on the tests.
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+	// After waits for the duration to elapse and then sends the current time on the returned channel.
+	After(d time.Duration) <-chan time.Time
+	// NewTicker returns a ticker that sends the current time every `d` on its channel.
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker is the equivalent of `time.Ticker` for a `Clock`.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// Real is the clock of the system.
+var Real Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) Since(t time.Time) time.Duration {
+	return time.Since(t)
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+type realTicker struct {
+	*time.Ticker
+}
+
+func (t realTicker) C() <-chan time.Time {
+	return t.Ticker.C
+}
@@ -0,0 +1,149 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Fake is a clock whose time only moves when `Advance` is called, firing the tickers and the waits that are due.
+type Fake struct {
+	mutex sync.Mutex
+	// added is signaled every time a ticker or a wait is registered.
+	added  *sync.Cond
+	now    time.Time
+	timers []*fakeTimer
+}
+
+// fakeTimer is a ticker of a `Fake` clock, or a wait (`After`) if its period is zero.
+type fakeTimer struct {
+	clock  *Fake
+	c      chan time.Time
+	next   time.Time
+	period time.Duration
+}
+
+// NewFake returns a fake clock that starts at the given time.
+func NewFake(now time.Time) *Fake {
+	f := &Fake{now: now}
+	f.added = sync.NewCond(&f.mutex)
+
+	return f
+}
+
+// Now returns the current time of the fake clock.
+func (f *Fake) Now() time.Time {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.now
+}
+
+// Since returns the time elapsed since `t` according to the fake clock.
+func (f *Fake) Since(t time.Time) time.Duration {
+	return f.Now().Sub(t)
+}
+
+// After returns a channel that receives the time once the fake clock is advanced by `d`.
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	return f.newTimer(d, 0).c
+}
+
+// NewTicker returns a ticker that sends the time every time that the fake clock is advanced by `d`.
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for NewTicker")
+	}
+
+	return f.newTimer(d, d)
+}
+
+func (f *Fake) newTimer(d, period time.Duration) *fakeTimer {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	t := &fakeTimer{
+		clock: f,
+		// Like the channels of the package `time`, only one value is kept if the receiver is not ready.
+		c:      make(chan time.Time, 1),
+		next:   f.now.Add(d),
+		period: period,
+	}
+
+	if d <= 0 {
+		t.c <- f.now
+		return t
+	}
+
+	f.timers = append(f.timers, t)
+	f.added.Broadcast()
+
+	return t
+}
+
+// Advance moves the time of the fake clock forward, firing in order the tickers and the waits that are due.
+func (f *Fake) Advance(d time.Duration) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	end := f.now.Add(d)
+
+	for {
+		var next *fakeTimer
+		for _, t := range f.timers {
+			if !t.next.After(end) && (next == nil || t.next.Before(next.next)) {
+				next = t
+			}
+		}
+
+		if next == nil {
+			break
+		}
+
+		f.now = next.next
+
+		select {
+		case next.c <- f.now:
+		default:
+		}
+
+		if next.period > 0 {
+			next.next = next.next.Add(next.period)
+		} else {
+			f.remove(next)
+		}
+	}
+
+	f.now = end
+}
+
+// BlockUntil waits until there are at least `n` tickers and waits registered on the fake clock, so the time can be
+// advanced once the code under test is waiting for it.
+func (f *Fake) BlockUntil(n int) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	for len(f.timers) < n {
+		f.added.Wait()
+	}
+}
+
+// remove must be called with the mutex locked.
+func (f *Fake) remove(t *fakeTimer) {
+	for i := range f.timers {
+		if f.timers[i] == t {
+			f.timers = append(f.timers[:i], f.timers[i+1:]...)
+			return
+		}
+	}
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTimer) Stop() {
+	t.clock.mutex.Lock()
+	defer t.clock.mutex.Unlock()
+
+	t.clock.remove(t)
+}
@@ -0,0 +1,48 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFake(t *testing.T) {
+	assert := assert.New(t)
+
+	start := time.Date(2021, 3, 5, 12, 0, 0, 0, time.UTC)
+	f := NewFake(start)
+
+	assert.Equal(start, f.Now())
+
+	ticker := f.NewTicker(time.Minute)
+	after := f.After(90 * time.Second)
+	f.BlockUntil(2)
+
+	f.Advance(30 * time.Second)
+	assert.Len(ticker.C(), 0, "the ticker must not be fired before its interval")
+	assert.Equal(30*time.Second, f.Since(start))
+
+	f.Advance(30 * time.Second)
+	if assert.Len(ticker.C(), 1, "the ticker must be fired") {
+		assert.Equal(start.Add(time.Minute), <-ticker.C())
+	}
+
+	f.Advance(time.Minute)
+	if assert.Len(after, 1, "the wait must be finished") {
+		assert.Equal(start.Add(90*time.Second), <-after, "the wait must receive the time in which it's due")
+	}
+	assert.Len(ticker.C(), 1)
+	assert.Equal(start.Add(2*time.Minute), <-ticker.C())
+
+	// Like on the real tickers, the ticks are dropped if the receiver is not ready.
+	f.Advance(5 * time.Minute)
+	assert.Len(ticker.C(), 1)
+	assert.Equal(start.Add(3*time.Minute), <-ticker.C())
+
+	ticker.Stop()
+	f.Advance(time.Minute)
+	assert.Len(ticker.C(), 0, "a stopped ticker must not be fired")
+
+	assert.Len(f.After(0), 1, "a wait without duration must be finished immediately")
+}
@@ -0,0 +1,29 @@
+// Package processes provides the execution of external programs that can be interrupted completely, including the
+// processes started by them.
+package processes
+
+import (
+	"context"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// WaitDelay is how long the output of a killed process is waited, since it could be kept open by a process that
+// escaped its group.
+const WaitDelay = time.Second
+
+// CommandContext is like `exec.CommandContext`, but the program is started on its own group of processes and the whole
+// group is killed when `ctx` is done. That way the processes started by the program (for example, the commands of a
+// shell pipeline) don't keep running, holding its output open, after the deadline.
+func CommandContext(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		// The negative PID represents the group of the process.
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = WaitDelay
+
+	return cmd
+}
@@ -2,49 +2,42 @@ package auth
 
 import (
 	"database/sql"
+	"fmt"
 	"os"
 	"path/filepath"
-	// "os/signal"
-	// "syscall"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3" // SQLite3 package
-	"github.com/rs/zerolog/log"
 )
 
-func init() {
+// Open initializes the tokens database (see `Database`), creating its directory and its table if they don't exist.
+// It must be closed once it's not used anymore.
+func Open() error {
 	// Create the path if not exists
 	err := os.MkdirAll(DatabasePath, os.ModePerm)
 	if err != nil {
-		log.Fatal().Err(err).Str("path", DatabasePath).Msg("Cannot initialize the directory to store tokens")
+		return fmt.Errorf("cannot initialize the directory to store tokens: %w", err)
 	}
 
 	Database, err = InitDB()
 	if err != nil {
-		log.Panic().Err(err).Msg("Cannot initialize the database to store tokens")
+		return fmt.Errorf("cannot initialize the database to store tokens: %w", err)
 	}
 
-	// go func() {
-	// 	sigs := make(chan os.Signal, 1)
-	// 	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
-	// 	// Close the database when the shutdown signal is received.
-	// 	<-sigs
-
-	// 	log.Println("Database closed")
-	// }()
-
 	err = CreateTable()
 	if err != nil {
-		log.Panic().Err(err).Msg("Error when trying to create the table on the tokens database")
+		Database.Close()
+		return fmt.Errorf("error when trying to create the table on the tokens database: %w", err)
 	}
+
+	return nil
 }
 
 /*
 *	Usage order:
-*	1) InitDB
-*	2) defer db.Close()
-*	3) CreateTable
-*	4) StoreToken/ReadLastToken
+*	1) Open (InitDB and CreateTable)
+*	2) defer Database.Close()
+*	3) StoreToken/ReadLastToken
  */
 
 // InitDB is the function used to initialize the sqlite3 database.
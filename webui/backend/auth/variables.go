@@ -11,5 +11,5 @@ var DatabasePath = data.Path
 // DatabaseName is the name of the sqlite3 database used for storage of auth info.
 const DatabaseName = "tokens.db"
 
-// Database is the tokens database instance. Need the execution of the function `InitDB` for initialization.
+// Database is the tokens database instance. Need the execution of the function `Open` for initialization.
 var Database *sql.DB
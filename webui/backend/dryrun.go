@@ -0,0 +1,191 @@
+package backend
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Pegasus8/piworker/core/data"
+	actionsModel "github.com/Pegasus8/piworker/core/elements/actions/shared"
+	"github.com/Pegasus8/piworker/core/types"
+	"github.com/Pegasus8/piworker/core/uservariables"
+)
+
+// dryRunReport is the result of simulating the execution of the actions of a task.
+type dryRunReport struct {
+	TaskID string       `json:"taskID"`
+	Valid  bool         `json:"valid"`
+	Steps  []dryRunStep `json:"steps"`
+}
+
+// dryRunStep represents the simulation of a single action, in the order in which it would be executed.
+type dryRunStep struct {
+	ID    string      `json:"ID"`
+	Name  string      `json:"name"`
+	Order uint8       `json:"order"`
+	Args  []dryRunArg `json:"args"`
+	// ReturnedChainResultType is the type of the result given to the next action.
+	ReturnedChainResultType types.PWType `json:"returnedChainResultType"`
+	Valid                   bool         `json:"valid"`
+	Error                   string       `json:"error,omitempty"`
+}
+
+// dryRunArg represents an argument of an action after the resolution of the user variables and the chained result.
+type dryRunArg struct {
+	ID          string       `json:"ID"`
+	Name        string       `json:"name"`
+	Content     string       `json:"content"`
+	ContentType types.PWType `json:"contentType"`
+	// Chained indicates that the content will be given by the result of the previous action, so it's only known at
+	// the moment of the execution.
+	Chained bool   `json:"chained"`
+	Error   string `json:"error,omitempty"`
+}
+
+// dryRun simulates the execution of the actions of the task: the user variables and the chained results are
+// resolved and the content of each argument is checked against the type declared by the action. The actions
+// themselves are never executed.
+func dryRun(task *data.UserTask) *dryRunReport {
+	report := &dryRunReport{
+		TaskID: task.ID,
+		Valid:  true,
+		Steps:  []dryRunStep{},
+	}
+
+	userActions := make([]data.UserAction, len(task.Actions))
+	copy(userActions, task.Actions)
+	sort.SliceStable(userActions, func(i, j int) bool {
+		return userActions[i].Order < userActions[j].Order
+	})
+
+	previousCR := &actionsModel.ChainedResult{}
+
+	for i, userAction := range userActions {
+		step := simulateAction(task.ID, userAction, previousCR)
+		if !step.Valid {
+			report.Valid = false
+		}
+
+		report.Steps = append(report.Steps, step)
+
+		// The actions of a parallel group (the ones that share the same order) receive the same chained result.
+		if userAction.Parallel != nil && i+1 < len(userActions) && userActions[i+1].Order == userAction.Order {
+			continue
+		}
+
+		// The real result is unknown, so the next action receives a placeholder of the declared type. After a
+		// parallel group, it's the JSON array with the results of its actions.
+		resultType := step.ReturnedChainResultType
+		if userAction.Parallel != nil && i > 0 && userActions[i-1].Order == userAction.Order {
+			resultType = types.JSON
+		}
+
+		previousCR = &actionsModel.ChainedResult{
+			Result:     fmt.Sprintf("<result of the action %d>", userAction.Order),
+			ResultType: resultType,
+		}
+	}
+
+	return report
+}
+
+func simulateAction(taskID string, userAction data.UserAction, previousCR *actionsModel.ChainedResult) dryRunStep {
+	step := dryRunStep{
+		ID:    userAction.ID,
+		Order: userAction.Order,
+		Args:  []dryRunArg{},
+		Valid: true,
+	}
+
+	pwAction, found := registry.Action(userAction.ID)
+	if !found {
+		step.Valid = false
+		step.Error = fmt.Sprintf("the action with the ID '%s' cannot be found", userAction.ID)
+
+		return step
+	}
+
+	step.Name = pwAction.Name
+	step.ReturnedChainResultType = pwAction.ReturnedChainResultType
+
+	// Work on a copy of the args, the task must not be modified.
+	args := make([]data.UserArg, len(userAction.Args))
+	copy(args, userAction.Args)
+	userAction.Args = args
+
+	argErrors := make(map[string]string)
+
+	for i := range userAction.Args {
+		err := uservariables.ReplaceVariable(&userAction.Args[i], taskID)
+		if err != nil {
+			argErrors[userAction.Args[i].ID] = err.Error()
+		}
+	}
+
+	err := actionsModel.HandleCR(&userAction, pwAction.Args, previousCR)
+	if err != nil {
+		step.Valid = false
+		step.Error = err.Error()
+	}
+
+	chainedArg := ""
+	if userAction.Chained && userAction.Order != 0 {
+		chainedArg = userAction.ArgumentToReplaceByCR
+	}
+
+	for _, pwArg := range pwAction.Args {
+		arg := dryRunArg{
+			ID:          pwArg.ID,
+			Name:        pwArg.Name,
+			ContentType: pwArg.ContentType,
+			Chained:     pwArg.ID == chainedArg,
+		}
+
+		found := false
+		for _, userArg := range userAction.Args {
+			if userArg.ID == pwArg.ID {
+				found = true
+				arg.Content = userArg.Content
+				break
+			}
+		}
+
+		switch {
+		case !found:
+			arg.Error = "the argument is missing"
+		case argErrors[pwArg.ID] != "":
+			arg.Error = argErrors[pwArg.ID]
+		case arg.Chained:
+			// The type compatibility of the chained result was already checked by `HandleCR`.
+		case !pwArg.ContentType.Accepts(arg.Content):
+			arg.Error = fmt.Sprintf("the content is not of type '%s'", pwArg.ContentType)
+		}
+
+		if arg.Error != "" {
+			step.Valid = false
+		}
+
+		step.Args = append(step.Args, arg)
+	}
+
+	// Arguments not declared by the action.
+	for _, userArg := range userAction.Args {
+		declared := false
+		for _, pwArg := range pwAction.Args {
+			if userArg.ID == pwArg.ID {
+				declared = true
+				break
+			}
+		}
+
+		if !declared {
+			step.Valid = false
+			step.Args = append(step.Args, dryRunArg{
+				ID:      userArg.ID,
+				Content: userArg.Content,
+				Error:   actionsModel.ErrUnrecognizedArgID.Error(),
+			})
+		}
+	}
+
+	return step
+}
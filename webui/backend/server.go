@@ -1,20 +1,24 @@
 package backend
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"os"
 	"path/filepath"
 	"regexp"
 	"sort"
+	"strconv"
 	"time"
 
 	"github.com/Pegasus8/piworker/core/configs"
 	"github.com/Pegasus8/piworker/core/data"
-	actionsList "github.com/Pegasus8/piworker/core/elements/actions/models"
-	triggersList "github.com/Pegasus8/piworker/core/elements/triggers/models"
+	"github.com/Pegasus8/piworker/core/elements"
+	"github.com/Pegasus8/piworker/core/elements/triggers/models/temp"
+	"github.com/Pegasus8/piworker/core/elements/triggers/models/webhook"
 	"github.com/Pegasus8/piworker/core/stats"
 
 	// pwLogs "github.com/Pegasus8/piworker/core/logs"
@@ -33,6 +37,10 @@ var tlsSupport bool
 var devMode bool
 var cfg *configs.Configs
 var tasksDB *data.DatabaseInstance
+var registry *elements.Registry
+
+// statistics is nil if the engine doesn't collect statistics.
+var statistics *stats.Stats
 
 // -- Using (partially) example from https://github.com/gorilla/mux#serving-single-page-applications --.
 // spaHandler implements the http.Handler interface, so we can use it
@@ -77,14 +85,7 @@ func (h spaHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 // ──────────────────────────────────────────────────────────────
 //
 
-func setupRoutes() {
-	defer func() {
-		err := auth.Database.Close()
-		if err != nil {
-			log.Error().Err(err).Msg("Error when closing auth db")
-		}
-	}()
-
+func setupRoutes(ctx context.Context) error {
 	_ = pkger.Include("/webui/frontend/dist")
 
 	auth.SetCfg(cfg)
@@ -109,26 +110,42 @@ func setupRoutes() {
 	if cfg.APIConfigs.GetAllTasksAPI {
 		router.Handle("/api/tasks/get-all", auth.IsAuthorized(makeGzipHandler(getTasksAPI))).Methods("GET")
 	}
+	if cfg.APIConfigs.ExecutionsAPI {
+		router.Handle("/api/tasks/{id}/executions", auth.IsAuthorized(makeGzipHandler(executionsAPI))).Methods("GET")
+	}
+	if cfg.APIConfigs.RunTaskAPI {
+		router.Handle("/api/tasks/{id}/run", auth.IsAuthorized(runTaskAPI)).Methods("POST")
+	}
+	if cfg.APIConfigs.DryRunAPI {
+		router.Handle("/api/tasks/{id}/dry-run", auth.IsAuthorized(makeGzipHandler(dryRunAPI))).Methods("GET")
+	}
 	if cfg.APIConfigs.LogsAPI {
 		router.Handle("/api/tasks/logs", auth.IsAuthorized(makeGzipHandler(logsAPI))).Methods("GET")
 	}
-	if cfg.APIConfigs.StatisticsAPI {
+	if cfg.APIConfigs.StatisticsAPI && statistics != nil {
 		router.Handle("/api/info/statistics", auth.IsAuthorized(makeGzipHandler(statisticsAPI))).Methods("GET")
 	}
 	if cfg.APIConfigs.TypesCompatAPI {
 		router.Handle("/api/info/types-compat", auth.IsAuthorized(makeGzipHandler(typesCompatAPI))).Methods("GET")
 	}
+	if cfg.APIConfigs.WebhooksAPI && registry.Hooks() != nil {
+		// Authenticated by the secret of each hook instead of the token of a user.
+		router.HandleFunc("/api/hooks/{hookID}", webhookAPI).Methods("POST")
+	}
 	// ────────────────────────────────────────────────────────────────────────────────
 
 	if cfg.WebUI.Enabled {
 		// ─── WEBSOCKET ──────────────────────────────────────────────────────────────────
-		router.HandleFunc("/ws", statsWS)
+		if statistics != nil {
+			router.HandleFunc("/ws", statsWS)
+		}
 		router.Handle("/api/ws-auth", auth.IsAuthorized(makeGzipHandler(wsAuthAPI))).Methods("GET")
 		// ────────────────────────────────────────────────────────────────────────────────
 
 		// ─── MODELS INFO ────────────────────────────────────────────────────────────────
 		router.Handle("/api/webui/triggers-structs", auth.IsAuthorized(makeGzipHandler(triggersInfoAPI))).Methods("GET")
 		router.Handle("/api/webui/actions-structs", auth.IsAuthorized(makeGzipHandler(actionsInfoAPI))).Methods("GET")
+		router.Handle("/api/webui/temperature-sensors", auth.IsAuthorized(makeGzipHandler(temperatureSensorsAPI))).Methods("GET")
 		// ────────────────────────────────────────────────────────────────────────────────
 
 		// ─── SINGLE PAGE APP ────────────────────────────────────────────────────────────
@@ -171,17 +188,60 @@ func setupRoutes() {
 		log.Warn().Msg("File 'server.key' not found")
 	}
 
+	// The server is shut down when the context is canceled. Once it's closed, the shutdown is awaited so the requests in
+	// progress finish before returning.
+	stopped := make(chan struct{})
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+
+		select {
+		case <-ctx.Done():
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			err := srv.Shutdown(shutdownCtx)
+			if err != nil {
+				log.Error().Err(err).Msg("Error when trying to shut down the server")
+			}
+		case <-stopped:
+		}
+	}()
+
+	var err error
 	if tlsSupport {
-		log.Fatal().Err(srv.ListenAndServeTLS("./server.crt", "./server.key")).Msg("")
+		err = srv.ListenAndServeTLS("./server.crt", "./server.key")
 	} else {
-		log.Fatal().Err(srv.ListenAndServe()).Msg("")
+		err = srv.ListenAndServe()
+	}
+
+	close(stopped)
+	<-shutdownDone
+
+	if err == http.ErrServerClosed {
+		return nil
 	}
+
+	return err
 }
 
-// Run - start the server
-func Run(tDB *data.DatabaseInstance, c *configs.Configs) {
+// Run - start the server, which keeps running until `ctx` is canceled or an error happens. The triggers and the actions
+// shown and accepted are the ones of `r`, and the statistics shown the ones of `s` (nil if they aren't collected). The
+// tokens database is opened by the server and closed once it has stopped, so it can be run again later.
+func Run(ctx context.Context, tDB *data.DatabaseInstance, c *configs.Configs, r *elements.Registry, s *stats.Stats) error {
 	log.Info().Msg("Starting server...")
 
+	err := auth.Open()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err := auth.Database.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("Error when closing auth db")
+		}
+	}()
+
 	if d := os.Getenv("DEV"); d != "" {
 		log.Warn().Msg("Server on development mode activated")
 		devMode = true
@@ -189,8 +249,10 @@ func Run(tDB *data.DatabaseInstance, c *configs.Configs) {
 
 	cfg = c
 	tasksDB = tDB
+	registry = r
+	statistics = s
 
-	setupRoutes()
+	return setupRoutes(ctx)
 }
 
 //
@@ -240,7 +302,7 @@ func statsWS(w http.ResponseWriter, request *http.Request) {
 	}
 	// Execution of data sending to the client
 	// into another goroutine
-	go websocket.Writer(ws)
+	go websocket.Writer(ws, statistics)
 }
 
 func wsAuthAPI(w http.ResponseWriter, request *http.Request) {
@@ -424,6 +486,19 @@ func newTaskAPI(w http.ResponseWriter, request *http.Request) { // Method: POST
 		return
 	}
 
+	err = validateTask(&task)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("api", "newTask").
+			Str("remoteAddr", request.RemoteAddr).
+			Msg("Rejecting request because the task is not valid")
+
+		writeValidationError(w, err)
+
+		return
+	}
+
 	task.Created = time.Now()
 	task.LastTimeModified = task.Created
 
@@ -485,6 +560,19 @@ func updateTaskAPI(w http.ResponseWriter, request *http.Request) { // Method: PU
 		return
 	}
 
+	err = validateTask(&task)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("api", "updateTask").
+			Str("remoteAddr", request.RemoteAddr).
+			Msg("Rejecting request because the task is not valid")
+
+		writeValidationError(w, err)
+
+		return
+	}
+
 	err = tasksDB.UpdateTask(taskID, &task)
 	if err != nil {
 		log.Error().
@@ -528,7 +616,7 @@ func deleteTaskAPI(w http.ResponseWriter, request *http.Request) { // Method: DE
 	// Uncomment to enable CORS support.
 	//setCORSHeaders(&w, request)
 
-	err := data.DeleteTask(taskID)
+	err := tasksDB.DeleteTask(taskID)
 	if err != nil {
 		log.Error().
 			Err(err).
@@ -592,32 +680,109 @@ func getTasksAPI(w http.ResponseWriter, request *http.Request) { // Method: GET
 		}
 
 		type triggerForWebUI struct {
-			Name        string        `json:"name"`
-			Description string        `json:"description"`
-			ID          string        `json:"ID"`
-			Timestamp   string        `json:"timestamp"`
-			Args        []argForWebUI `json:"args"`
+			Name        string            `json:"name"`
+			Description string            `json:"description"`
+			ID          string            `json:"ID"`
+			Timestamp   string            `json:"timestamp"`
+			Args        []argForWebUI     `json:"args"`
+			Children    []triggerForWebUI `json:"children,omitempty"`
 		}
 
 		type actionForWebUI struct {
-			Name                  string        `json:"name"`
-			Description           string        `json:"description"`
-			ID                    string        `json:"ID"`
-			Timestamp             string        `json:"timestamp"`
-			Args                  []argForWebUI `json:"args"`
-			Order                 uint8         `json:"order"`
-			Chained               bool          `json:"chained"`
-			ArgumentToReplaceByCR string        `json:"argumentToReplaceByCR"`
+			Name                  string              `json:"name"`
+			Description           string              `json:"description"`
+			ID                    string              `json:"ID"`
+			Timestamp             string              `json:"timestamp"`
+			Args                  []argForWebUI       `json:"args"`
+			Order                 uint8               `json:"order"`
+			Chained               bool                `json:"chained"`
+			ArgumentToReplaceByCR string              `json:"argumentToReplaceByCR"`
+			Retry                 *data.RetryPolicy   `json:"retry,omitempty"`
+			Timeout               int64               `json:"timeout,omitempty"`
+			Condition             *data.StepCondition `json:"condition,omitempty"`
+			Parallel              *data.ParallelGroup `json:"parallel,omitempty"`
 		}
 
 		type taskForWebUI struct {
-			Name             string           `json:"name"`
-			State            data.TaskState   `json:"state"`
-			Trigger          triggerForWebUI  `json:"trigger"`
-			Actions          []actionForWebUI `json:"actions"`
-			Created          time.Time        `json:"created"`
-			LastTimeModified time.Time        `json:"lastTimeModified"`
-			ID               string           `json:"ID"`
+			Name             string            `json:"name"`
+			State            data.TaskState    `json:"state"`
+			Trigger          triggerForWebUI   `json:"trigger"`
+			Actions          []actionForWebUI  `json:"actions"`
+			OnFailure        []actionForWebUI  `json:"onFailure,omitempty"`
+			Settings         data.TaskSettings `json:"settings"`
+			Created          time.Time         `json:"created"`
+			LastTimeModified time.Time         `json:"lastTimeModified"`
+			ID               string            `json:"ID"`
+			FailureCount     uint16            `json:"failureCount"`
+			LastFailure      time.Time         `json:"lastFailure"`
+			LastFired        time.Time         `json:"lastFired"`
+		}
+
+		// The recreation of the trigger is recursive because of the children of composite triggers.
+		var recreateTrigger func(trigger data.UserTrigger) triggerForWebUI
+		recreateTrigger = func(trigger data.UserTrigger) triggerForWebUI {
+			pwtrigger, _ := registry.Trigger(trigger.ID)
+			recreatedTrigger := triggerForWebUI{
+				Name:        pwtrigger.Name,
+				Description: pwtrigger.Description,
+				ID:          trigger.ID,
+				Timestamp:   trigger.Timestamp,
+				Args:        []argForWebUI{}, // Will be completed after
+			}
+			for _, arg := range trigger.Args {
+				for _, pwarg := range pwtrigger.Args {
+					if arg.ID == pwarg.ID {
+						recreatedArg := argForWebUI{
+							Name:        pwarg.Name,
+							Description: pwarg.Description,
+							ID:          arg.ID,
+							Content:     arg.Content,
+							ContentType: pwarg.ContentType,
+						}
+						recreatedTrigger.Args = append(recreatedTrigger.Args, recreatedArg)
+					}
+				}
+			}
+			for _, child := range trigger.Children {
+				recreatedTrigger.Children = append(recreatedTrigger.Children, recreateTrigger(child))
+			}
+
+			return recreatedTrigger
+		}
+
+		recreateAction := func(userAction data.UserAction) actionForWebUI {
+			pwaction, _ := registry.Action(userAction.ID)
+			recreatedAction := actionForWebUI{
+				Name:                  pwaction.Name,
+				Description:           pwaction.Description,
+				ID:                    userAction.ID,
+				Timestamp:             userAction.Timestamp,
+				Args:                  []argForWebUI{}, // Will be completed after
+				Order:                 userAction.Order,
+				Chained:               userAction.Chained,
+				ArgumentToReplaceByCR: userAction.ArgumentToReplaceByCR,
+				Retry:                 userAction.Retry,
+				Timeout:               userAction.Timeout,
+				Condition:             userAction.Condition,
+				Parallel:              userAction.Parallel,
+			}
+			for _, arg := range userAction.Args {
+				for _, pwarg := range pwaction.Args {
+					if arg.ID == pwarg.ID {
+						recreatedArg := argForWebUI{
+							Name:        pwarg.Name,
+							Description: pwarg.Description,
+							ID:          arg.ID,
+							Content:     arg.Content,
+							ContentType: pwarg.ContentType,
+						}
+						recreatedAction.Args = append(recreatedAction.Args, recreatedArg)
+						break
+					}
+				}
+			}
+
+			return recreatedAction
 		}
 
 		var recreatedUserData []taskForWebUI
@@ -639,66 +804,24 @@ func getTasksAPI(w http.ResponseWriter, request *http.Request) { // Method: GET
 
 				recreatedTask.Name = task.Name
 				recreatedTask.State = task.State
+				recreatedTask.Settings = task.Settings
 				recreatedTask.Created = task.Created
 				recreatedTask.LastTimeModified = task.LastTimeModified
 				recreatedTask.ID = task.ID
+				recreatedTask.FailureCount = task.FailureCount
+				recreatedTask.LastFailure = task.LastFailure
+				recreatedTask.LastFired = task.LastFired
 
 				// Reformatting of actions
 				for _, userAction := range task.Actions {
-					pwaction := actionsList.Get(userAction.ID)
-					recreatedAction := actionForWebUI{
-						Name:                  pwaction.Name,
-						Description:           pwaction.Description,
-						ID:                    userAction.ID,
-						Timestamp:             userAction.Timestamp,
-						Args:                  []argForWebUI{}, // Will be completed after
-						Order:                 userAction.Order,
-						Chained:               userAction.Chained,
-						ArgumentToReplaceByCR: userAction.ArgumentToReplaceByCR,
-					}
-					for _, arg := range userAction.Args {
-						for _, pwarg := range pwaction.Args {
-							if arg.ID == pwarg.ID {
-								recreatedArg := argForWebUI{
-									Name:        pwarg.Name,
-									Description: pwarg.Description,
-									ID:          arg.ID,
-									Content:     arg.Content,
-									ContentType: pwarg.ContentType,
-								}
-								recreatedAction.Args = append(recreatedAction.Args, recreatedArg)
-								break
-							}
-						}
-					}
-
-					recreatedTask.Actions = append(recreatedTask.Actions, recreatedAction)
+					recreatedTask.Actions = append(recreatedTask.Actions, recreateAction(userAction))
+				}
+				for _, userAction := range task.OnFailure {
+					recreatedTask.OnFailure = append(recreatedTask.OnFailure, recreateAction(userAction))
 				}
 
 				// Reformatting of trigger
-				pwtrigger := triggersList.Get(task.Trigger.ID)
-				recreatedTrigger := triggerForWebUI{
-					Name:        pwtrigger.Name,
-					Description: pwtrigger.Description,
-					ID:          task.Trigger.ID,
-					Timestamp:   task.Trigger.Timestamp,
-					Args:        []argForWebUI{}, // Will be completed after
-				}
-				for _, arg := range task.Trigger.Args {
-					for _, pwarg := range pwtrigger.Args {
-						if arg.ID == pwarg.ID {
-							recreatedArg := argForWebUI{
-								Name:        pwarg.Name,
-								Description: pwarg.Description,
-								ID:          arg.ID,
-								Content:     arg.Content,
-								ContentType: pwarg.ContentType,
-							}
-							recreatedTrigger.Args = append(recreatedTrigger.Args, recreatedArg)
-						}
-					}
-				}
-				recreatedTask.Trigger = recreatedTrigger
+				recreatedTask.Trigger = recreateTrigger(task.Trigger)
 
 				executionTime := time.Since(startTime)
 				log.Info().
@@ -748,6 +871,238 @@ func getTasksAPI(w http.ResponseWriter, request *http.Request) { // Method: GET
 	}
 }
 
+func executionsAPI(w http.ResponseWriter, request *http.Request) { // Method: GET
+	if request.Method != "GET" {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	taskID := mux.Vars(request)["id"]
+
+	limit, err := intQueryParam(request, "limit", 20)
+	if err != nil || limit <= 0 || limit > 100 {
+		log.Error().
+			Err(errors.New("url Param 'limit' is invalid")).
+			Str("api", "executions").
+			Str("remoteAddr", request.RemoteAddr).
+			Msg("Rejecting request because of an invalid 'limit' param")
+
+		w.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	offset, err := intQueryParam(request, "offset", 0)
+	if err != nil || offset < 0 {
+		log.Error().
+			Err(errors.New("url Param 'offset' is invalid")).
+			Str("api", "executions").
+			Str("remoteAddr", request.RemoteAddr).
+			Msg("Rejecting request because of an invalid 'offset' param")
+
+		w.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	_, err = tasksDB.GetTaskByID(taskID)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("api", "executions").
+			Str("remoteAddr", request.RemoteAddr).
+			Str("taskID", taskID).
+			Msg("Error when trying to get the task")
+
+		if err == data.ErrBadTaskID {
+			w.WriteHeader(http.StatusNotFound)
+		} else {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	total, err := tasksDB.CountExecutions(taskID)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("api", "executions").
+			Str("remoteAddr", request.RemoteAddr).
+			Str("taskID", taskID).
+			Msg("Error when trying to count the executions of the task")
+
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	executions, err := tasksDB.GetExecutions(taskID, limit, offset)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("api", "executions").
+			Str("remoteAddr", request.RemoteAddr).
+			Str("taskID", taskID).
+			Msg("Error when trying to read the executions of the task")
+
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	response := struct {
+		Total      int                   `json:"total"`
+		Limit      int                   `json:"limit"`
+		Offset     int                   `json:"offset"`
+		Executions *[]data.TaskExecution `json:"executions"`
+	}{
+		Total:      total,
+		Limit:      limit,
+		Offset:     offset,
+		Executions: executions,
+	}
+
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("api", "executions").
+			Str("remoteAddr", request.RemoteAddr).
+			Msg("Error when trying to encode the JSON response")
+
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func runTaskAPI(w http.ResponseWriter, request *http.Request) { // Method: POST
+	if request.Method != "POST" {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	taskID := mux.Vars(request)["id"]
+
+	err := tasksDB.RequestExecution(taskID)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("api", "runTask").
+			Str("remoteAddr", request.RemoteAddr).
+			Str("taskID", taskID).
+			Msg("Error when trying to request the execution of the task")
+
+		switch err {
+		case data.ErrBadTaskID:
+			w.WriteHeader(http.StatusNotFound)
+		case data.ErrTaskOnExecution:
+			w.WriteHeader(http.StatusConflict)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	// The actions are executed asynchronously by the engine, the result can be consulted on the executions
+	// of the task.
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// maxWebhookBody limits the size of the body of the requests received by the webhooks.
+const maxWebhookBody = 1 << 20
+
+func webhookAPI(w http.ResponseWriter, request *http.Request) { // Method: POST
+	if request.Method != "POST" {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	hookID := mux.Vars(request)["hookID"]
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, request.Body, maxWebhookBody))
+	if err != nil {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	activated, err := registry.Hooks().Deliver(hookID, request.Header, body)
+	if err != nil {
+		log.Warn().
+			Err(err).
+			Str("api", "webhook").
+			Str("remoteAddr", request.RemoteAddr).
+			Str("hookID", hookID).
+			Msg("Request to a webhook rejected")
+
+		switch err {
+		case webhook.ErrUnauthorized:
+			w.WriteHeader(http.StatusUnauthorized)
+		case webhook.ErrInvalidBody:
+			w.WriteHeader(http.StatusBadRequest)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	// The tasks are executed asynchronously by the engine.
+	w.WriteHeader(http.StatusAccepted)
+	err = json.NewEncoder(w).Encode(struct {
+		Activated int `json:"activated"`
+	}{activated})
+	if err != nil {
+		log.Error().Err(err).Str("api", "webhook").Msg("Error when trying to encode the JSON response")
+	}
+}
+
+func dryRunAPI(w http.ResponseWriter, request *http.Request) { // Method: GET
+	if request.Method != "GET" {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	taskID := mux.Vars(request)["id"]
+
+	task, err := tasksDB.GetTaskByID(taskID)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("api", "dryRun").
+			Str("remoteAddr", request.RemoteAddr).
+			Str("taskID", taskID).
+			Msg("Error when trying to get the task")
+
+		if err == data.ErrBadTaskID {
+			w.WriteHeader(http.StatusNotFound)
+		} else {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(dryRun(task))
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("api", "dryRun").
+			Str("remoteAddr", request.RemoteAddr).
+			Msg("Error when trying to encode the JSON response")
+
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
 func logsAPI(w http.ResponseWriter, request *http.Request) { // Method: GET
 	if request.Method != "GET" {
 		w.WriteHeader(http.StatusMethodNotAllowed)
@@ -863,7 +1218,7 @@ func statisticsAPI(w http.ResponseWriter, request *http.Request) { // Method: GE
 	}
 
 	if byHour {
-		ts, rs, err := stats.ReadStatsByHour(date[0], hour[0])
+		ts, rs, err := statistics.ReadStatsByHour(date[0], hour[0])
 		if err != nil {
 			log.Error().
 				Err(err).
@@ -897,7 +1252,7 @@ func statisticsAPI(w http.ResponseWriter, request *http.Request) { // Method: GE
 			w.WriteHeader(http.StatusInternalServerError)
 		}
 	} else {
-		ts, rs, err := stats.ReadStatsByDate(date[0])
+		ts, rs, err := statistics.ReadStatsByDate(date[0])
 		if err != nil {
 			log.Error().
 				Err(err).
@@ -941,7 +1296,7 @@ func triggersInfoAPI(w http.ResponseWriter, request *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 
-	err := json.NewEncoder(w).Encode(triggersList.TRIGGERS)
+	err := json.NewEncoder(w).Encode(registry.Triggers())
 	if err != nil {
 		log.Error().
 			Err(err).
@@ -961,7 +1316,7 @@ func actionsInfoAPI(w http.ResponseWriter, request *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 
-	err := json.NewEncoder(w).Encode(actionsList.ACTIONS)
+	err := json.NewEncoder(w).Encode(registry.Actions())
 	if err != nil {
 		log.Error().
 			Err(err).
@@ -973,6 +1328,53 @@ func actionsInfoAPI(w http.ResponseWriter, request *http.Request) {
 	}
 }
 
+// temperatureSensorsAPI returns the temperature sensors of the host, whose keys can be used on the trigger
+// `temp.RaspberryTemperature`.
+func temperatureSensorsAPI(w http.ResponseWriter, request *http.Request) {
+	if request.Method != "GET" {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	sensors, err := temp.Sensors()
+	if err != nil && err != temp.ErrNoSensors {
+		log.Error().
+			Err(err).
+			Str("api", "temperatureSensors").
+			Str("remoteAddr", request.RemoteAddr).
+			Msg("Error when trying to read the temperature sensors")
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if sensors == nil {
+		sensors = []temp.Sensor{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	err = json.NewEncoder(w).Encode(sensors)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("api", "temperatureSensors").
+			Str("remoteAddr", request.RemoteAddr).
+			Msg("")
+
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// intQueryParam returns the value of the URL param `key` converted to integer, or `def` if the param is absent.
+func intQueryParam(request *http.Request, key string, def int) (int, error) {
+	values, ok := request.URL.Query()[key]
+	if !ok || len(values[0]) < 1 {
+		return def, nil
+	}
+
+	return strconv.Atoi(values[0])
+}
+
 //func setCORSHeaders(w *http.ResponseWriter, _ *http.Request) {
 //	(*w).Header().Set("Access-Control-Allow-Origin", "*")
 //	(*w).Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
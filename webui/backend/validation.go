@@ -0,0 +1,89 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/Pegasus8/piworker/core/data"
+	"github.com/Pegasus8/piworker/core/elements/triggers/models/composite"
+	"github.com/Pegasus8/piworker/core/uservariables"
+
+	"github.com/rs/zerolog/log"
+)
+
+// validateTask checks the configuration of the trigger of a task (and the calendar referenced by it), rejecting a
+// wrong one before it's stored instead of letting it fail on the task loop.
+func validateTask(task *data.UserTask) error {
+	if name := task.Settings.Calendar; name != "" {
+		if _, found := cfg.Calendar(name); !found {
+			return fmt.Errorf("the calendar '%s' doesn't exist", name)
+		}
+	}
+
+	return validateTrigger(&task.Trigger)
+}
+
+func validateTrigger(trigger *data.UserTrigger) error {
+	if trigger.ID == composite.Composite.ID {
+		operator, err := composite.ParseOperator(&trigger.Args)
+		if err != nil {
+			return err
+		}
+
+		err = composite.CheckChildren(operator, len(trigger.Children))
+		if err != nil {
+			return err
+		}
+
+		for i := range trigger.Children {
+			// The children are evaluated on each tick, which isn't possible with the triggers that notify their
+			// activations.
+			if child, _ := registry.Trigger(trigger.Children[i].ID); child.IsPush() {
+				return fmt.Errorf("child %d (ID: %s): push triggers can't be combined", i, trigger.Children[i].ID)
+			}
+
+			err = validateTrigger(&trigger.Children[i])
+			if err != nil {
+				return fmt.Errorf("child %d (ID: %s): %s", i, trigger.Children[i].ID, err.Error())
+			}
+		}
+
+		return nil
+	}
+
+	if len(trigger.Children) > 0 {
+		return fmt.Errorf("the trigger with the ID '%s' can't have children", trigger.ID)
+	}
+
+	pwTrigger, _ := registry.Trigger(trigger.ID)
+	if pwTrigger.Validate == nil {
+		return nil
+	}
+
+	// The content of the user variables is only known at the moment of the execution, so there is nothing to
+	// check yet.
+	for _, arg := range trigger.Args {
+		if uservariables.ContainGlobalVariable(&arg.Content) || uservariables.ContainLocalVariable(&arg.Content) {
+			return nil
+		}
+	}
+
+	return pwTrigger.Validate(&trigger.Args)
+}
+
+// writeValidationError responds with the status `http.StatusBadRequest` and the reason of the rejection.
+func writeValidationError(w http.ResponseWriter, err error) {
+	w.WriteHeader(http.StatusBadRequest)
+
+	response := struct {
+		Error string `json:"error"`
+	}{
+		Error: err.Error(),
+	}
+
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		log.Error().Err(err).Msg("Error when trying to encode the JSON response")
+	}
+}
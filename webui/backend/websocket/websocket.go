@@ -36,8 +36,8 @@ func Upgrade(w http.ResponseWriter, request *http.Request) (*websocket.Conn, err
 	return ws, nil
 }
 
-// Writer func sends data into WebSocket to the client
-func Writer(conn *websocket.Conn) {
+// Writer func sends the statistics `s` into WebSocket to the client
+func Writer(conn *websocket.Conn, s *stats.Stats) {
 	type d struct {
 		*stats.TasksStats
 		*stats.RaspberryStats
@@ -48,9 +48,9 @@ func Writer(conn *websocket.Conn) {
 	}
 
 	// Increment the counter of connections
-	stats.WSConns.Lock()
-	stats.WSConns.N++
-	stats.WSConns.Unlock()
+	s.WSConns.Lock()
+	s.WSConns.N++
+	s.WSConns.Unlock()
 
 	ticker := time.NewTicker(time.Second * 1)
 	defer ticker.Stop()
@@ -58,27 +58,27 @@ func Writer(conn *websocket.Conn) {
 	log.Info().Str("remoteAddr", conn.RemoteAddr().String()).Msg("Sending statistics through the WebSocket")
 	// Send data to client every 1 sec
 	for range ticker.C {
-		stats.Current.RLock()
+		s.RLock()
 		data := msg{
 			Type: "stat",
 			Payload: d{
-				&stats.Current.TasksStats,
-				&stats.Current.RaspberryStats,
+				&s.TasksStats,
+				&s.RaspberryStats,
 			},
 		}
 
 		jsonData, err := json.Marshal(data)
 		if err != nil {
 			log.Error().Err(err).Msg("")
-			stats.Current.RUnlock()
+			s.RUnlock()
 
 			// Decrease the counter of connections
-			stats.WSConns.Lock()
-			stats.WSConns.N--
-			stats.WSConns.Unlock()
+			s.WSConns.Lock()
+			s.WSConns.N--
+			s.WSConns.Unlock()
 			return
 		}
-		stats.Current.RUnlock()
+		s.RUnlock()
 
 		// Send data
 		err = conn.WriteMessage(websocket.TextMessage, jsonData)
@@ -87,9 +87,9 @@ func Writer(conn *websocket.Conn) {
 				log.Error().Err(err).Msg("")
 
 				// Decrease the counter of connections
-				stats.WSConns.Lock()
-				stats.WSConns.N--
-				stats.WSConns.Unlock()
+				s.WSConns.Lock()
+				s.WSConns.N--
+				s.WSConns.Unlock()
 				return
 			}
 
@@ -97,9 +97,9 @@ func Writer(conn *websocket.Conn) {
 				Str("remoteAddr", conn.RemoteAddr().String()).
 				Msg("The client has closed the WebSocket connection")
 			// Decrease the counter of connections
-			stats.WSConns.Lock()
-			stats.WSConns.N--
-			stats.WSConns.Unlock()
+			s.WSConns.Lock()
+			s.WSConns.N--
+			s.WSConns.Unlock()
 			return
 		}
 	}